@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"sync"
 
 	"github.com/alibaba/sentinel-golang/core/base"
@@ -16,6 +17,7 @@ var entryOptsPool = sync.Pool{
 			slotChain:    nil,                //
 			args:         nil,                //
 			attachments:  nil,                //
+			ctx:          nil,                //
 		}
 	},
 }
@@ -29,6 +31,7 @@ type EntryOptions struct {
 	slotChain    *base.SlotChain             //
 	args         []interface{}               //
 	attachments  map[interface{}]interface{} //
+	ctx          context.Context             // 调用方的上下文
 }
 
 func (o *EntryOptions) Reset() {
@@ -39,6 +42,7 @@ func (o *EntryOptions) Reset() {
 	o.slotChain = nil                   //
 	o.args = o.args[:0]                 //
 	o.attachments = nil                 //
+	o.ctx = nil                         //
 }
 
 type EntryOption func(*EntryOptions)
@@ -87,6 +91,13 @@ func WithAttachments(data map[interface{}]interface{}) EntryOption {
 	}
 }
 
+// WithContext sets the context of the caller, the waiting in the slots (e.g. the bulkhead) is canceled when the context is done.
+func WithContext(ctx context.Context) EntryOption {
+	return func(opts *EntryOptions) {
+		opts.ctx = ctx
+	}
+}
+
 // Entry 入站流量的入口
 func Entry(resource string, opts ...EntryOption) (*base.SentinelEntry, *base.BlockError) {
 	options := entryOptsPool.Get().(*EntryOptions)
@@ -123,6 +134,7 @@ func entry(resource string, options *EntryOptions) (*base.SentinelEntry, *base.B
 	if len(options.attachments) != 0 {
 		ctx.Input.Attachments = options.attachments
 	}
+	ctx.Input.Context = options.ctx
 	e := base.NewSentinelEntry(ctx, rw, sc)
 	ctx.SetEntry(e)
 	r := sc.Entry(ctx) // 主逻辑
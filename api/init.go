@@ -6,7 +6,9 @@ import (
 	"net/http"
 
 	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
 	"github.com/alibaba/sentinel-golang/core/log/metric"
+	"github.com/alibaba/sentinel-golang/core/stat/base"
 	"github.com/alibaba/sentinel-golang/core/system_metric"
 	metric_exporter "github.com/alibaba/sentinel-golang/exporter/metric"
 	"github.com/alibaba/sentinel-golang/util"
@@ -67,6 +69,9 @@ func InitWithConfigFile(configPath string) error {
 }
 
 func initCoreComponents() error {
+	if err := base.SetCounterType(config.StatCounterType(), config.StatCounterStripes()); err != nil {
+		return err
+	}
 	if config.MetricLogFlushIntervalSec() > 0 {
 		if err := metric.InitTask(); err != nil {
 			return err
@@ -77,6 +82,11 @@ func initCoreComponents() error {
 	loadStatInterval := systemStatInterval
 	cpuStatInterval := systemStatInterval
 	memStatInterval := systemStatInterval
+	goroutineStatInterval := systemStatInterval
+	heapStatInterval := systemStatInterval
+	gcStatInterval := systemStatInterval
+	schedLatencyStatInterval := systemStatInterval
+	customSignalInterval := systemStatInterval
 
 	if config.LoadStatCollectIntervalMs() > 0 {
 		loadStatInterval = config.LoadStatCollectIntervalMs()
@@ -87,7 +97,25 @@ func initCoreComponents() error {
 	if config.MemoryStatCollectIntervalMs() > 0 {
 		memStatInterval = config.MemoryStatCollectIntervalMs()
 	}
+	if config.GoroutineStatCollectIntervalMs() > 0 {
+		goroutineStatInterval = config.GoroutineStatCollectIntervalMs()
+	}
+	if config.HeapStatCollectIntervalMs() > 0 {
+		heapStatInterval = config.HeapStatCollectIntervalMs()
+	}
+	if config.GcStatCollectIntervalMs() > 0 {
+		gcStatInterval = config.GcStatCollectIntervalMs()
+	}
+	if config.SchedLatencyStatCollectIntervalMs() > 0 {
+		schedLatencyStatInterval = config.SchedLatencyStatCollectIntervalMs()
+	}
+	if config.CustomSignalCollectIntervalMs() > 0 {
+		customSignalInterval = config.CustomSignalCollectIntervalMs()
+	}
 
+	if err := system_metric.InitCgroupReader(config.CgroupMode(), config.CgroupRootPath()); err != nil {
+		return err
+	}
 	if loadStatInterval > 0 {
 		system_metric.InitLoadCollector(loadStatInterval)
 	}
@@ -97,11 +125,30 @@ func initCoreComponents() error {
 	if memStatInterval > 0 {
 		system_metric.InitMemoryCollector(memStatInterval)
 	}
+	if goroutineStatInterval > 0 {
+		system_metric.InitGoroutinesCollector(goroutineStatInterval)
+	}
+	if heapStatInterval > 0 {
+		system_metric.InitHeapInUseCollector(heapStatInterval)
+	}
+	if gcStatInterval > 0 {
+		system_metric.InitGcCollector(gcStatInterval)
+	}
+	if schedLatencyStatInterval > 0 {
+		system_metric.InitSchedLatencyCollector(schedLatencyStatInterval)
+	}
+	if customSignalInterval > 0 {
+		system_metric.InitCustomSignalCollector(customSignalInterval)
+	}
 
 	if config.UseCacheTime() {
 		util.StartTimeTicker()
 	}
 
+	if interval := config.HotSpotTopKParamsReportIntervalMs(); interval > 0 {
+		hotspot.InitTopKParamsReporter(interval, int(config.HotSpotTopKParamsCount()))
+	}
+
 	if config.MetricExportHTTPAddr() != "" {
 		httpAddr := config.MetricExportHTTPAddr()
 		httpPath := config.MetricExportHTTPPath()
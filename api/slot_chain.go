@@ -28,7 +28,9 @@ func BuildDefaultSlotChain() *base.SlotChain {
 	sc.AddRuleCheckSlot(circuitbreaker.DefaultSlot) // 断路器
 
 	sc.AddStatSlot(stat.DefaultSlot)
+	sc.AddStatSlot(system.DefaultAdaptiveStatSlot)       // 系统自适应
 	sc.AddStatSlot(flow.DefaultStandaloneStatSlot)       // 流量控制
+	sc.AddStatSlot(isolation.DefaultStatSlot)            // 并发控制
 	sc.AddStatSlot(hotspot.DefaultConcurrencyStatSlot)   // 热点
 	sc.AddStatSlot(circuitbreaker.DefaultMetricStatSlot) // 断路器
 	return sc
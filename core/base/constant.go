@@ -9,3 +9,10 @@ const (
 	DefaultIntervalMsTotal   uint32 = 10000        // default 10s (total length)
 	DefaultStatisticMaxRt           = int64(60000) // 最大的请求时间
 )
+
+const (
+	// CounterTypeAtomic 表示统计桶的每个指标使用单个原子计数.
+	CounterTypeAtomic = "atomic"
+	// CounterTypeStriped 表示统计桶的每个指标使用分段计数, 降低高并发下的竞争.
+	CounterTypeStriped = "striped"
+)
@@ -1,6 +1,10 @@
 package base
 
-import "github.com/alibaba/sentinel-golang/util"
+import (
+	"context"
+
+	"github.com/alibaba/sentinel-golang/util"
+)
 
 type EntryContext struct {
 	entry           *SentinelEntry
@@ -63,12 +67,15 @@ type SentinelInput struct {
 	Flag        int32
 	Args        []interface{}
 	Attachments map[interface{}]interface{} // 当调用context in slot时，在此上下文中存储一些值.
+	// Context is the context of the caller, the slots which may wait (e.g. the bulkhead) stop waiting when it's done.
+	Context context.Context
 }
 
 func (i *SentinelInput) reset() {
 	i.BatchCount = 1
 	i.Flag = 0
 	i.Args = i.Args[:0]
+	i.Context = nil
 	if len(i.Attachments) != 0 {
 		i.Attachments = make(map[interface{}]interface{})
 	}
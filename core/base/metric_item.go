@@ -1,6 +1,7 @@
 package base
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
@@ -23,6 +24,10 @@ type MetricItem struct {
 	AvgRt           uint64
 	OccupiedPassQps uint64
 	Concurrency     uint32
+	P50Rt           uint64
+	P90Rt           uint64
+	P99Rt           uint64
+	MaxRt           uint64
 }
 
 type MetricItemRetriever interface {
@@ -34,10 +39,11 @@ func (m *MetricItem) ToFatString() (string, error) {
 	timeStr := util.FormatTimeMillis(m.Timestamp)
 	// All "|" in the resource name will be replaced with "_"
 	finalName := strings.ReplaceAll(m.Resource, "|", "_")
-	_, err := fmt.Fprintf(&b, "%d|%s|%s|%d|%d|%d|%d|%d|%d|%d|%d",
+	_, err := fmt.Fprintf(&b, "%d|%s|%s|%d|%d|%d|%d|%d|%d|%d|%d|%d|%d|%d|%d",
 		m.Timestamp, timeStr, finalName, m.PassQps,
 		m.BlockQps, m.CompleteQps, m.ErrorQps, m.AvgRt,
-		m.OccupiedPassQps, m.Concurrency, m.Classification)
+		m.OccupiedPassQps, m.Concurrency, m.Classification,
+		m.P50Rt, m.P90Rt, m.P99Rt, m.MaxRt)
 	if err != nil {
 		return "", err
 	}
@@ -47,16 +53,90 @@ func (m *MetricItem) ToFatString() (string, error) {
 func (m *MetricItem) ToThinString() (string, error) {
 	b := strings.Builder{}
 	finalName := strings.ReplaceAll(m.Resource, "|", "_")
-	_, err := fmt.Fprintf(&b, "%d|%s|%d|%d|%d|%d|%d|%d|%d|%d",
+	_, err := fmt.Fprintf(&b, "%d|%s|%d|%d|%d|%d|%d|%d|%d|%d|%d|%d|%d|%d",
 		m.Timestamp, finalName, m.PassQps,
 		m.BlockQps, m.CompleteQps, m.ErrorQps, m.AvgRt,
-		m.OccupiedPassQps, m.Concurrency, m.Classification)
+		m.OccupiedPassQps, m.Concurrency, m.Classification,
+		m.P50Rt, m.P90Rt, m.P99Rt, m.MaxRt)
 	if err != nil {
 		return "", err
 	}
 	return b.String(), nil
 }
 
+// metricItemJson 是 MetricItem 在JSON行格式中的表示, 字段按名称读写, 未知字段会被忽略, 缺失字段为零值.
+type metricItemJson struct {
+	Timestamp       uint64 `json:"timestamp"`
+	Time            string `json:"time,omitempty"`
+	Resource        string `json:"resource"`
+	Classification  int32  `json:"classification"`
+	PassQps         uint64 `json:"passQps"`
+	BlockQps        uint64 `json:"blockQps"`
+	CompleteQps     uint64 `json:"completeQps"`
+	ErrorQps        uint64 `json:"errorQps"`
+	AvgRt           uint64 `json:"avgRt"`
+	OccupiedPassQps uint64 `json:"occupiedPassQps"`
+	Concurrency     uint32 `json:"concurrency"`
+	P50Rt           uint64 `json:"p50Rt"`
+	P90Rt           uint64 `json:"p90Rt"`
+	P99Rt           uint64 `json:"p99Rt"`
+	MaxRt           uint64 `json:"maxRt"`
+}
+
+// ToJsonString 将 MetricItem 转换为单行JSON, 用于JSON行(NDJSON)格式的指标日志.
+func (m *MetricItem) ToJsonString() (string, error) {
+	b, err := json.Marshal(&metricItemJson{
+		Timestamp:       m.Timestamp,
+		Time:            util.FormatTimeMillis(m.Timestamp),
+		Resource:        m.Resource,
+		Classification:  m.Classification,
+		PassQps:         m.PassQps,
+		BlockQps:        m.BlockQps,
+		CompleteQps:     m.CompleteQps,
+		ErrorQps:        m.ErrorQps,
+		AvgRt:           m.AvgRt,
+		OccupiedPassQps: m.OccupiedPassQps,
+		Concurrency:     m.Concurrency,
+		P50Rt:           m.P50Rt,
+		P90Rt:           m.P90Rt,
+		P99Rt:           m.P99Rt,
+		MaxRt:           m.MaxRt,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func MetricItemFromJsonString(line string) (*MetricItem, error) {
+	if len(line) == 0 {
+		return nil, errors.New("invalid metric line: empty string")
+	}
+	j := &metricItemJson{}
+	if err := json.Unmarshal([]byte(line), j); err != nil {
+		return nil, err
+	}
+	if j.Timestamp == 0 {
+		return nil, errors.New("invalid metric line: absent timestamp")
+	}
+	return &MetricItem{
+		Resource:        j.Resource,
+		Classification:  j.Classification,
+		Timestamp:       j.Timestamp,
+		PassQps:         j.PassQps,
+		BlockQps:        j.BlockQps,
+		CompleteQps:     j.CompleteQps,
+		ErrorQps:        j.ErrorQps,
+		AvgRt:           j.AvgRt,
+		OccupiedPassQps: j.OccupiedPassQps,
+		Concurrency:     j.Concurrency,
+		P50Rt:           j.P50Rt,
+		P90Rt:           j.P90Rt,
+		P99Rt:           j.P99Rt,
+		MaxRt:           j.MaxRt,
+	}, nil
+}
+
 func MetricItemFromFatString(line string) (*MetricItem, error) {
 	if len(line) == 0 {
 		return nil, errors.New("invalid metric line: empty string")
@@ -119,5 +199,17 @@ func MetricItemFromFatString(line string) (*MetricItem, error) {
 		}
 		item.Classification = int32(cl)
 	}
+	// The RT percentiles are absent in the legacy metric logs.
+	rtFields := []*uint64{&item.P50Rt, &item.P90Rt, &item.P99Rt, &item.MaxRt}
+	for i, f := range rtFields {
+		if len(arr) < 12+i {
+			break
+		}
+		v, err := strconv.ParseUint(arr[11+i], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		*f = v
+	}
 	return item, nil
 }
@@ -30,6 +30,13 @@ type ReadStat interface {
 	AvgRT() float64                           // 平均请求时间
 }
 
+// RtDistributionStat 是 ReadStat 的可选扩展, 提供请求时间的分布统计, 通过类型断言使用.
+type RtDistributionStat interface {
+	MaxRT() float64 // 最大的请求时间
+	// RtPercentile 返回请求时间的百分位估计值, percentile 的有效范围为 (0.0, 100.0], 如 99.0 表示P99.
+	RtPercentile(percentile float64) float64
+}
+
 func NopReadStat() *nopReadStat {
 	return globalNopReadStat
 }
@@ -57,6 +64,14 @@ func (rs *nopReadStat) AvgRT() float64 {
 	return 0.0
 }
 
+func (rs *nopReadStat) MaxRT() float64 {
+	return 0.0
+}
+
+func (rs *nopReadStat) RtPercentile(_ float64) float64 {
+	return 0.0
+}
+
 type WriteStat interface {
 	AddCount(event MetricEvent, count int64) // 将给定的计数添加到提供的MetricEvent的度量中.
 }
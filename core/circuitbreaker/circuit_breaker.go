@@ -1,6 +1,8 @@
 package circuitbreaker
 
 import (
+	"math"
+	"math/rand"
 	"sync/atomic"
 
 	"github.com/alibaba/sentinel-golang/core/base"
@@ -62,6 +64,11 @@ func (s *State) String() string {
 	}
 }
 
+// MarshalText implements encoding.TextMarshaler, so that State is readable in JSON.
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
 func (s *State) get() State {
 	return State(atomic.LoadInt32((*int32)(s)))
 }
@@ -80,6 +87,24 @@ type StateChangeListener interface {
 	OnTransformToHalfOpen(prev State, rule Rule)                   // -> 半开
 }
 
+// BackoffStateChangeListener is an optional extension of StateChangeListener.
+// If a registered listener implements it, OnTransformToOpenWithBackoff will be invoked instead of
+// OnTransformToOpen, carrying the retry timeout (after backoff, in milliseconds) of the current open period.
+type BackoffStateChangeListener interface {
+	StateChangeListener
+	OnTransformToOpenWithBackoff(prev State, rule Rule, snapshot interface{}, retryTimeoutMs uint32)
+}
+
+func notifyTransformToOpen(prev State, rule Rule, snapshot interface{}, retryTimeoutMs uint32) {
+	for _, listener := range stateChangeListeners {
+		if l, ok := listener.(BackoffStateChangeListener); ok {
+			l.OnTransformToOpenWithBackoff(prev, rule, snapshot, retryTimeoutMs)
+			continue
+		}
+		listener.OnTransformToOpen(prev, rule, snapshot)
+	}
+}
+
 type CircuitBreaker interface {
 	BoundRule() *Rule                    // 返回相关的断路规则。
 	BoundStat() interface{}              // 返回相关的统计数据结构。
@@ -98,7 +123,10 @@ type circuitBreakerBase struct {
 	// retryTimeoutMs represents recovery timeout (in milliseconds) before the circuit breaker opens.
 	// During the open period, no requests are permitted until the timeout has elapsed.
 	// After that, the circuit breaker will transform to half-open state for trying a few "trial" requests.
-	retryTimeoutMs       uint32
+	retryTimeoutMs uint32
+	// curRetryTimeoutMs represents the retry timeout after backoff, it grows on each failed probe
+	// and will be reset to retryTimeoutMs when the circuit breaker is closed.
+	curRetryTimeoutMs    uint32
 	nextRetryTimestampMs uint64 // 下一次进行探测的时间
 	probeNumber          uint64 // 当断路器半开时允许通过的探测请求数。
 	curProbeNumber       uint64 // 当前探测数量
@@ -118,7 +146,50 @@ func (b *circuitBreakerBase) retryTimeoutArrived() bool {
 }
 
 func (b *circuitBreakerBase) updateNextRetryTimestamp() {
-	atomic.StoreUint64(&b.nextRetryTimestampMs, util.CurrentTimeMillis()+uint64(b.retryTimeoutMs))
+	atomic.StoreUint64(&b.nextRetryTimestampMs, util.CurrentTimeMillis()+b.jitteredRetryTimeout())
+}
+
+// currentRetryTimeout returns the retry timeout (in milliseconds) after backoff.
+func (b *circuitBreakerBase) currentRetryTimeout() uint32 {
+	return atomic.LoadUint32(&b.curRetryTimeoutMs)
+}
+
+// backoffRetryTimeout grows the retry timeout by the backoff multiplier of the rule.
+func (b *circuitBreakerBase) backoffRetryTimeout() {
+	multiplier := b.rule.RetryTimeoutBackoffMultiplier
+	if multiplier <= 1.0 {
+		return
+	}
+	upper := float64(math.MaxUint32)
+	if b.rule.MaxRetryTimeoutMs > 0 {
+		upper = float64(b.rule.MaxRetryTimeoutMs)
+	}
+	next := math.Min(float64(b.currentRetryTimeout())*multiplier, upper)
+	atomic.StoreUint32(&b.curRetryTimeoutMs, uint32(next))
+}
+
+// resetRetryTimeout resets the backed-off retry timeout to the origin retry timeout of the rule.
+func (b *circuitBreakerBase) resetRetryTimeout() {
+	atomic.StoreUint32(&b.curRetryTimeoutMs, b.retryTimeoutMs)
+}
+
+func (b *circuitBreakerBase) jitteredRetryTimeout() uint64 {
+	timeout := float64(b.currentRetryTimeout())
+	jitter := b.rule.RetryTimeoutJitter
+	if jitter <= 0.0 {
+		return uint64(timeout)
+	}
+	timeout = timeout * (1.0 + jitter*(2.0*rand.Float64()-1.0))
+	if timeout < 0 {
+		return 0
+	}
+	return uint64(timeout)
+}
+
+// overridden checks whether the state of the circuit breaker is overridden manually,
+// the state machine of the circuit breaker doesn't transform during the override.
+func (b *circuitBreakerBase) overridden() bool {
+	return isOverridden(b.rule.Resource)
 }
 
 // 添加当前探测数量
@@ -134,12 +205,10 @@ func (b *circuitBreakerBase) resetCurProbeNum() {
 // fromClosedToOpen 更新断路器状态机由闭合状态变为开启状态。
 // 仅当当前goroutine成功完成转换时返回true。
 func (b *circuitBreakerBase) fromClosedToOpen(snapshot interface{}) bool {
-	if b.state.cas(Closed, Open) {
+	if !b.overridden() && b.state.cas(Closed, Open) {
 		b.updateNextRetryTimestamp()
-		for _, listener := range stateChangeListeners {
-			listener.OnTransformToOpen(Closed, *b.rule, snapshot)
-		}
-		stateChangedCounter.Add(float64(1), b.BoundRule().Resource, "Closed", "Open")
+		notifyTransformToOpen(Closed, *b.rule, snapshot, b.currentRetryTimeout())
+		recordStateChange(b.rule, Closed, Open, snapshot)
 		return true
 	}
 	return false
@@ -147,7 +216,7 @@ func (b *circuitBreakerBase) fromClosedToOpen(snapshot interface{}) bool {
 
 // fromOpenToHalfOpen 将断路器状态机从开到半开。
 func (b *circuitBreakerBase) fromOpenToHalfOpen(ctx *base.EntryContext) bool {
-	if b.state.cas(Open, HalfOpen) {
+	if !b.overridden() && b.state.cas(Open, HalfOpen) {
 		for _, listener := range stateChangeListeners {
 			listener.OnTransformToHalfOpen(Open, *b.rule)
 		}
@@ -157,19 +226,42 @@ func (b *circuitBreakerBase) fromOpenToHalfOpen(ctx *base.EntryContext) bool {
 			logging.Error(errors.New("nil entry"), "Nil entry in circuitBreakerBase.fromOpenToHalfOpen()", "rule", b.rule)
 		} else {
 			// add hook for entry exit
-			// if the current circuit breaker performs the probe through this entry, but the entry was blocked,
-			// this hook will guarantee current circuit breaker state machine will rollback to Open from Half-Open
+			// if the current circuit breaker performs the probe through this entry, but the entry was blocked
+			// or its error is ignored, this hook will guarantee current circuit breaker state machine will
+			// rollback to Open from Half-Open. Only the probe entry rollbacks, the ignored errors of the other
+			// requests completed during Half-Open don't affect the state.
 			entry.WhenExit(func(entry *base.SentinelEntry, ctx *base.EntryContext) error {
-				if ctx.IsBlocked() && b.state.cas(HalfOpen, Open) {
-					for _, listener := range stateChangeListeners {
-						listener.OnTransformToOpen(HalfOpen, *b.rule, 1.0)
-					}
+				if ctx.IsBlocked() || b.isIgnoredError(ctx.Err()) {
+					b.rollbackProbe()
 				}
 				return nil
 			})
 		}
 
-		stateChangedCounter.Add(float64(1), b.BoundRule().Resource, "Open", "HalfOpen")
+		recordStateChange(b.rule, Open, HalfOpen, nil)
+		return true
+	}
+	return false
+}
+
+// isIgnoredError checks whether the error is ignored by the circuit breaker of the error strategies.
+func (b *circuitBreakerBase) isIgnoredError(err error) bool {
+	switch b.rule.Strategy {
+	case ErrorRatio, ErrorCount, ConsecutiveErrors:
+		return classifyError(b.rule, err) == ErrorClassIgnored
+	default:
+		return false
+	}
+}
+
+// rollbackProbe rollbacks the circuit breaker state machine from HalfOpen to Open without
+// updating the retry timestamp, so that the next request could probe again.
+// It is used when the probe entry is blocked or its error is ignored.
+func (b *circuitBreakerBase) rollbackProbe() bool {
+	if !b.overridden() && b.state.cas(HalfOpen, Open) {
+		b.resetCurProbeNum()
+		notifyTransformToOpen(HalfOpen, *b.rule, 1.0, b.currentRetryTimeout())
+		recordStateChange(b.rule, HalfOpen, Open, 1.0)
 		return true
 	}
 	return false
@@ -178,13 +270,12 @@ func (b *circuitBreakerBase) fromOpenToHalfOpen(ctx *base.EntryContext) bool {
 // fromHalfOpenToOpen 将断路器状态机从半开状态更新为开状态。
 // 仅当当前goroutine成功完成转换时返回true。
 func (b *circuitBreakerBase) fromHalfOpenToOpen(snapshot interface{}) bool {
-	if b.state.cas(HalfOpen, Open) {
+	if !b.overridden() && b.state.cas(HalfOpen, Open) {
 		b.resetCurProbeNum()
+		b.backoffRetryTimeout()
 		b.updateNextRetryTimestamp()
-		for _, listener := range stateChangeListeners {
-			listener.OnTransformToOpen(HalfOpen, *b.rule, snapshot)
-		}
-		stateChangedCounter.Add(float64(1), b.BoundRule().Resource, "HalfOpen", "Open")
+		notifyTransformToOpen(HalfOpen, *b.rule, snapshot, b.currentRetryTimeout())
+		recordStateChange(b.rule, HalfOpen, Open, snapshot)
 		return true
 	}
 	return false
@@ -193,13 +284,14 @@ func (b *circuitBreakerBase) fromHalfOpenToOpen(snapshot interface{}) bool {
 // fromHalfOpenToOpen 将断路器状态机从半开状态更新为闭合状态
 // 仅当当前goroutine成功完成转换时返回true。
 func (b *circuitBreakerBase) fromHalfOpenToClosed() bool {
-	if b.state.cas(HalfOpen, Closed) {
+	if !b.overridden() && b.state.cas(HalfOpen, Closed) {
 		b.resetCurProbeNum()
+		b.resetRetryTimeout()
 		for _, listener := range stateChangeListeners { // 触发所有监听者
 			listener.OnTransformToClosed(HalfOpen, *b.rule)
 		}
 
-		stateChangedCounter.Add(float64(1), b.BoundRule().Resource, "HalfOpen", "Closed")
+		recordStateChange(b.rule, HalfOpen, Closed, nil)
 		return true
 	}
 	return false
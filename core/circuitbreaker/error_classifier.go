@@ -0,0 +1,141 @@
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrorClass indicates how an error traced by the entry is counted by the error-based circuit breakers.
+type ErrorClass uint8
+
+const (
+	// ErrorClassFailure means the error counts as a failed request.
+	ErrorClassFailure ErrorClass = iota
+	// ErrorClassIgnored means the request is ignored by the circuit breaker statistic.
+	ErrorClassIgnored
+	// ErrorClassSuccess means the error counts as a succeed request.
+	ErrorClassSuccess
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassFailure:
+		return "Failure"
+	case ErrorClassIgnored:
+		return "Ignored"
+	case ErrorClassSuccess:
+		return "Success"
+	default:
+		return "Undefined"
+	}
+}
+
+// ErrorClassifier classifies the non-nil error traced by the entry.
+type ErrorClassifier func(err error) ErrorClass
+
+const (
+	// ContextCanceledClassifierName is the registered name of ContextCanceledClassifier.
+	ContextCanceledClassifierName = "contextCanceled"
+)
+
+var (
+	errorClassifiers         = make(map[string]ErrorClassifier)
+	resourceErrorClassifiers = make(map[string]ErrorClassifier)
+	classifierMux            = new(sync.RWMutex)
+)
+
+func init() {
+	errorClassifiers[ContextCanceledClassifierName] = ContextCanceledClassifier
+}
+
+// ContextCanceledClassifier ignores the errors caused by context cancellation,
+// all the other errors count as failures.
+func ContextCanceledClassifier(err error) ErrorClass {
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassIgnored
+	}
+	return ErrorClassFailure
+}
+
+// RegisterErrorClassifier registers the named error classifier, which could be referenced by Rule.ErrorClassifierName.
+// The classifier must be registered before loading the rules referencing it, otherwise the rules are invalid.
+func RegisterErrorClassifier(name string, classifier ErrorClassifier) error {
+	if len(name) == 0 {
+		return errors.New("empty classifier name")
+	}
+	if classifier == nil {
+		return errors.New("nil classifier")
+	}
+	classifierMux.Lock()
+	defer classifierMux.Unlock()
+
+	errorClassifiers[name] = classifier
+	return nil
+}
+
+// RemoveErrorClassifier removes the named error classifier.
+func RemoveErrorClassifier(name string) {
+	classifierMux.Lock()
+	defer classifierMux.Unlock()
+
+	delete(errorClassifiers, name)
+}
+
+// GetErrorClassifier returns the registered error classifier of the given name.
+func GetErrorClassifier(name string) (ErrorClassifier, bool) {
+	classifierMux.RLock()
+	defer classifierMux.RUnlock()
+
+	classifier, ok := errorClassifiers[name]
+	return classifier, ok
+}
+
+func isErrorClassifierRegistered(name string) bool {
+	_, ok := GetErrorClassifier(name)
+	return ok
+}
+
+// SetResourceErrorClassifier sets the error classifier for all the circuit breakers of the given resource.
+// The classifier referenced by Rule.ErrorClassifierName takes precedence over the resource level classifier.
+func SetResourceErrorClassifier(res string, classifier ErrorClassifier) error {
+	if len(res) == 0 {
+		return errors.New("empty resource")
+	}
+	if classifier == nil {
+		return errors.New("nil classifier")
+	}
+	classifierMux.Lock()
+	defer classifierMux.Unlock()
+
+	resourceErrorClassifiers[res] = classifier
+	return nil
+}
+
+// RemoveResourceErrorClassifier removes the error classifier of the given resource.
+func RemoveResourceErrorClassifier(res string) {
+	classifierMux.Lock()
+	defer classifierMux.Unlock()
+
+	delete(resourceErrorClassifiers, res)
+}
+
+// classifyError classifies the error for the given rule.
+// The nil error always counts as success, and the non-nil error counts as failure if there is no available classifier.
+func classifyError(r *Rule, err error) ErrorClass {
+	if err == nil {
+		return ErrorClassSuccess
+	}
+	classifierMux.RLock()
+	classifier, ok := errorClassifiers[r.ErrorClassifierName]
+	if !ok {
+		classifier = resourceErrorClassifiers[r.Resource]
+	}
+	classifierMux.RUnlock()
+
+	if classifier == nil {
+		return ErrorClassFailure
+	}
+	return classifier(err)
+}
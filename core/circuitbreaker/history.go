@@ -0,0 +1,172 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultStateTransitionHistoryCapacity = 64
+)
+
+// StateTransition records a state transition of the circuit breaker.
+type StateTransition struct {
+	TimestampMs uint64   `json:"timestamp"`
+	Resource    string   `json:"resource"`
+	RuleId      string   `json:"ruleId,omitempty"`
+	Strategy    Strategy `json:"strategy"`
+	FromState   State    `json:"fromState"`
+	ToState     State    `json:"toState"`
+	// Snapshot is the statistic value which triggers the transition,
+	// e.g. error ratio, slow request ratio or error count. It is nil for the transitions to HalfOpen and Closed.
+	Snapshot interface{} `json:"snapshot,omitempty"`
+}
+
+// transitionRing is the bounded ring buffer of state transitions.
+type transitionRing struct {
+	records []StateTransition
+	next    int
+	full    bool
+}
+
+func (r *transitionRing) add(t StateTransition) {
+	r.records[r.next] = t
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the records in chronological order.
+func (r *transitionRing) list() []StateTransition {
+	if !r.full {
+		ret := make([]StateTransition, r.next)
+		copy(ret, r.records[:r.next])
+		return ret
+	}
+	ret := make([]StateTransition, 0, len(r.records))
+	ret = append(ret, r.records[r.next:]...)
+	return append(ret, r.records[:r.next]...)
+}
+
+var (
+	transitionHistory         = make(map[string]*transitionRing)
+	transitionHistoryCapacity = DefaultStateTransitionHistoryCapacity
+	transitionHistoryMux      = new(sync.RWMutex)
+)
+
+// recordStateChange records the state transition to the metric exporter and the transition history.
+func recordStateChange(rule *Rule, from, to State, snapshot interface{}) {
+	stateChangedCounter.Add(float64(1), rule.Resource, from.String(), to.String())
+
+	t := StateTransition{
+		TimestampMs: util.CurrentTimeMillis(),
+		Resource:    rule.Resource,
+		RuleId:      rule.Id,
+		Strategy:    rule.Strategy,
+		FromState:   from,
+		ToState:     to,
+		Snapshot:    snapshot,
+	}
+	transitionHistoryMux.Lock()
+	defer transitionHistoryMux.Unlock()
+
+	if transitionHistoryCapacity <= 0 {
+		return
+	}
+	ring, ok := transitionHistory[rule.Resource]
+	if !ok {
+		ring = &transitionRing{records: make([]StateTransition, transitionHistoryCapacity)}
+		transitionHistory[rule.Resource] = ring
+	}
+	ring.add(t)
+}
+
+// SetStateTransitionHistoryCapacity sets the max count of state transitions kept for each resource,
+// 0 means disabling the transition history. All the previous history will be cleared.
+func SetStateTransitionHistoryCapacity(capacity int) error {
+	if capacity < 0 {
+		return errors.New("negative capacity")
+	}
+	transitionHistoryMux.Lock()
+	defer transitionHistoryMux.Unlock()
+
+	transitionHistoryCapacity = capacity
+	transitionHistory = make(map[string]*transitionRing)
+	return nil
+}
+
+// GetStateTransitionHistory returns the state transitions of the resource in chronological order.
+// The history is removed once all the circuit breakers of the resource are removed by the rule update.
+func GetStateTransitionHistory(resource string) []StateTransition {
+	transitionHistoryMux.RLock()
+	defer transitionHistoryMux.RUnlock()
+
+	ring, ok := transitionHistory[resource]
+	if !ok {
+		return nil
+	}
+	return ring.list()
+}
+
+// GetAllStateTransitionHistory returns the state transitions of all the resources.
+func GetAllStateTransitionHistory() map[string][]StateTransition {
+	transitionHistoryMux.RLock()
+	defer transitionHistoryMux.RUnlock()
+
+	ret := make(map[string][]StateTransition, len(transitionHistory))
+	for res, ring := range transitionHistory {
+		ret[res] = ring.list()
+	}
+	return ret
+}
+
+// ClearStateTransitionHistory clears the state transition history of all the resources.
+func ClearStateTransitionHistory() {
+	transitionHistoryMux.Lock()
+	defer transitionHistoryMux.Unlock()
+
+	transitionHistory = make(map[string]*transitionRing)
+}
+
+// pruneStateTransitionHistory removes the history of the resources which have no circuit breakers.
+func pruneStateTransitionHistory(resBreakers map[string][]CircuitBreaker) {
+	transitionHistoryMux.Lock()
+	defer transitionHistoryMux.Unlock()
+
+	for res := range transitionHistory {
+		if len(resBreakers[res]) == 0 {
+			delete(transitionHistory, res)
+		}
+	}
+}
+
+// removeStateTransitionHistory removes the history of the resource.
+func removeStateTransitionHistory(resource string) {
+	transitionHistoryMux.Lock()
+	defer transitionHistoryMux.Unlock()
+
+	delete(transitionHistory, resource)
+}
+
+// StateTransitionHistoryHandler returns the debug http.Handler which responds the state transition history in JSON.
+// The optional query parameter "resource" filters the history of the given resource.
+func StateTransitionHistoryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var history interface{}
+		if res := req.URL.Query().Get("resource"); len(res) > 0 {
+			history = map[string][]StateTransition{res: GetStateTransitionHistory(res)}
+		} else {
+			history = GetAllStateTransitionHistory()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(history); err != nil {
+			logging.Warn("[CircuitBreaker] Fail to encode the state transition history", "err", err.Error())
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package circuitbreaker
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	metric_exporter "github.com/alibaba/sentinel-golang/exporter/metric"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+// Override represents the manual override of all the circuit breakers of the resource.
+// The overridden state takes precedence over the state computed by circuit breakers until it expires,
+// and the state machines of the circuit breakers don't transform during the override.
+type Override struct {
+	Resource string
+	// State is the forced state, either Open or Closed.
+	State State
+	// ExpireTimestampMs is the timestamp (in milliseconds) when the override expires.
+	ExpireTimestampMs uint64
+}
+
+func (o *Override) expired() bool {
+	return util.CurrentTimeMillis() >= o.ExpireTimestampMs
+}
+
+// overrideSweepInterval is the interval to remove the expired overrides.
+const overrideSweepInterval = 100 * time.Millisecond
+
+var (
+	overrides   = make(map[string]*Override)
+	overrideMux = new(sync.RWMutex)
+	// overrideCount is the count of overrides, which is used to skip the lookup in the fast path.
+	overrideCount int32
+	// overrideSweeping indicates whether the sweeper of expired overrides is running, guarded by overrideMux.
+	overrideSweeping bool
+
+	overrideGauge = metric_exporter.NewGauge(
+		"circuit_breaker_override",
+		"Circuit breaker manual override state, 1 means the override is active",
+		[]string{"resource", "state"})
+)
+
+func init() {
+	metric_exporter.Register(overrideGauge)
+}
+
+// ForceOpen forces all the circuit breakers of the resource to be open during the given ttl,
+// all the requests of the resource will be blocked.
+func ForceOpen(resource string, ttl time.Duration) error {
+	return setOverride(resource, Open, ttl)
+}
+
+// ForceClose forces all the circuit breakers of the resource to be closed during the given ttl,
+// the circuit breakers still record the completed requests but never block or transform their states.
+func ForceClose(resource string, ttl time.Duration) error {
+	return setOverride(resource, Closed, ttl)
+}
+
+// ClearOverride clears the override of the resource, the circuit breakers fall back to the computed state.
+// It returns false if there is no active override of the resource.
+func ClearOverride(resource string) bool {
+	overrideMux.RLock()
+	o, ok := overrides[resource]
+	overrideMux.RUnlock()
+	if !ok {
+		return false
+	}
+	return removeOverride(o)
+}
+
+// GetOverride returns a copy of the active override of the resource.
+func GetOverride(resource string) (Override, bool) {
+	o := activeOverride(resource)
+	if o == nil {
+		return Override{}, false
+	}
+	return *o, true
+}
+
+func setOverride(resource string, state State, ttl time.Duration) error {
+	if len(resource) == 0 {
+		return errors.New("empty resource")
+	}
+	if ttl <= 0 {
+		return errors.New("invalid ttl")
+	}
+	o := &Override{
+		Resource:          resource,
+		State:             state,
+		ExpireTimestampMs: util.CurrentTimeMillis() + uint64(ttl.Milliseconds()),
+	}
+
+	overrideMux.Lock()
+	prev, exist := overrides[resource]
+	overrides[resource] = o
+	if !exist {
+		atomic.AddInt32(&overrideCount, 1)
+	}
+	startOverrideSweeperLocked()
+	overrideMux.Unlock()
+
+	if exist {
+		overrideGauge.Set(0, resource, prev.State.String())
+	}
+	overrideGauge.Set(1, resource, o.State.String())
+	for _, cb := range getBreakersOfResource(resource) {
+		from := cb.CurrentState()
+		if exist && !prev.expired() {
+			from = prev.State
+		}
+		notifyStateChange(from, state, cb.BoundRule(), o, overrideRetryTimeoutMs(ttl))
+	}
+	logging.Info("[CircuitBreaker] Circuit breakers were overridden", "resource", resource, "state", o.State.String(), "ttl", ttl)
+	return nil
+}
+
+// overrideRetryTimeoutMs returns the retry timeout (in milliseconds) notified to the listeners when the override is set,
+// which is the ttl clamped to the max value of uint32.
+func overrideRetryTimeoutMs(ttl time.Duration) uint32 {
+	ms := ttl.Milliseconds()
+	if ms > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(ms)
+}
+
+// startOverrideSweeperLocked starts the sweeper which removes the overrides once they expire,
+// so that the state change listeners and the override gauge are notified even if there is no traffic.
+// The sweeper stops when there is no override. It must be called with overrideMux held.
+func startOverrideSweeperLocked() {
+	if overrideSweeping {
+		return
+	}
+	overrideSweeping = true
+	ticker := util.NewTicker(overrideSweepInterval)
+	go util.RunWithRecover(func() {
+		defer ticker.Stop()
+		for range ticker.C() {
+			if !sweepExpiredOverrides() {
+				return
+			}
+		}
+	})
+}
+
+// sweepExpiredOverrides removes the expired overrides, it returns false if there is no override left to sweep.
+func sweepExpiredOverrides() bool {
+	overrideMux.Lock()
+	if len(overrides) == 0 {
+		overrideSweeping = false
+		overrideMux.Unlock()
+		return false
+	}
+	expired := make([]*Override, 0)
+	for _, o := range overrides {
+		if o.expired() {
+			expired = append(expired, o)
+		}
+	}
+	overrideMux.Unlock()
+
+	for _, o := range expired {
+		removeOverride(o)
+	}
+	return true
+}
+
+// activeOverride returns the active override of the resource, the expired override will be removed
+// if the sweeper hasn't removed it yet.
+func activeOverride(resource string) *Override {
+	if atomic.LoadInt32(&overrideCount) == 0 {
+		return nil
+	}
+	overrideMux.RLock()
+	o, ok := overrides[resource]
+	overrideMux.RUnlock()
+	if !ok {
+		return nil
+	}
+	if o.expired() {
+		removeOverride(o)
+		return nil
+	}
+	return o
+}
+
+// removeOverride removes the given override if it is still the current override of the resource,
+// and notifies the state change from the overridden state to the computed state.
+func removeOverride(o *Override) bool {
+	overrideMux.Lock()
+	cur, ok := overrides[o.Resource]
+	if !ok || cur != o {
+		overrideMux.Unlock()
+		return false
+	}
+	delete(overrides, o.Resource)
+	atomic.AddInt32(&overrideCount, -1)
+	overrideMux.Unlock()
+
+	overrideGauge.Set(0, o.Resource, o.State.String())
+	for _, cb := range getBreakersOfResource(o.Resource) {
+		notifyStateChange(o.State, cb.CurrentState(), cb.BoundRule(), o, retryTimeoutOf(cb))
+	}
+	logging.Info("[CircuitBreaker] Circuit breaker override was removed", "resource", o.Resource, "state", o.State.String())
+	return true
+}
+
+// retryTimeoutOf returns the current retry timeout (in milliseconds, after backoff) of the circuit breaker,
+// the retry timeout of the rule is returned if the circuit breaker doesn't back off.
+func retryTimeoutOf(cb CircuitBreaker) uint32 {
+	if b, ok := cb.(interface{ currentRetryTimeout() uint32 }); ok {
+		return b.currentRetryTimeout()
+	}
+	return cb.BoundRule().RetryTimeoutMs
+}
+
+// isOverridden checks whether there is an active override of the resource.
+func isOverridden(resource string) bool {
+	return activeOverride(resource) != nil
+}
+
+func notifyStateChange(from, to State, rule *Rule, snapshot interface{}, retryTimeoutMs uint32) {
+	if from == to {
+		return
+	}
+	switch to {
+	case Closed:
+		for _, listener := range stateChangeListeners {
+			listener.OnTransformToClosed(from, *rule)
+		}
+	case HalfOpen:
+		for _, listener := range stateChangeListeners {
+			listener.OnTransformToHalfOpen(from, *rule)
+		}
+	case Open:
+		notifyTransformToOpen(from, *rule, snapshot, retryTimeoutMs)
+	default:
+		return
+	}
+	recordStateChange(rule, from, to, snapshot)
+}
@@ -12,6 +12,9 @@ const (
 	SlowRequestRatio Strategy = iota // 策略根据慢请求比改变断路器状态
 	ErrorRatio
 	ErrorCount
+	ConsecutiveErrors // 策略根据连续错误数改变断路器状态
+	ConsecutiveSlow   // 策略根据连续慢请求数改变断路器状态
+	SlowPercentile    // 策略根据响应时间百分位改变断路器状态
 )
 
 func (s Strategy) String() string {
@@ -22,6 +25,12 @@ func (s Strategy) String() string {
 		return "ErrorRatio"
 	case ErrorCount:
 		return "ErrorCount"
+	case ConsecutiveErrors:
+		return "ConsecutiveErrors"
+	case ConsecutiveSlow:
+		return "ConsecutiveSlow"
+	case SlowPercentile:
+		return "SlowPercentile"
 	default:
 		return "Undefined"
 	}
@@ -42,14 +51,33 @@ type Rule struct {
 	// for SlowRequestRatio, it represents the max slow request ratio
 	// for ErrorRatio, it represents the max error request ratio
 	// for ErrorCount, it represents the max error request count
+	// for ConsecutiveErrors, it represents the max consecutive error request count
+	// for ConsecutiveSlow, it represents the max consecutive slow request count
 	Threshold float64 `json:"threshold"`
 	ProbeNum  uint64  `json:"probeNum"` // 探测数量
+	// RetryTimeoutBackoffMultiplier represents the multiplier applied to the retry timeout
+	// each time a probe fails (HalfOpen -> Open). The retry timeout will be reset to RetryTimeoutMs
+	// once the circuit breaker is closed. Values not greater than 1 disable the backoff.
+	RetryTimeoutBackoffMultiplier float64 `json:"retryTimeoutBackoffMultiplier"`
+	// MaxRetryTimeoutMs represents the upper bound (in milliseconds) of the backed-off retry timeout.
+	// 0 means no upper bound.
+	MaxRetryTimeoutMs uint32 `json:"maxRetryTimeoutMs"`
+	// RetryTimeoutJitter represents the random jitter ratio (valid range: [0.0, 1.0)) applied to the retry timeout,
+	// e.g. 0.1 means the real retry timeout will be randomized in [0.9*timeout, 1.1*timeout].
+	RetryTimeoutJitter float64 `json:"retryTimeoutJitter"`
+	// ErrorClassifierName represents the name of the registered ErrorClassifier,
+	// which decides how the errors are counted by ErrorRatio and ErrorCount circuit breakers.
+	ErrorClassifierName string `json:"errorClassifierName,omitempty"`
+	// Percentile represents the response time percentile (valid range: (0.0, 100.0]) of SlowPercentile strategy,
+	// e.g. 99 means the circuit breaker opens once the P99 response time exceeds MaxAllowedRtMs.
+	Percentile float64 `json:"percentile,omitempty"`
 }
 
 func (r *Rule) String() string {
 	// fallback string
-	return fmt.Sprintf("{id=%s, resource=%s, strategy=%s, RetryTimeoutMs=%d, MinRequestAmount=%d, StatIntervalMs=%d, StatSlidingWindowBucketCount=%d, MaxAllowedRtMs=%d, Threshold=%f}",
-		r.Id, r.Resource, r.Strategy, r.RetryTimeoutMs, r.MinRequestAmount, r.StatIntervalMs, r.StatSlidingWindowBucketCount, r.MaxAllowedRtMs, r.Threshold)
+	return fmt.Sprintf("{id=%s, resource=%s, strategy=%s, RetryTimeoutMs=%d, MinRequestAmount=%d, StatIntervalMs=%d, StatSlidingWindowBucketCount=%d, MaxAllowedRtMs=%d, Threshold=%f, RetryTimeoutBackoffMultiplier=%f, MaxRetryTimeoutMs=%d, RetryTimeoutJitter=%f, ErrorClassifierName=%s, Percentile=%f}",
+		r.Id, r.Resource, r.Strategy, r.RetryTimeoutMs, r.MinRequestAmount, r.StatIntervalMs, r.StatSlidingWindowBucketCount, r.MaxAllowedRtMs, r.Threshold,
+		r.RetryTimeoutBackoffMultiplier, r.MaxRetryTimeoutMs, r.RetryTimeoutJitter, r.ErrorClassifierName, r.Percentile)
 }
 
 func (r *Rule) isStatReusable(newRule *Rule) bool {
@@ -69,7 +97,9 @@ func (r *Rule) isEqualsToBase(newRule *Rule) bool {
 		return false
 	}
 	return r.Resource == newRule.Resource && r.Strategy == newRule.Strategy && r.RetryTimeoutMs == newRule.RetryTimeoutMs &&
-		r.MinRequestAmount == newRule.MinRequestAmount && r.StatIntervalMs == newRule.StatIntervalMs && r.StatSlidingWindowBucketCount == newRule.StatSlidingWindowBucketCount
+		r.MinRequestAmount == newRule.MinRequestAmount && r.StatIntervalMs == newRule.StatIntervalMs && r.StatSlidingWindowBucketCount == newRule.StatSlidingWindowBucketCount &&
+		util.Float64Equals(r.RetryTimeoutBackoffMultiplier, newRule.RetryTimeoutBackoffMultiplier) && r.MaxRetryTimeoutMs == newRule.MaxRetryTimeoutMs &&
+		util.Float64Equals(r.RetryTimeoutJitter, newRule.RetryTimeoutJitter)
 }
 
 func (r *Rule) isEqualsTo(newRule *Rule) bool {
@@ -81,9 +111,15 @@ func (r *Rule) isEqualsTo(newRule *Rule) bool {
 	case SlowRequestRatio:
 		return r.MaxAllowedRtMs == newRule.MaxAllowedRtMs && util.Float64Equals(r.Threshold, newRule.Threshold)
 	case ErrorRatio:
-		return util.Float64Equals(r.Threshold, newRule.Threshold)
+		return util.Float64Equals(r.Threshold, newRule.Threshold) && r.ErrorClassifierName == newRule.ErrorClassifierName
 	case ErrorCount:
-		return util.Float64Equals(r.Threshold, newRule.Threshold)
+		return util.Float64Equals(r.Threshold, newRule.Threshold) && r.ErrorClassifierName == newRule.ErrorClassifierName
+	case ConsecutiveErrors:
+		return util.Float64Equals(r.Threshold, newRule.Threshold) && r.ErrorClassifierName == newRule.ErrorClassifierName
+	case ConsecutiveSlow:
+		return r.MaxAllowedRtMs == newRule.MaxAllowedRtMs && util.Float64Equals(r.Threshold, newRule.Threshold)
+	case SlowPercentile:
+		return r.MaxAllowedRtMs == newRule.MaxAllowedRtMs && util.Float64Equals(r.Percentile, newRule.Percentile)
 	default:
 		return false
 	}
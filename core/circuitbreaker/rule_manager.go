@@ -67,6 +67,38 @@ func init() {
 		}
 		return newErrorCountCircuitBreakerWithStat(r, stat), nil
 	}
+
+	consecutiveGenFunc := func(r *Rule, reuseStat interface{}) (CircuitBreaker, error) {
+		if r == nil {
+			return nil, errors.New("nil rule")
+		}
+		if reuseStat == nil {
+			return newConsecutiveFailureCircuitBreaker(r), nil
+		}
+		stat, ok := reuseStat.(*consecutiveCounter)
+		if !ok || stat == nil {
+			logging.Warn("[CircuitBreaker RuleManager] Expect to generate circuit breaker with reuse statistic, but fail to do type assertion, expect:*consecutiveCounter", "statType", reflect.TypeOf(stat).Name())
+			return newConsecutiveFailureCircuitBreaker(r), nil
+		}
+		return newConsecutiveFailureCircuitBreakerWithStat(r, stat), nil
+	}
+	cbGenFuncMap[ConsecutiveErrors] = consecutiveGenFunc
+	cbGenFuncMap[ConsecutiveSlow] = consecutiveGenFunc
+
+	cbGenFuncMap[SlowPercentile] = func(r *Rule, reuseStat interface{}) (CircuitBreaker, error) {
+		if r == nil {
+			return nil, errors.New("nil rule")
+		}
+		if reuseStat == nil {
+			return newSlowPercentileCircuitBreaker(r)
+		}
+		stat, ok := reuseStat.(*rtHistogramLeapArray)
+		if !ok || stat == nil {
+			logging.Warn("[CircuitBreaker RuleManager] Expect to generate circuit breaker with reuse statistic, but fail to do type assertion, expect:*rtHistogramLeapArray", "statType", reflect.TypeOf(stat).Name())
+			return newSlowPercentileCircuitBreaker(r)
+		}
+		return newSlowPercentileCircuitBreakerWithStat(r, stat), nil
+	}
 }
 
 // GetRulesOfResource returns specific resource's rules based on copy.
@@ -257,6 +289,7 @@ func onRuleUpdate(rawResRulesMap map[string][]*Rule) (err error) {
 	breakers = newBreakers
 	updateMux.Unlock()
 	currentRules = rawResRulesMap
+	pruneStateTransitionHistory(newBreakers)
 
 	logging.Debug("[CircuitBreaker onRuleUpdate] Time statistics(ns) for updating circuit breaker rule", "timeCost", util.CurrentTimeNano()-start)
 	logRuleUpdate(validResRulesMap)
@@ -301,6 +334,9 @@ func onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
 	}
 	updateMux.Unlock()
 	currentRules[res] = rawResRules
+	if len(newCbsOfRes) == 0 {
+		removeStateTransitionHistory(res)
+	}
 
 	logging.Debug("[CircuitBreaker onResourceRuleUpdate] Time statistics(ns) for updating circuit breaker rule", "timeCost", util.CurrentTimeNano()-start)
 	logging.Info("[CircuitBreaker] load resource level rules", "resource", res, "validResRules", validResRules)
@@ -356,7 +392,7 @@ func SetCircuitBreakerGenerator(s Strategy, generator CircuitBreakerGenFunc) err
 	if generator == nil {
 		return errors.New("nil generator")
 	}
-	if s <= ErrorCount {
+	if s <= SlowPercentile {
 		return errors.New("not allowed to replace the generator for default circuit breaking strategies")
 	}
 	updateMux.Lock()
@@ -367,7 +403,7 @@ func SetCircuitBreakerGenerator(s Strategy, generator CircuitBreakerGenFunc) err
 }
 
 func RemoveCircuitBreakerGenerator(s Strategy) error {
-	if s <= ErrorCount {
+	if s <= SlowPercentile {
 		return errors.New("not allowed to remove the generator for default circuit breaking strategies")
 	}
 	updateMux.Lock()
@@ -436,7 +472,9 @@ func IsValidRule(r *Rule) error {
 	if len(r.Resource) == 0 {
 		return errors.New("empty resource name")
 	}
-	if r.StatIntervalMs <= 0 {
+	isConsecutive := r.Strategy == ConsecutiveErrors || r.Strategy == ConsecutiveSlow
+	// the consecutive failure strategies don't rely on the statistic window
+	if r.StatIntervalMs <= 0 && !isConsecutive {
 		return errors.New("invalid StatIntervalMs")
 	}
 	if r.RetryTimeoutMs <= 0 {
@@ -451,7 +489,25 @@ func IsValidRule(r *Rule) error {
 	if r.Strategy == ErrorRatio && r.Threshold > 1.0 {
 		return errors.New("invalid error ratio threshold (valid range: [0.0, 1.0])")
 	}
-	if r.StatSlidingWindowBucketCount != 0 && r.StatIntervalMs%r.StatSlidingWindowBucketCount != 0 {
+	if r.Strategy == SlowPercentile && (r.Percentile <= 0.0 || r.Percentile > 100.0) {
+		return errors.New("invalid Percentile (valid range: (0.0, 100.0])")
+	}
+	if isConsecutive && r.Threshold < 1.0 {
+		return errors.New("invalid consecutive failure count threshold (valid range: [1, +inf))")
+	}
+	if r.RetryTimeoutBackoffMultiplier < 0.0 {
+		return errors.New("invalid RetryTimeoutBackoffMultiplier")
+	}
+	if r.MaxRetryTimeoutMs > 0 && r.MaxRetryTimeoutMs < r.RetryTimeoutMs {
+		return errors.New("invalid MaxRetryTimeoutMs, it must not be less than RetryTimeoutMs")
+	}
+	if r.RetryTimeoutJitter < 0.0 || r.RetryTimeoutJitter >= 1.0 {
+		return errors.New("invalid RetryTimeoutJitter (valid range: [0.0, 1.0))")
+	}
+	if len(r.ErrorClassifierName) > 0 && !isErrorClassifierRegistered(r.ErrorClassifierName) {
+		return errors.Errorf("unregistered ErrorClassifierName: %s", r.ErrorClassifierName)
+	}
+	if !isConsecutive && r.StatSlidingWindowBucketCount != 0 && r.StatIntervalMs%r.StatSlidingWindowBucketCount != 0 {
 		logging.Warn("[CircuitBreaker IsValidRule] The following must be true: StatIntervalMs % StatSlidingWindowBucketCount == 0. StatSlidingWindowBucketCount will be replaced by 1", "rule", r)
 	}
 	return nil
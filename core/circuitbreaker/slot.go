@@ -25,6 +25,21 @@ func (b *Slot) Check(ctx *base.EntryContext) *base.TokenResult {
 	if len(resource) == 0 {
 		return result
 	}
+	if o := activeOverride(resource); o != nil {
+		if o.State == Open {
+			msg := "circuit breaker forced open"
+			var rule base.SentinelRule
+			if breakers := getBreakersOfResource(resource); len(breakers) > 0 {
+				rule = breakers[0].BoundRule()
+			}
+			if result == nil {
+				result = base.NewTokenResultBlockedWithCause(base.BlockTypeCircuitBreaking, msg, rule, o)
+			} else {
+				result.ResetToBlockedWithCause(base.BlockTypeCircuitBreaking, msg, rule, o)
+			}
+		}
+		return result
+	}
 	if passed, rule := checkPass(ctx); !passed {
 		msg := "circuit breaker check blocked"
 		if result == nil {
@@ -0,0 +1,197 @@
+package circuitbreaker
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	sbase "github.com/alibaba/sentinel-golang/core/stat/base"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/pkg/errors"
+)
+
+// ================================= slowPercentileCircuitBreaker ====================================
+// slowPercentileCircuitBreaker opens once the response time at the given percentile
+// over the statistic window exceeds MaxAllowedRtMs.
+type slowPercentileCircuitBreaker struct {
+	circuitBreakerBase
+	stat             *rtHistogramLeapArray
+	maxAllowedRt     uint64
+	percentile       float64
+	minRequestAmount uint64
+	// scratch is the histogram reused to aggregate the buckets of the statistic window, guarded by scratchMux.
+	scratch    *sbase.Histogram
+	scratchMux sync.Mutex
+}
+
+func newSlowPercentileCircuitBreakerWithStat(r *Rule, stat *rtHistogramLeapArray) *slowPercentileCircuitBreaker {
+	return &slowPercentileCircuitBreaker{
+		circuitBreakerBase: circuitBreakerBase{
+			rule:                 r,
+			retryTimeoutMs:       r.RetryTimeoutMs,
+			curRetryTimeoutMs:    r.RetryTimeoutMs,
+			nextRetryTimestampMs: 0,
+			state:                newState(),
+			probeNumber:          r.ProbeNum,
+		},
+		stat:             stat,
+		maxAllowedRt:     r.MaxAllowedRtMs,
+		percentile:       r.Percentile,
+		minRequestAmount: r.MinRequestAmount,
+		scratch:          sbase.NewHistogram(),
+	}
+}
+
+func newSlowPercentileCircuitBreaker(r *Rule) (*slowPercentileCircuitBreaker, error) {
+	interval := r.StatIntervalMs
+	bucketCount := getRuleStatSlidingWindowBucketCount(r)
+	stat := &rtHistogramLeapArray{}
+	leapArray, err := sbase.NewLeapArray(bucketCount, interval, stat)
+	if err != nil {
+		return nil, err
+	}
+	stat.data = leapArray
+
+	return newSlowPercentileCircuitBreakerWithStat(r, stat), nil
+}
+
+func (b *slowPercentileCircuitBreaker) BoundStat() interface{} {
+	return b.stat
+}
+
+// TryPass checks circuit breaker based on state machine of circuit breaker.
+func (b *slowPercentileCircuitBreaker) TryPass(ctx *base.EntryContext) bool {
+	curStatus := b.CurrentState()
+	if curStatus == Closed {
+		return true
+	} else if curStatus == Open {
+		// switch state to half-open to probe if retry timeout
+		if b.retryTimeoutArrived() && b.fromOpenToHalfOpen(ctx) {
+			return true
+		}
+	} else if curStatus == HalfOpen && b.probeNumber > 0 {
+		return true
+	}
+	return false
+}
+
+func (b *slowPercentileCircuitBreaker) OnRequestComplete(rt uint64, _ error) {
+	metricStat := b.stat
+	histogram, curErr := metricStat.currentHistogram()
+	if curErr != nil {
+		logging.Error(curErr, "Fail to get current histogram in slowPercentileCircuitBreaker#OnRequestComplete().",
+			"rule", b.rule)
+		return
+	}
+	histogram.Record(rt)
+
+	// handleStateChange
+	curStatus := b.CurrentState()
+	if curStatus == Open {
+		return
+	} else if curStatus == HalfOpen {
+		if rt > b.maxAllowedRt {
+			// fail to probe
+			b.fromHalfOpenToOpen(rt)
+		} else {
+			b.addCurProbeNum()
+			if b.probeNumber == 0 || atomic.LoadUint64(&b.curProbeNumber) >= b.probeNumber {
+				// succeed to probe
+				b.fromHalfOpenToClosed()
+				b.resetMetric()
+			}
+		}
+		return
+	}
+
+	// current state is CLOSED
+	// Each request evaluates the statistic window including its own response time,
+	// so that the slow request completed concurrently is never missed.
+	observed, ok := b.observePercentile()
+	if ok && observed > b.maxAllowedRt {
+		curStatus = b.CurrentState()
+		switch curStatus {
+		case Closed:
+			b.fromClosedToOpen(observed)
+		case HalfOpen:
+			b.fromHalfOpenToOpen(observed)
+		default:
+		}
+	}
+}
+
+// observePercentile aggregates the buckets of the statistic window into the scratch histogram,
+// and returns the response time at the percentile. It returns false if the requests are not enough.
+func (b *slowPercentileCircuitBreaker) observePercentile() (uint64, bool) {
+	b.scratchMux.Lock()
+	defer b.scratchMux.Unlock()
+
+	b.scratch.Reset()
+	for _, h := range b.stat.allHistogram() {
+		b.scratch.Merge(h)
+	}
+	if b.scratch.Count() < b.minRequestAmount {
+		return 0, false
+	}
+	return b.scratch.Percentile(b.percentile), true
+}
+
+func (b *slowPercentileCircuitBreaker) resetMetric() {
+	for _, h := range b.stat.allHistogram() {
+		h.Reset()
+	}
+}
+
+// rtHistogramLeapArray is the leap array whose buckets are response time histograms.
+type rtHistogramLeapArray struct {
+	data *sbase.LeapArray
+}
+
+func (s *rtHistogramLeapArray) NewEmptyBucket() interface{} {
+	return sbase.NewHistogram()
+}
+
+func (s *rtHistogramLeapArray) ResetBucketTo(bw *sbase.BucketWrap, startTime uint64) *sbase.BucketWrap {
+	atomic.StoreUint64(&bw.BucketStart, startTime)
+	bw.Value.Store(sbase.NewHistogram())
+	return bw
+}
+
+func (s *rtHistogramLeapArray) currentHistogram() (*sbase.Histogram, error) {
+	curBucket, err := s.data.CurrentBucket(s)
+	if err != nil {
+		return nil, err
+	}
+	if curBucket == nil {
+		return nil, errors.New("nil BucketWrap")
+	}
+	mb := curBucket.Value.Load()
+	if mb == nil {
+		return nil, errors.New("nil Histogram")
+	}
+	histogram, ok := mb.(*sbase.Histogram)
+	if !ok {
+		return nil, errors.Errorf("bucket fail to do type assert, expect: *base.Histogram, in fact: %s", reflect.TypeOf(mb).Name())
+	}
+	return histogram, nil
+}
+
+func (s *rtHistogramLeapArray) allHistogram() []*sbase.Histogram {
+	buckets := s.data.Values()
+	ret := make([]*sbase.Histogram, 0, len(buckets))
+	for _, b := range buckets {
+		mb := b.Value.Load()
+		if mb == nil {
+			logging.Error(errors.New("current bucket atomic Value is nil"), "Current bucket atomic Value is nil in rtHistogramLeapArray.allHistogram()")
+			continue
+		}
+		histogram, ok := mb.(*sbase.Histogram)
+		if !ok {
+			logging.Error(errors.New("bucket data type error"), "Bucket data type error in rtHistogramLeapArray.allHistogram()", "expect type", "*base.Histogram", "actual type", reflect.TypeOf(mb).Name())
+			continue
+		}
+		ret = append(ret, histogram)
+	}
+	return ret
+}
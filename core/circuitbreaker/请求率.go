@@ -24,6 +24,7 @@ func newSlowRtCircuitBreakerWithStat(r *Rule, stat *slowRequestLeapArray) *slowR
 		circuitBreakerBase: circuitBreakerBase{
 			rule:                 r,
 			retryTimeoutMs:       r.RetryTimeoutMs,
+			curRetryTimeoutMs:    r.RetryTimeoutMs,
 			nextRetryTimestampMs: 0,
 			state:                newState(),
 			probeNumber:          r.ProbeNum,
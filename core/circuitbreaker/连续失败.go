@@ -0,0 +1,113 @@
+package circuitbreaker
+
+import (
+	"sync/atomic"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+)
+
+// ================================= consecutiveFailureCircuitBreaker ====================================
+// consecutiveFailureCircuitBreaker opens once the count of consecutive failures reaches the threshold,
+// regardless of the statistic window and MinRequestAmount.
+// For ConsecutiveErrors, the failure is the request that completes with an error classified as ErrorClassFailure.
+// For ConsecutiveSlow, the failure is the request whose response time exceeds MaxAllowedRtMs.
+type consecutiveFailureCircuitBreaker struct {
+	circuitBreakerBase
+	threshold    uint64
+	maxAllowedRt uint64
+
+	stat *consecutiveCounter
+}
+
+// consecutiveCounter records the count of consecutive failures.
+type consecutiveCounter struct {
+	count uint64
+}
+
+func (c *consecutiveCounter) reset() {
+	atomic.StoreUint64(&c.count, 0)
+}
+
+func newConsecutiveFailureCircuitBreakerWithStat(r *Rule, stat *consecutiveCounter) *consecutiveFailureCircuitBreaker {
+	return &consecutiveFailureCircuitBreaker{
+		circuitBreakerBase: circuitBreakerBase{
+			rule:                 r,
+			retryTimeoutMs:       r.RetryTimeoutMs,
+			curRetryTimeoutMs:    r.RetryTimeoutMs,
+			nextRetryTimestampMs: 0,
+			state:                newState(),
+			probeNumber:          r.ProbeNum,
+		},
+		threshold:    uint64(r.Threshold),
+		maxAllowedRt: r.MaxAllowedRtMs,
+		stat:         stat,
+	}
+}
+
+func newConsecutiveFailureCircuitBreaker(r *Rule) *consecutiveFailureCircuitBreaker {
+	return newConsecutiveFailureCircuitBreakerWithStat(r, &consecutiveCounter{})
+}
+
+func (b *consecutiveFailureCircuitBreaker) BoundStat() interface{} {
+	return b.stat
+}
+
+func (b *consecutiveFailureCircuitBreaker) TryPass(ctx *base.EntryContext) bool {
+	curStatus := b.CurrentState()
+	if curStatus == Closed {
+		return true
+	} else if curStatus == Open {
+		// switch state to half-open to probe if retry timeout
+		if b.retryTimeoutArrived() && b.fromOpenToHalfOpen(ctx) {
+			return true
+		}
+	} else if curStatus == HalfOpen && b.probeNumber > 0 {
+		return true
+	}
+	return false
+}
+
+func (b *consecutiveFailureCircuitBreaker) classify(rt uint64, err error) ErrorClass {
+	if b.rule.Strategy == ConsecutiveSlow {
+		if rt > b.maxAllowedRt {
+			return ErrorClassFailure
+		}
+		return ErrorClassSuccess
+	}
+	return classifyError(b.rule, err)
+}
+
+func (b *consecutiveFailureCircuitBreaker) OnRequestComplete(rt uint64, err error) {
+	class := b.classify(rt, err)
+	if class == ErrorClassIgnored {
+		// The ignored probe is rolled back by the exit hook of the probe entry.
+		return
+	}
+
+	curStatus := b.CurrentState()
+	if curStatus == Open {
+		return
+	}
+	if curStatus == HalfOpen {
+		if class == ErrorClassSuccess {
+			b.addCurProbeNum()
+			if b.probeNumber == 0 || atomic.LoadUint64(&b.curProbeNumber) >= b.probeNumber {
+				b.fromHalfOpenToClosed()
+				b.stat.reset()
+			}
+		} else {
+			b.fromHalfOpenToOpen(atomic.LoadUint64(&b.stat.count))
+		}
+		return
+	}
+
+	// current state is CLOSED
+	if class == ErrorClassSuccess {
+		b.stat.reset()
+		return
+	}
+	failures := atomic.AddUint64(&b.stat.count, 1)
+	if failures >= b.threshold {
+		b.fromClosedToOpen(failures)
+	}
+}
@@ -21,6 +21,7 @@ func newErrorCountCircuitBreakerWithStat(r *Rule, stat *errorCounterLeapArray) *
 		circuitBreakerBase: circuitBreakerBase{
 			rule:                 r,
 			retryTimeoutMs:       r.RetryTimeoutMs,
+			curRetryTimeoutMs:    r.RetryTimeoutMs,
 			nextRetryTimestampMs: 0,
 			state:                newState(),
 			probeNumber:          r.ProbeNum,
@@ -64,6 +65,11 @@ func (b *errorCountCircuitBreaker) TryPass(ctx *base.EntryContext) bool {
 
 // OnRequestComplete 会更新对应的状态
 func (b *errorCountCircuitBreaker) OnRequestComplete(_ uint64, err error) {
+	errClass := classifyError(b.rule, err)
+	if errClass == ErrorClassIgnored {
+		// The ignored probe is rolled back by the exit hook of the probe entry.
+		return
+	}
 	metricStat := b.stat
 	counter, curErr := metricStat.currentCounter() // 当前时间所对应的bucket的计数
 	if curErr != nil {
@@ -71,7 +77,7 @@ func (b *errorCountCircuitBreaker) OnRequestComplete(_ uint64, err error) {
 			"rule", b.rule)
 		return
 	}
-	if err != nil {
+	if errClass == ErrorClassFailure {
 		atomic.AddUint64(&counter.errorCount, 1)
 	}
 	atomic.AddUint64(&counter.totalCount, 1)
@@ -89,7 +95,7 @@ func (b *errorCountCircuitBreaker) OnRequestComplete(_ uint64, err error) {
 		return
 	}
 	if curStatus == HalfOpen {
-		if err == nil {
+		if errClass == ErrorClassSuccess {
 			b.addCurProbeNum() // 添加当前探测数量
 			if b.probeNumber == 0 || atomic.LoadUint64(&b.curProbeNumber) >= b.probeNumber {
 				b.fromHalfOpenToClosed() // 将半开-> close
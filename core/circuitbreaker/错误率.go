@@ -24,6 +24,7 @@ func newErrorRatioCircuitBreakerWithStat(r *Rule, stat *errorCounterLeapArray) *
 		circuitBreakerBase: circuitBreakerBase{
 			rule:                 r,
 			retryTimeoutMs:       r.RetryTimeoutMs,
+			curRetryTimeoutMs:    r.RetryTimeoutMs,
 			nextRetryTimestampMs: 0,
 			state:                newState(),
 			probeNumber:          r.ProbeNum,
@@ -66,6 +67,11 @@ func (b *errorRatioCircuitBreaker) TryPass(ctx *base.EntryContext) bool {
 }
 
 func (b *errorRatioCircuitBreaker) OnRequestComplete(_ uint64, err error) {
+	errClass := classifyError(b.rule, err)
+	if errClass == ErrorClassIgnored {
+		// The ignored probe is rolled back by the exit hook of the probe entry.
+		return
+	}
 	metricStat := b.stat
 	counter, curErr := metricStat.currentCounter()
 	if curErr != nil {
@@ -73,7 +79,7 @@ func (b *errorRatioCircuitBreaker) OnRequestComplete(_ uint64, err error) {
 			"rule", b.rule)
 		return
 	}
-	if err != nil {
+	if errClass == ErrorClassFailure {
 		atomic.AddUint64(&counter.errorCount, 1)
 	}
 	atomic.AddUint64(&counter.totalCount, 1)
@@ -93,7 +99,7 @@ func (b *errorRatioCircuitBreaker) OnRequestComplete(_ uint64, err error) {
 		return
 	}
 	if curStatus == HalfOpen {
-		if err == nil {
+		if errClass == ErrorClassSuccess {
 			b.addCurProbeNum()
 			if b.probeNumber == 0 || atomic.LoadUint64(&b.curProbeNumber) >= b.probeNumber {
 				b.fromHalfOpenToClosed()
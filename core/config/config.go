@@ -210,6 +210,22 @@ func MetricLogMaxFileAmount() uint32 {
 	return globalCfg.MetricLogMaxFileAmount()
 }
 
+func MetricLogFormat() string {
+	return globalCfg.MetricLogFormat()
+}
+
+func MetricLogCompression() string {
+	return globalCfg.MetricLogCompression()
+}
+
+func MetricLogMaxAgeDays() uint32 {
+	return globalCfg.MetricLogMaxAgeDays()
+}
+
+func MetricLogTotalSizeLimit() uint64 {
+	return globalCfg.MetricLogTotalSizeLimit()
+}
+
 func SystemStatCollectIntervalMs() uint32 {
 	return globalCfg.SystemStatCollectIntervalMs()
 }
@@ -226,6 +242,34 @@ func MemoryStatCollectIntervalMs() uint32 {
 	return globalCfg.MemoryStatCollectIntervalMs()
 }
 
+func GoroutineStatCollectIntervalMs() uint32 {
+	return globalCfg.GoroutineStatCollectIntervalMs()
+}
+
+func HeapStatCollectIntervalMs() uint32 {
+	return globalCfg.HeapStatCollectIntervalMs()
+}
+
+func GcStatCollectIntervalMs() uint32 {
+	return globalCfg.GcStatCollectIntervalMs()
+}
+
+func SchedLatencyStatCollectIntervalMs() uint32 {
+	return globalCfg.SchedLatencyStatCollectIntervalMs()
+}
+
+func CustomSignalCollectIntervalMs() uint32 {
+	return globalCfg.CustomSignalCollectIntervalMs()
+}
+
+func CgroupMode() string {
+	return globalCfg.CgroupMode()
+}
+
+func CgroupRootPath() string {
+	return globalCfg.CgroupRootPath()
+}
+
 func UseCacheTime() bool {
 	return globalCfg.UseCacheTime()
 }
@@ -248,3 +292,19 @@ func MetricStatisticIntervalMs() uint32 {
 func MetricStatisticSampleCount() uint32 {
 	return globalCfg.MetricStatisticSampleCount()
 }
+
+func StatCounterType() string {
+	return globalCfg.StatCounterType()
+}
+
+func StatCounterStripes() uint32 {
+	return globalCfg.StatCounterStripes()
+}
+
+func HotSpotTopKParamsReportIntervalMs() uint32 {
+	return globalCfg.HotSpotTopKParamsReportIntervalMs()
+}
+
+func HotSpotTopKParamsCount() uint32 {
+	return globalCfg.HotSpotTopKParamsCount()
+}
@@ -14,12 +14,36 @@ const (
 	DefaultConfigFilename       = "sentinel.yml"
 	DefaultAppType        int32 = 0
 
-	DefaultMetricLogFlushIntervalSec   uint32 = 1
-	DefaultMetricLogSingleFileMaxSize  uint64 = 1024 * 1024 * 50
-	DefaultMetricLogMaxFileAmount      uint32 = 8
-	DefaultSystemStatCollectIntervalMs uint32 = 1000
-	DefaultLoadStatCollectIntervalMs   uint32 = 1000
-	DefaultCpuStatCollectIntervalMs    uint32 = 1000
-	DefaultMemoryStatCollectIntervalMs uint32 = 150
-	DefaultWarmUpColdFactor            uint32 = 3
+	DefaultMetricLogFlushIntervalSec         uint32 = 1
+	DefaultMetricLogSingleFileMaxSize        uint64 = 1024 * 1024 * 50
+	DefaultMetricLogMaxFileAmount            uint32 = 8
+	DefaultMetricLogFormat                          = MetricLogFormatFat
+	DefaultSystemStatCollectIntervalMs       uint32 = 1000
+	DefaultLoadStatCollectIntervalMs         uint32 = 1000
+	DefaultCpuStatCollectIntervalMs          uint32 = 1000
+	DefaultMemoryStatCollectIntervalMs       uint32 = 150
+	DefaultGoroutineStatCollectIntervalMs    uint32 = 1000
+	DefaultHeapStatCollectIntervalMs         uint32 = 1000
+	DefaultGcStatCollectIntervalMs           uint32 = 1000
+	DefaultSchedLatencyStatCollectIntervalMs uint32 = 1000
+	DefaultCustomSignalCollectIntervalMs     uint32 = 1000
+	DefaultWarmUpColdFactor                  uint32 = 3
+	DefaultCgroupMode                               = "disabled"
+	DefaultCgroupRootPath                           = "/sys/fs/cgroup"
+)
+
+const (
+	// MetricLogFormatFat 表示以"|"分隔的定位字段格式的指标日志.
+	MetricLogFormatFat = "fat"
+	// MetricLogFormatJson 表示JSON行(NDJSON)格式的指标日志, 每行是一个包含命名字段的JSON对象.
+	MetricLogFormatJson = "json"
+)
+
+const (
+	// MetricLogCompressionNone 表示不压缩滚动后的指标日志.
+	MetricLogCompressionNone = "none"
+	// MetricLogCompressionGzip 表示以gzip压缩滚动后的指标日志.
+	MetricLogCompressionGzip = "gzip"
+	// MetricLogCompressionZstd 表示以zstd压缩滚动后的指标日志.
+	MetricLogCompressionZstd = "zstd"
 )
@@ -46,6 +46,14 @@ type MetricLogConfig struct {
 	SingleFileMaxSize uint64 `yaml:"singleFileMaxSize"`
 	MaxFileCount      uint32 `yaml:"maxFileCount"`
 	FlushIntervalSec  uint32 `yaml:"flushIntervalSec"`
+	// Format 表示指标日志的格式: fat(默认) 或 json.
+	Format string `yaml:"format"`
+	// Compression 表示滚动后的指标日志的压缩方式: none(默认), gzip 或 zstd.
+	Compression string `yaml:"compression"`
+	// MaxAgeDays 表示指标日志的最长保留天数, 为0时不按时间清理.
+	MaxAgeDays uint32 `yaml:"maxAgeDays"`
+	// TotalSizeLimit 表示指标日志及其索引文件占用的磁盘总量上限(字节), 为0时不限制.
+	TotalSizeLimit uint64 `yaml:"totalSizeLimit"`
 }
 
 // StatConfig 表示统计信息的配置项.
@@ -59,13 +67,36 @@ type StatConfig struct {
 	MetricStatisticSampleCount uint32           `yaml:"metricStatisticSampleCount"`
 	MetricStatisticIntervalMs  uint32           `yaml:"metricStatisticIntervalMs"`
 	System                     SystemStatConfig `yaml:"system"`
+	// CounterType 表示统计桶的计数器类型: atomic(单个原子计数, 默认) 或 striped(分段计数, 降低高并发下的竞争, 但占用更多内存).
+	CounterType string `yaml:"counterType"`
+	// CounterStripes 表示分段计数器的分段数, 为0时使用GOMAXPROCS.
+	CounterStripes uint32            `yaml:"counterStripes"`
+	HotSpot        HotSpotStatConfig `yaml:"hotspot"`
+}
+
+// HotSpotStatConfig 表示热点参数统计的配置项.
+type HotSpotStatConfig struct {
+	// TopKParamsReportIntervalMs 表示导出Top-K热点参数指标的间隔, 为0(默认)时不导出, 也不记录每个参数的通过和拒绝数.
+	TopKParamsReportIntervalMs uint32 `yaml:"topKParamsReportIntervalMs"`
+	// TopKParamsCount 表示每条规则导出的热点参数数量, 为0时使用默认值.
+	TopKParamsCount uint32 `yaml:"topKParamsCount"`
 }
 
 type SystemStatConfig struct {
-	CollectIntervalMs       uint32 `yaml:"collectIntervalMs"`       // 表示系统指标收集器的收集间隔.
-	CollectLoadIntervalMs   uint32 `yaml:"collectLoadIntervalMs"`   // 表示系统负载收集器的收集间隔.
-	CollectCpuIntervalMs    uint32 `yaml:"collectCpuIntervalMs"`    // 表示系统CPU使用率收集器的收集间隔.
-	CollectMemoryIntervalMs uint32 `yaml:"collectMemoryIntervalMs"` // 表示系统内存使用收集器的收集间隔.
+	CollectIntervalMs             uint32 `yaml:"collectIntervalMs"`             // 表示系统指标收集器的收集间隔.
+	CollectLoadIntervalMs         uint32 `yaml:"collectLoadIntervalMs"`         // 表示系统负载收集器的收集间隔.
+	CollectCpuIntervalMs          uint32 `yaml:"collectCpuIntervalMs"`          // 表示系统CPU使用率收集器的收集间隔.
+	CollectMemoryIntervalMs       uint32 `yaml:"collectMemoryIntervalMs"`       // 表示系统内存使用收集器的收集间隔.
+	CollectGoroutineIntervalMs    uint32 `yaml:"collectGoroutineIntervalMs"`    // 表示goroutine数量收集器的收集间隔.
+	CollectHeapIntervalMs         uint32 `yaml:"collectHeapIntervalMs"`         // 表示堆内存使用收集器的收集间隔.
+	CollectGcIntervalMs           uint32 `yaml:"collectGcIntervalMs"`           // 表示GC停顿和GC CPU占比收集器的收集间隔.
+	CollectSchedLatencyIntervalMs uint32 `yaml:"collectSchedLatencyIntervalMs"` // 表示调度延迟收集器的收集间隔.
+	CollectCustomSignalIntervalMs uint32 `yaml:"collectCustomSignalIntervalMs"` // 表示自定义信号收集器的收集间隔.
+	// CgroupMode 表示CPU和内存指标的来源: auto(自动探测当前进程的cgroup), v1, v2, disabled(默认, 使用进程指标).
+	// cgroup文件不可访问时会回退到进程指标.
+	CgroupMode string `yaml:"cgroupMode"`
+	// CgroupRootPath 表示cgroup的挂载路径, 默认为 /sys/fs/cgroup, 仅在无法从 /proc/self/mountinfo 解析挂载点时使用.
+	CgroupRootPath string `yaml:"cgroupRootPath"`
 }
 
 func NewDefaultConfig() *Entity {
@@ -87,6 +118,8 @@ func NewDefaultConfig() *Entity {
 					SingleFileMaxSize: DefaultMetricLogSingleFileMaxSize,
 					MaxFileCount:      DefaultMetricLogMaxFileAmount,
 					FlushIntervalSec:  DefaultMetricLogFlushIntervalSec,
+					Format:            DefaultMetricLogFormat,
+					Compression:       MetricLogCompressionNone,
 				},
 			},
 			Stat: StatConfig{
@@ -94,11 +127,19 @@ func NewDefaultConfig() *Entity {
 				GlobalStatisticIntervalMsTotal:  base.DefaultIntervalMsTotal,
 				MetricStatisticSampleCount:      base.DefaultSampleCount,
 				MetricStatisticIntervalMs:       base.DefaultIntervalMs,
+				CounterType:                     base.CounterTypeAtomic,
 				System: SystemStatConfig{
-					CollectIntervalMs:       DefaultSystemStatCollectIntervalMs,
-					CollectLoadIntervalMs:   DefaultLoadStatCollectIntervalMs,
-					CollectCpuIntervalMs:    DefaultCpuStatCollectIntervalMs,
-					CollectMemoryIntervalMs: DefaultMemoryStatCollectIntervalMs,
+					CollectIntervalMs:             DefaultSystemStatCollectIntervalMs,
+					CollectLoadIntervalMs:         DefaultLoadStatCollectIntervalMs,
+					CollectCpuIntervalMs:          DefaultCpuStatCollectIntervalMs,
+					CollectMemoryIntervalMs:       DefaultMemoryStatCollectIntervalMs,
+					CollectGoroutineIntervalMs:    DefaultGoroutineStatCollectIntervalMs,
+					CollectHeapIntervalMs:         DefaultHeapStatCollectIntervalMs,
+					CollectGcIntervalMs:           DefaultGcStatCollectIntervalMs,
+					CollectSchedLatencyIntervalMs: DefaultSchedLatencyStatCollectIntervalMs,
+					CollectCustomSignalIntervalMs: DefaultCustomSignalCollectIntervalMs,
+					CgroupMode:                    DefaultCgroupMode,
+					CgroupRootPath:                DefaultCgroupRootPath,
 				},
 			},
 			UseCacheTime: false,
@@ -130,10 +171,21 @@ func checkConfValid(conf *SentinelConfig) error {
 	if mc.SingleFileMaxSize <= 0 {
 		return errors.New("Illegal metric log globalCfg: singleFileMaxSize <= 0")
 	}
+	if mc.Format != "" && mc.Format != MetricLogFormatFat && mc.Format != MetricLogFormatJson {
+		return errors.Errorf("Illegal metric log globalCfg: unknown format %s", mc.Format)
+	}
+	switch mc.Compression {
+	case "", MetricLogCompressionNone, MetricLogCompressionGzip, MetricLogCompressionZstd:
+	default:
+		return errors.Errorf("Illegal metric log globalCfg: unknown compression %s", mc.Compression)
+	}
 	if err := base.CheckValidityForReuseStatistic(conf.Stat.MetricStatisticSampleCount, conf.Stat.MetricStatisticIntervalMs,
 		conf.Stat.GlobalStatisticSampleCountTotal, conf.Stat.GlobalStatisticIntervalMsTotal); err != nil {
 		return err
 	}
+	if ct := conf.Stat.CounterType; ct != "" && ct != base.CounterTypeAtomic && ct != base.CounterTypeStriped {
+		return errors.Errorf("Illegal stat counterType: %s", ct)
+	}
 	return nil
 }
 
@@ -186,6 +238,22 @@ func (entity *Entity) MetricLogMaxFileAmount() uint32 {
 	return entity.Sentinel.Log.Metric.MaxFileCount
 }
 
+func (entity *Entity) MetricLogFormat() string {
+	return entity.Sentinel.Log.Metric.Format
+}
+
+func (entity *Entity) MetricLogCompression() string {
+	return entity.Sentinel.Log.Metric.Compression
+}
+
+func (entity *Entity) MetricLogMaxAgeDays() uint32 {
+	return entity.Sentinel.Log.Metric.MaxAgeDays
+}
+
+func (entity *Entity) MetricLogTotalSizeLimit() uint64 {
+	return entity.Sentinel.Log.Metric.TotalSizeLimit
+}
+
 func (entity *Entity) SystemStatCollectIntervalMs() uint32 {
 	return entity.Sentinel.Stat.System.CollectIntervalMs
 }
@@ -202,6 +270,34 @@ func (entity *Entity) MemoryStatCollectIntervalMs() uint32 {
 	return entity.Sentinel.Stat.System.CollectMemoryIntervalMs
 }
 
+func (entity *Entity) GoroutineStatCollectIntervalMs() uint32 {
+	return entity.Sentinel.Stat.System.CollectGoroutineIntervalMs
+}
+
+func (entity *Entity) HeapStatCollectIntervalMs() uint32 {
+	return entity.Sentinel.Stat.System.CollectHeapIntervalMs
+}
+
+func (entity *Entity) GcStatCollectIntervalMs() uint32 {
+	return entity.Sentinel.Stat.System.CollectGcIntervalMs
+}
+
+func (entity *Entity) SchedLatencyStatCollectIntervalMs() uint32 {
+	return entity.Sentinel.Stat.System.CollectSchedLatencyIntervalMs
+}
+
+func (entity *Entity) CustomSignalCollectIntervalMs() uint32 {
+	return entity.Sentinel.Stat.System.CollectCustomSignalIntervalMs
+}
+
+func (entity *Entity) CgroupMode() string {
+	return entity.Sentinel.Stat.System.CgroupMode
+}
+
+func (entity *Entity) CgroupRootPath() string {
+	return entity.Sentinel.Stat.System.CgroupRootPath
+}
+
 func (entity *Entity) UseCacheTime() bool {
 	return entity.Sentinel.UseCacheTime
 }
@@ -220,3 +316,19 @@ func (entity *Entity) MetricStatisticIntervalMs() uint32 {
 func (entity *Entity) MetricStatisticSampleCount() uint32 {
 	return entity.Sentinel.Stat.MetricStatisticSampleCount
 }
+
+func (entity *Entity) StatCounterType() string {
+	return entity.Sentinel.Stat.CounterType
+}
+
+func (entity *Entity) StatCounterStripes() uint32 {
+	return entity.Sentinel.Stat.CounterStripes
+}
+
+func (entity *Entity) HotSpotTopKParamsReportIntervalMs() uint32 {
+	return entity.Sentinel.Stat.HotSpot.TopKParamsReportIntervalMs
+}
+
+func (entity *Entity) HotSpotTopKParamsCount() uint32 {
+	return entity.Sentinel.Stat.HotSpot.TopKParamsCount
+}
@@ -87,6 +87,11 @@ type Rule struct {
 	HighMemUsageThreshold int64 `json:"highMemUsageThreshold"` // 内存高使用率时的限流阈值，该字段仅在Token计算策略是MemoryAdaptive时生效
 	MemLowWaterMarkBytes  int64 `json:"memLowWaterMarkBytes"`  // 内存低水位标记字节大小，该字段仅在Token计算策略是MemoryAdaptive时生效
 	MemHighWaterMarkBytes int64 `json:"memHighWaterMarkBytes"` // 内存高水位标记字节大小，该字段仅在Token计算策略是MemoryAdaptive时生效
+	// AdaptiveSignal 表示MemoryAdaptive策略参考的系统信号名称(见 system_metric.RegisterMetricProvider)，为空时参考内存使用字节.
+	// 设置后使用 SignalLowWaterMark 和 SignalHighWaterMark 作为水位，MemLowWaterMarkBytes 和 MemHighWaterMarkBytes 不生效.
+	AdaptiveSignal      string  `json:"adaptiveSignal,omitempty"`
+	SignalLowWaterMark  float64 `json:"signalLowWaterMark,omitempty"`  // 信号的低水位，该字段仅在设置了 AdaptiveSignal 时生效
+	SignalHighWaterMark float64 `json:"signalHighWaterMark,omitempty"` // 信号的高水位，该字段仅在设置了 AdaptiveSignal 时生效
 }
 
 func (r *Rule) isEqualsTo(newRule *Rule) bool {
@@ -100,7 +105,9 @@ func (r *Rule) isEqualsTo(newRule *Rule) bool {
 		r.MaxQueueingTimeMs == newRule.MaxQueueingTimeMs && r.WarmUpPeriodSec == newRule.WarmUpPeriodSec &&
 		r.WarmUpColdFactor == newRule.WarmUpColdFactor &&
 		r.LowMemUsageThreshold == newRule.LowMemUsageThreshold && r.HighMemUsageThreshold == newRule.HighMemUsageThreshold &&
-		r.MemLowWaterMarkBytes == newRule.MemLowWaterMarkBytes && r.MemHighWaterMarkBytes == newRule.MemHighWaterMarkBytes) {
+		r.MemLowWaterMarkBytes == newRule.MemLowWaterMarkBytes && r.MemHighWaterMarkBytes == newRule.MemHighWaterMarkBytes &&
+		r.AdaptiveSignal == newRule.AdaptiveSignal && util.Float64Equals(r.SignalLowWaterMark, newRule.SignalLowWaterMark) &&
+		util.Float64Equals(r.SignalHighWaterMark, newRule.SignalHighWaterMark)) {
 
 		return false
 	}
@@ -2,6 +2,7 @@ package flow
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"sync"
 
@@ -612,6 +613,9 @@ func IsValidRule(rule *Rule) error {
 			return errors.New("rule.HighMemUsageThreshold >= rule.LowMemUsageThreshold")
 		}
 
+		if len(rule.AdaptiveSignal) > 0 {
+			return checkSignalWaterMarks(rule)
+		}
 		if rule.MemLowWaterMarkBytes <= 0 {
 			return errors.New("rule.MemLowWaterMarkBytes <= 0")
 		}
@@ -629,3 +633,18 @@ func IsValidRule(rule *Rule) error {
 
 	return nil
 }
+
+// checkSignalWaterMarks checks the water marks of the adaptive signal, the signal could be any value (e.g. the CPU usage ratio),
+// so the water marks are not required to be positive.
+func checkSignalWaterMarks(rule *Rule) error {
+	if math.IsNaN(rule.SignalLowWaterMark) || math.IsInf(rule.SignalLowWaterMark, 0) {
+		return errors.New("invalid rule.SignalLowWaterMark")
+	}
+	if math.IsNaN(rule.SignalHighWaterMark) || math.IsInf(rule.SignalHighWaterMark, 0) {
+		return errors.New("invalid rule.SignalHighWaterMark")
+	}
+	if rule.SignalLowWaterMark >= rule.SignalHighWaterMark {
+		return errors.New("rule.SignalLowWaterMark >= rule.SignalHighWaterMark")
+	}
+	return nil
+}
@@ -6,29 +6,39 @@ import (
 )
 
 // MemoryAdaptiveTrafficShapingCalculator is a memory adaptive traffic shaping calculator
+// The watermark is the memory usage in bytes by default, whose low and high water marks are
+// Rule.MemLowWaterMarkBytes and Rule.MemHighWaterMarkBytes. If Rule.AdaptiveSignal is set,
+// the watermark is the value of the signal, whose water marks are Rule.SignalLowWaterMark and Rule.SignalHighWaterMark.
 //
 // adaptive flow control algorithm
-// If the watermark is less than Rule.MemLowWaterMarkBytes, the threshold is Rule.LowMemUsageThreshold.
-// If the watermark is greater than Rule.MemHighWaterMarkBytes, the threshold is Rule.HighMemUsageThreshold.
-// Otherwise, the threshold is ((watermark - MemLowWaterMarkBytes)/(MemHighWaterMarkBytes - MemLowWaterMarkBytes)) *
+// If the watermark is less than the low water mark, the threshold is Rule.LowMemUsageThreshold.
+// If the watermark is greater than the high water mark, the threshold is Rule.HighMemUsageThreshold.
+// Otherwise, the threshold is ((watermark - lowWaterMark)/(highWaterMark - lowWaterMark)) *
 //
 //	(HighMemUsageThreshold - LowMemUsageThreshold) + LowMemUsageThreshold.
 type MemoryAdaptiveTrafficShapingCalculator struct {
 	owner                 *TrafficShapingController
 	lowMemUsageThreshold  int64
 	highMemUsageThreshold int64
-	memLowWaterMark       int64
-	memHighWaterMark      int64
+	lowWaterMark          float64
+	highWaterMark         float64
+	signal                string
 }
 
 func NewMemoryAdaptiveTrafficShapingCalculator(owner *TrafficShapingController, r *Rule) *MemoryAdaptiveTrafficShapingCalculator {
-	return &MemoryAdaptiveTrafficShapingCalculator{
+	m := &MemoryAdaptiveTrafficShapingCalculator{
 		owner:                 owner,
 		lowMemUsageThreshold:  r.LowMemUsageThreshold,
 		highMemUsageThreshold: r.HighMemUsageThreshold,
-		memLowWaterMark:       r.MemLowWaterMarkBytes,
-		memHighWaterMark:      r.MemHighWaterMarkBytes,
+		lowWaterMark:          float64(r.MemLowWaterMarkBytes),
+		highWaterMark:         float64(r.MemHighWaterMarkBytes),
+		signal:                r.AdaptiveSignal,
 	}
+	if len(r.AdaptiveSignal) > 0 {
+		m.lowWaterMark = r.SignalLowWaterMark
+		m.highWaterMark = r.SignalHighWaterMark
+	}
+	return m
 }
 
 func (m *MemoryAdaptiveTrafficShapingCalculator) BoundOwner() *TrafficShapingController {
@@ -37,18 +47,26 @@ func (m *MemoryAdaptiveTrafficShapingCalculator) BoundOwner() *TrafficShapingCon
 
 func (m *MemoryAdaptiveTrafficShapingCalculator) CalculateAllowedTokens(_ uint32, _ int32) float64 {
 	var threshold float64
-	mem := system_metric.CurrentMemoryUsage()
-	if mem == system_metric.NotRetrievedMemoryValue {
-		logging.Warn("[MemoryAdaptiveTrafficShapingCalculator CalculateAllowedTokens]Fail to load memory usage")
+	mem, ok := m.currentWaterMark()
+	if !ok {
+		logging.Warn("[MemoryAdaptiveTrafficShapingCalculator CalculateAllowedTokens]Fail to load memory usage", "signal", m.signal)
 		return float64(m.lowMemUsageThreshold)
 	}
-	if mem <= m.memLowWaterMark {
+	if mem <= m.lowWaterMark {
 		threshold = float64(m.lowMemUsageThreshold)
-	} else if mem >= m.memHighWaterMark {
+	} else if mem >= m.highWaterMark {
 		threshold = float64(m.highMemUsageThreshold)
 	} else {
 		//动态的获取内存已使用的字节(使用工具库 gopsutil)，然后去对比当前内存使用的字节在哪一个区域内，如果在动态的波动区域内则简单的数学公式计算出对应的动态流控阈值
-		threshold = (float64(m.highMemUsageThreshold-m.lowMemUsageThreshold)/float64(m.memHighWaterMark-m.memLowWaterMark))*float64(mem-m.memLowWaterMark) + float64(m.lowMemUsageThreshold)
+		threshold = (float64(m.highMemUsageThreshold-m.lowMemUsageThreshold)/(m.highWaterMark-m.lowWaterMark))*(mem-m.lowWaterMark) + float64(m.lowMemUsageThreshold)
 	}
 	return threshold
 }
+
+func (m *MemoryAdaptiveTrafficShapingCalculator) currentWaterMark() (float64, bool) {
+	if len(m.signal) > 0 {
+		return system_metric.CurrentSignal(m.signal)
+	}
+	mem := system_metric.CurrentMemoryUsage()
+	return float64(mem), mem != system_metric.NotRetrievedMemoryValue
+}
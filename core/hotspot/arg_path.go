@@ -0,0 +1,325 @@
+package hotspot
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/pkg/errors"
+)
+
+const (
+	argsPathRoot        = "args"
+	attachmentsPathRoot = "attachments"
+)
+
+// FieldExtractor extracts the parameter from the root value (the argument or attachment) of a ParamPath.
+// It's the typed alternative to the reflection based resolution of a field path.
+type FieldExtractor func(value interface{}) interface{}
+
+type fieldExtractorKey struct {
+	typ  reflect.Type
+	path string
+}
+
+type fieldIndexKey struct {
+	typ  reflect.Type
+	name string
+}
+
+var (
+	fieldExtractors   = make(map[fieldExtractorKey]FieldExtractor)
+	fieldExtractorMux = new(sync.RWMutex)
+	// fieldIndexCache caches the index of struct field by the struct type and the field name.
+	fieldIndexCache = new(sync.Map)
+)
+
+// RegisterFieldExtractor registers the typed FieldExtractor for the given field path of the type of sample.
+// The path is the part after the root of ParamPath, e.g. "User.ID" for "args[0].User.ID", or "/user/id" for "args[0]/user/id".
+// When the root value of a ParamPath is of the type of sample and the field path matches,
+// the extractor is used instead of reflection. The result of extractor is ignored if it isn't comparable.
+func RegisterFieldExtractor(sample interface{}, path string, extractor FieldExtractor) error {
+	if sample == nil {
+		return errors.New("nil sample")
+	}
+	if extractor == nil {
+		return errors.New("nil extractor")
+	}
+	fieldExtractorMux.Lock()
+	defer fieldExtractorMux.Unlock()
+	fieldExtractors[fieldExtractorKey{typ: reflect.TypeOf(sample), path: path}] = extractor
+	return nil
+}
+
+// RemoveFieldExtractor removes the FieldExtractor registered for the given field path of the type of sample.
+func RemoveFieldExtractor(sample interface{}, path string) {
+	if sample == nil {
+		return
+	}
+	fieldExtractorMux.Lock()
+	defer fieldExtractorMux.Unlock()
+	delete(fieldExtractors, fieldExtractorKey{typ: reflect.TypeOf(sample), path: path})
+}
+
+func getFieldExtractor(typ reflect.Type, path string) FieldExtractor {
+	fieldExtractorMux.RLock()
+	defer fieldExtractorMux.RUnlock()
+	return fieldExtractors[fieldExtractorKey{typ: typ, path: path}]
+}
+
+// pathSegment is a step of the field path, which is the struct field name, the map key or the slice index.
+type pathSegment struct {
+	name  string
+	index int
+	// isIndex indicates whether the segment is written as [n], which could only be used as slice index or map key.
+	isIndex bool
+}
+
+// argPath is the compiled ParamPath.
+type argPath struct {
+	fromAttachment bool
+	argIndex       int
+	attachmentKey  string
+	// fieldPath is the part after the root, used as the key of FieldExtractor.
+	fieldPath string
+	segments  []pathSegment
+}
+
+// parseArgPath compiles the ParamPath, the formats are:
+//
+//	args[0].User.ID        struct fields (or string keys of map) of the first argument
+//	args[-1].Items[2]      the third element of the Items slice of the last argument
+//	attachments[req]/user/id  JSON-pointer-style path into the "req" attachment
+func parseArgPath(path string) (*argPath, error) {
+	p := &argPath{}
+	var rest string
+	switch {
+	case strings.HasPrefix(path, argsPathRoot+"["):
+		end := strings.IndexByte(path, ']')
+		if end < 0 {
+			return nil, errors.Errorf("unclosed bracket in param path: %s", path)
+		}
+		idx, err := strconv.Atoi(path[len(argsPathRoot)+1 : end])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid argument index in param path: %s", path)
+		}
+		p.argIndex = idx
+		rest = path[end+1:]
+	case strings.HasPrefix(path, attachmentsPathRoot+"["):
+		end := strings.IndexByte(path, ']')
+		if end < 0 {
+			return nil, errors.Errorf("unclosed bracket in param path: %s", path)
+		}
+		key := path[len(attachmentsPathRoot)+1 : end]
+		if key == "" {
+			return nil, errors.Errorf("empty attachment key in param path: %s", path)
+		}
+		p.fromAttachment = true
+		p.attachmentKey = key
+		rest = path[end+1:]
+	default:
+		return nil, errors.Errorf("param path must start with args[n] or attachments[key]: %s", path)
+	}
+
+	var (
+		segments []pathSegment
+		err      error
+	)
+	switch {
+	case rest == "":
+	case rest[0] == '/':
+		p.fieldPath = rest
+		segments = parseJSONPointer(rest)
+	case rest[0] == '.' || rest[0] == '[':
+		p.fieldPath = strings.TrimPrefix(rest, ".")
+		segments, err = parseFieldPath(rest)
+	default:
+		err = errors.Errorf("unexpected character %q after the root", rest[0])
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid param path: %s", path)
+	}
+	p.segments = segments
+	return p, nil
+}
+
+func parseFieldPath(s string) ([]pathSegment, error) {
+	segments := make([]pathSegment, 0, 4)
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, errors.New("empty field name")
+			}
+			segments = append(segments, pathSegment{name: s[:end]})
+			s = s[end:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, errors.New("unclosed bracket")
+			}
+			idx, err := strconv.Atoi(s[1:end])
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid index")
+			}
+			segments = append(segments, pathSegment{name: s[1:end], index: idx, isIndex: true})
+			s = s[end+1:]
+		default:
+			return nil, errors.Errorf("unexpected character %q", s[0])
+		}
+	}
+	return segments, nil
+}
+
+// parseJSONPointer parses the path like /a/b/0 by the rules of RFC 6901.
+func parseJSONPointer(s string) []pathSegment {
+	tokens := strings.Split(s[1:], "/")
+	segments := make([]pathSegment, 0, len(tokens))
+	for _, token := range tokens {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		seg := pathSegment{name: token}
+		if idx, err := strconv.Atoi(token); err == nil {
+			seg.index = idx
+		}
+		segments = append(segments, seg)
+	}
+	return segments
+}
+
+// extract resolves the parameter by the path, return nil if the parameter doesn't exist or isn't hashable.
+func (p *argPath) extract(c *baseTrafficShapingController, ctx *base.EntryContext) interface{} {
+	var root interface{}
+	if p.fromAttachment {
+		root = c.extractAttachmentOfKey(ctx, p.attachmentKey)
+	} else {
+		root = c.extractArgOfIndex(ctx, p.argIndex)
+	}
+	if root == nil || len(p.segments) == 0 {
+		return root
+	}
+	if extractor := getFieldExtractor(reflect.TypeOf(root), p.fieldPath); extractor != nil {
+		return hashableValue(reflect.ValueOf(extractor(root)))
+	}
+
+	v := reflect.ValueOf(root)
+	for _, seg := range p.segments {
+		v = resolveSegment(indirect(v), seg)
+		if !v.IsValid() {
+			if logging.DebugEnabled() {
+				logging.Debug("[ParamPath] The field doesn't exist", "fieldPath", p.fieldPath, "segment", seg.name)
+			}
+			return nil
+		}
+	}
+	return hashableValue(v)
+}
+
+// hashableValue returns the underlying value of v which could be the key of cache, return nil if it isn't hashable.
+// The net.IP value is converted to its string form before the hashability check.
+func hashableValue(v reflect.Value) interface{} {
+	v = indirect(v)
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	arg := normalizeArg(v.Interface())
+	if arg == nil || !reflect.TypeOf(arg).Comparable() {
+		return nil
+	}
+	return arg
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func resolveSegment(v reflect.Value, seg pathSegment) reflect.Value {
+	if !v.IsValid() {
+		return v
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if seg.isIndex {
+			return reflect.Value{}
+		}
+		index, ok := structFieldIndex(v.Type(), seg.name)
+		if !ok {
+			return reflect.Value{}
+		}
+		// Resolve the fields one by one rather than FieldByIndex to avoid panic on the nil embedded pointer.
+		for i, x := range index {
+			if i > 0 {
+				if v = indirect(v); !v.IsValid() {
+					return v
+				}
+			}
+			v = v.Field(x)
+		}
+		return v
+	case reflect.Map:
+		key, ok := mapKeyOf(v.Type().Key(), seg)
+		if !ok {
+			return reflect.Value{}
+		}
+		return v.MapIndex(key)
+	case reflect.Slice, reflect.Array:
+		if _, err := strconv.Atoi(seg.name); err != nil || seg.index < 0 || seg.index >= v.Len() {
+			return reflect.Value{}
+		}
+		return v.Index(seg.index)
+	default:
+		return reflect.Value{}
+	}
+}
+
+func structFieldIndex(typ reflect.Type, name string) ([]int, bool) {
+	key := fieldIndexKey{typ: typ, name: name}
+	if cached, ok := fieldIndexCache.Load(key); ok {
+		index := cached.([]int)
+		return index, index != nil
+	}
+	field, ok := typ.FieldByName(name)
+	// Only exported fields are accessible.
+	if !ok || field.PkgPath != "" {
+		fieldIndexCache.Store(key, []int(nil))
+		return nil, false
+	}
+	fieldIndexCache.Store(key, field.Index)
+	return field.Index, true
+}
+
+func mapKeyOf(keyType reflect.Type, seg pathSegment) (reflect.Value, bool) {
+	switch keyType.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(seg.name).Convert(keyType), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(seg.name, 10, 64)
+		if err != nil {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(i).Convert(keyType), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(seg.name, 10, 64)
+		if err != nil {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(u).Convert(keyType), true
+	case reflect.Interface:
+		return reflect.ValueOf(seg.name), true
+	default:
+		return reflect.Value{}, false
+	}
+}
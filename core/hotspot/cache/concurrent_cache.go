@@ -30,3 +30,10 @@ type ConcurrentCounterCache interface {
 	// Purge clears all cache entries.
 	Purge()
 }
+
+// PeekableCounterCache is the optional interface of ConcurrentCounterCache,
+// which reads the value without affecting the eviction, all the built-in caches implement it.
+type PeekableCounterCache interface {
+	// Peek returns key's value from the cache without updating the "recently used"-ness of the key.
+	Peek(key interface{}) (value *int64, isFound bool)
+}
@@ -41,6 +41,17 @@ func (c *LruCacheMap) Get(key interface{}) (value *int64, isFound bool) {
 	return nil, false
 }
 
+func (c *LruCacheMap) Peek(key interface{}) (value *int64, isFound bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	val, found := c.lru.Peek(key)
+	if found {
+		return val.(*int64), true
+	}
+	return nil, false
+}
+
 func (c *LruCacheMap) Remove(key interface{}) (isFound bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
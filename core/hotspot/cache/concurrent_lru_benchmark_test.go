@@ -61,3 +61,106 @@ func Benchmark_LRU_Get(b *testing.B) {
 		}
 	}
 }
+
+func newFilledCache(b *testing.B, newCache func(size int) ConcurrentCounterCache) ConcurrentCounterCache {
+	c := newCache(CacheSize)
+	for a := 1; a <= CacheSize; a++ {
+		val := new(int64)
+		*val = int64(a)
+		c.Add(strconv.Itoa(a), val)
+	}
+	b.ResetTimer()
+	return c
+}
+
+func newShardedLRU(size int) ConcurrentCounterCache {
+	return NewShardedLRUCacheMap(size, DefaultShardCount)
+}
+
+func newTinyLFU(size int) ConcurrentCounterCache {
+	return NewTinyLFUCacheMap(size, DefaultShardCount)
+}
+
+func benchmarkAddIfAbsent(b *testing.B, newCache func(size int) ConcurrentCounterCache) {
+	c := newFilledCache(b, newCache)
+	for i := 0; i < b.N; i++ {
+		for j := 1000; j <= 1001; j++ {
+			newVal := new(int64)
+			*newVal = int64(j)
+			prior := c.AddIfAbsent(strconv.Itoa(j), newVal)
+			if prior == nil || *prior != int64(j) {
+				b.Fatal("error!")
+			}
+		}
+	}
+}
+
+func benchmarkGet(b *testing.B, newCache func(size int) ConcurrentCounterCache) {
+	c := newFilledCache(b, newCache)
+	// The shards may not be filled evenly, so pick the keys which are surely cached.
+	keys := c.Keys()[:2]
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, key := range keys {
+			val, found := c.Get(key)
+			if !found || strconv.Itoa(int(*val)) != key {
+				b.Fatal("error")
+			}
+		}
+	}
+}
+
+// benchmarkParallelHotspot simulates the hotspot checking under high parallelism,
+// every access does AddIfAbsent and Get on the skewed parameters.
+func benchmarkParallelHotspot(b *testing.B, newCache func(size int) ConcurrentCounterCache) {
+	c := newCache(CacheSize)
+	keys := make([]string, CacheSize*2)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			// 3/4 of the accesses hit the first 1000 parameters.
+			var key string
+			if i%4 != 0 {
+				key = keys[(i*7)%1000]
+			} else {
+				key = keys[(i*7919)%len(keys)]
+			}
+			val := int64(i)
+			c.AddIfAbsent(key, &val)
+			c.Get(key)
+			i++
+		}
+	})
+}
+
+func Benchmark_ShardedLRU_AddIfAbsent(b *testing.B) {
+	benchmarkAddIfAbsent(b, newShardedLRU)
+}
+
+func Benchmark_TinyLFU_AddIfAbsent(b *testing.B) {
+	benchmarkAddIfAbsent(b, newTinyLFU)
+}
+
+func Benchmark_ShardedLRU_Get(b *testing.B) {
+	benchmarkGet(b, newShardedLRU)
+}
+
+func Benchmark_TinyLFU_Get(b *testing.B) {
+	benchmarkGet(b, newTinyLFU)
+}
+
+func Benchmark_LRU_Parallel(b *testing.B) {
+	benchmarkParallelHotspot(b, NewLRUCacheMap)
+}
+
+func Benchmark_ShardedLRU_Parallel(b *testing.B) {
+	benchmarkParallelHotspot(b, newShardedLRU)
+}
+
+func Benchmark_TinyLFU_Parallel(b *testing.B) {
+	benchmarkParallelHotspot(b, newTinyLFU)
+}
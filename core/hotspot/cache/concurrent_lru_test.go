@@ -94,3 +94,19 @@ func Test_concurrentLruCounterCacheMap_Remove(t *testing.T) {
 		assert.True(t, existed == false && val == nil)
 	})
 }
+
+func Test_concurrentLruCounterCacheMap_Peek(t *testing.T) {
+	t.Run("Test_concurrentLruCounterCacheMap_Peek", func(t *testing.T) {
+		c := NewLRUCacheMap(2)
+		v1, v2, v3 := int64(1), int64(2), int64(3)
+		c.Add("1", &v1)
+		c.Add("2", &v2)
+		val, found := c.(PeekableCounterCache).Peek("1")
+		assert.True(t, found && *val == 1)
+		// Peek doesn't update the recent-ness, so "1" is still the oldest one.
+		c.Add("3", &v3)
+		assert.False(t, c.Contains("1"))
+		_, found = c.(PeekableCounterCache).Peek("1")
+		assert.False(t, found)
+	})
+}
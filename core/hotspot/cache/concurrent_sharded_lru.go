@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"sync"
+)
+
+const (
+	// DefaultShardCount is the default amount of shards of the sharded caches.
+	DefaultShardCount = 16
+	// MaxShardCount is the max amount of shards of the sharded caches.
+	MaxShardCount = 1 << 16
+)
+
+// ShardedLruCacheMap splits the hotspot parameters into several LRU shards by the hash of parameter,
+// each shard has its own lock, so that the lock contention is reduced under high parallelism.
+// The LRU strategy is kept within each shard rather than the whole cache.
+type ShardedLruCacheMap struct {
+	shards []*LruCacheMap
+	mask   uint64
+}
+
+func (c *ShardedLruCacheMap) shardOf(key interface{}) *LruCacheMap {
+	return c.shards[shardIndexOf(hashKey(key), c.mask)]
+}
+
+func (c *ShardedLruCacheMap) Add(key interface{}, value *int64) {
+	c.shardOf(key).Add(key, value)
+}
+
+func (c *ShardedLruCacheMap) AddIfAbsent(key interface{}, value *int64) (priorValue *int64) {
+	return c.shardOf(key).AddIfAbsent(key, value)
+}
+
+func (c *ShardedLruCacheMap) Get(key interface{}) (value *int64, isFound bool) {
+	return c.shardOf(key).Get(key)
+}
+
+func (c *ShardedLruCacheMap) Peek(key interface{}) (value *int64, isFound bool) {
+	return c.shardOf(key).Peek(key)
+}
+
+func (c *ShardedLruCacheMap) Remove(key interface{}) (isFound bool) {
+	return c.shardOf(key).Remove(key)
+}
+
+func (c *ShardedLruCacheMap) Contains(key interface{}) (ok bool) {
+	return c.shardOf(key).Contains(key)
+}
+
+// Keys returns the keys shard by shard, the keys are ordered from oldest to newest only within each shard.
+func (c *ShardedLruCacheMap) Keys() []interface{} {
+	keys := make([]interface{}, 0, c.Len())
+	for _, s := range c.shards {
+		keys = append(keys, s.Keys()...)
+	}
+	return keys
+}
+
+func (c *ShardedLruCacheMap) Len() int {
+	l := 0
+	for _, s := range c.shards {
+		l += s.Len()
+	}
+	return l
+}
+
+func (c *ShardedLruCacheMap) Purge() {
+	for _, s := range c.shards {
+		s.Purge()
+	}
+}
+
+// NewShardedLRUCacheMap creates the sharded LRU cache with the total size,
+// the shardCount is rounded up to the power of two, and DefaultShardCount is used if shardCount is not positive.
+func NewShardedLRUCacheMap(size int, shardCount int) ConcurrentCounterCache {
+	if size <= 0 {
+		return nil
+	}
+	shardCount = normalizeShardCount(size, shardCount)
+	shardSize := (size + shardCount - 1) / shardCount
+	shards := make([]*LruCacheMap, shardCount)
+	for i := range shards {
+		lru, err := NewLRU(shardSize, nil)
+		if err != nil {
+			return nil
+		}
+		shards[i] = &LruCacheMap{
+			lru:  lru,
+			lock: new(sync.RWMutex),
+		}
+	}
+	return &ShardedLruCacheMap{
+		shards: shards,
+		mask:   uint64(shardCount - 1),
+	}
+}
+
+// shardIndexOf picks the shard by the high bits of the hash, so that the low bits are
+// still distributed evenly within the shard, e.g. for the frequency sketch.
+func shardIndexOf(h uint64, mask uint64) uint64 {
+	return (h >> 48) & mask
+}
+
+func normalizeShardCount(size int, shardCount int) int {
+	if shardCount <= 0 {
+		shardCount = DefaultShardCount
+	}
+	if shardCount > MaxShardCount {
+		shardCount = MaxShardCount
+	}
+	shardCount = ceilingPowerOfTwo(shardCount)
+	// Too many shards make the LRU strategy meaningless for the small cache.
+	for shardCount > 1 && size/shardCount < 1 {
+		shardCount >>= 1
+	}
+	return shardCount
+}
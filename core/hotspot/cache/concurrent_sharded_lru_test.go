@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_shardedLruCounterCacheMap_Add_Get(t *testing.T) {
+	t.Run("Test_shardedLruCounterCacheMap_Add_Get", func(t *testing.T) {
+		c := NewShardedLRUCacheMap(1000, 8)
+		for i := 1; i <= 100; i++ {
+			val := int64(i)
+			c.Add(strconv.Itoa(i), &val)
+		}
+		assert.True(t, c.Len() == 100)
+		assert.True(t, len(c.Keys()) == 100)
+		val, found := c.Get("1")
+		assert.True(t, found && *val == 1)
+		val, found = c.(PeekableCounterCache).Peek("100")
+		assert.True(t, found && *val == 100)
+	})
+}
+
+func Test_shardedLruCounterCacheMap_AddIfAbsent(t *testing.T) {
+	t.Run("Test_shardedLruCounterCacheMap_AddIfAbsent", func(t *testing.T) {
+		c := NewShardedLRUCacheMap(100, 0)
+		v := int64(100)
+		prior := c.AddIfAbsent(100, &v)
+		assert.True(t, prior == nil)
+		v2 := int64(1000)
+		prior2 := c.AddIfAbsent(100, &v2)
+		assert.True(t, *prior2 == 100)
+		assert.True(t, c.Contains(100))
+		assert.True(t, c.Remove(100))
+		assert.False(t, c.Contains(100))
+	})
+}
+
+func Test_shardedLruCounterCacheMap_Capacity(t *testing.T) {
+	t.Run("Test_shardedLruCounterCacheMap_Capacity", func(t *testing.T) {
+		c := NewShardedLRUCacheMap(64, 4)
+		for i := 0; i < 1000; i++ {
+			val := int64(i)
+			c.Add(i, &val)
+		}
+		assert.True(t, c.Len() <= 64)
+		c.Purge()
+		assert.True(t, c.Len() == 0)
+		// The shards are reduced for the small cache.
+		assert.Equal(t, 2, len(NewShardedLRUCacheMap(2, 16).(*ShardedLruCacheMap).shards))
+		assert.Nil(t, NewShardedLRUCacheMap(0, 16))
+	})
+}
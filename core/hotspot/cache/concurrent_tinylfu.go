@@ -0,0 +1,300 @@
+package cache
+
+import (
+	"sync"
+)
+
+const (
+	sketchDepth      = 4
+	sketchMaxCounter = 15
+	// sketchWidthFactor decides the width of each row of the sketch by the capacity, the wider the fewer collisions.
+	sketchWidthFactor = 4
+	sketchMinWidth    = 64
+	// sketchSampleFactor decides the sample size of the sketch, the counters are halved after
+	// sketchSampleFactor * width increments so that the old frequency would decay.
+	sketchSampleFactor = 10
+	// windowPercent is the percentage of the window space in each shard.
+	windowPercent = 1
+)
+
+// countMinSketch estimates the access frequency of the keys with 4-bit-like saturating counters.
+type countMinSketch struct {
+	rows       [sketchDepth][]uint8
+	mask       uint32
+	additions  int
+	sampleSize int
+}
+
+func newCountMinSketch(capacity int) *countMinSketch {
+	width := ceilingPowerOfTwo(sketchWidthFactor * capacity)
+	if width < sketchMinWidth {
+		width = sketchMinWidth
+	}
+	s := &countMinSketch{
+		mask:       uint32(width - 1),
+		sampleSize: sketchSampleFactor * width,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// indexOf picks the counter of the i-th row by double hashing.
+func (s *countMinSketch) indexOf(h uint64, i int) uint32 {
+	h1, h2 := uint32(h), uint32(h>>32)
+	return (h1 + uint32(i)*h2) & s.mask
+}
+
+func (s *countMinSketch) increment(h uint64) {
+	added := false
+	for i := range s.rows {
+		idx := s.indexOf(h, i)
+		if s.rows[i][idx] < sketchMaxCounter {
+			s.rows[i][idx]++
+			added = true
+		}
+	}
+	if added {
+		s.additions++
+		if s.additions >= s.sampleSize {
+			s.reset()
+		}
+	}
+}
+
+func (s *countMinSketch) estimate(h uint64) uint8 {
+	min := uint8(sketchMaxCounter)
+	for i := range s.rows {
+		if c := s.rows[i][s.indexOf(h, i)]; c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+// reset halves all the counters.
+func (s *countMinSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+func (s *countMinSketch) clear() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] = 0
+		}
+	}
+	s.additions = 0
+}
+
+// tinyLfuShard is the W-TinyLFU shard, the new key always goes into the small window LRU first,
+// and competes with the victim of the main LRU by the frequency when it's evicted from the window.
+type tinyLfuShard struct {
+	lock     sync.Mutex
+	window   *LRU
+	main     *LRU
+	winSize  int
+	mainSize int
+	sketch   *countMinSketch
+}
+
+// get looks up the key in the main LRU and then the window LRU.
+func (s *tinyLfuShard) get(key interface{}) (*int64, bool) {
+	if val, found := s.main.Get(key); found {
+		return val.(*int64), true
+	}
+	if val, found := s.window.Get(key); found {
+		return val.(*int64), true
+	}
+	return nil, false
+}
+
+// add puts the absent key into the window, so that the new key is always cached
+// and the following accesses of it would be counted.
+func (s *tinyLfuShard) add(key interface{}, value *int64) {
+	if s.window.Len() >= s.winSize {
+		if candidate, val, ok := s.window.RemoveOldest(); ok {
+			s.promote(candidate, val)
+		}
+	}
+	s.window.Add(key, value)
+}
+
+// promote moves the key evicted from the window to the main LRU, when the main LRU is full,
+// the candidate is admitted only if it's more frequent than the LRU victim, otherwise it's dropped.
+func (s *tinyLfuShard) promote(candidate interface{}, value interface{}) {
+	if s.main.Len() >= s.mainSize {
+		victim, _, ok := s.main.GetOldest()
+		if ok && s.sketch.estimate(hashKey(candidate)) <= s.sketch.estimate(hashKey(victim)) {
+			return
+		}
+		s.main.RemoveOldest()
+	}
+	s.main.Add(candidate, value)
+}
+
+// TinyLFUCacheMap is the sharded cache with the W-TinyLFU policy.
+// The new parameter is always cached in a small window, and it would not evict the existing one
+// of the main space unless it's accessed more frequently, so that the hot parameters are kept
+// in the cache under the burst of one-off parameters.
+type TinyLFUCacheMap struct {
+	shards []*tinyLfuShard
+	mask   uint64
+}
+
+func (c *TinyLFUCacheMap) shardOf(key interface{}) (*tinyLfuShard, uint64) {
+	h := hashKey(key)
+	return c.shards[shardIndexOf(h, c.mask)], h
+}
+
+func (c *TinyLFUCacheMap) Add(key interface{}, value *int64) {
+	s, h := c.shardOf(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sketch.increment(h)
+	if s.main.Contains(key) {
+		s.main.Add(key, value)
+		return
+	}
+	if s.window.Contains(key) {
+		s.window.Add(key, value)
+		return
+	}
+	s.add(key, value)
+}
+
+func (c *TinyLFUCacheMap) AddIfAbsent(key interface{}, value *int64) (priorValue *int64) {
+	s, h := c.shardOf(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sketch.increment(h)
+	if val, found := s.get(key); found {
+		return val
+	}
+	s.add(key, value)
+	return nil
+}
+
+func (c *TinyLFUCacheMap) Get(key interface{}) (value *int64, isFound bool) {
+	s, h := c.shardOf(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.sketch.increment(h)
+	return s.get(key)
+}
+
+func (c *TinyLFUCacheMap) Peek(key interface{}) (value *int64, isFound bool) {
+	s, _ := c.shardOf(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if val, found := s.main.Peek(key); found {
+		return val.(*int64), true
+	}
+	if val, found := s.window.Peek(key); found {
+		return val.(*int64), true
+	}
+	return nil, false
+}
+
+func (c *TinyLFUCacheMap) Remove(key interface{}) (isFound bool) {
+	s, _ := c.shardOf(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.main.Remove(key) || s.window.Remove(key)
+}
+
+func (c *TinyLFUCacheMap) Contains(key interface{}) (ok bool) {
+	s, _ := c.shardOf(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.main.Contains(key) || s.window.Contains(key)
+}
+
+func (c *TinyLFUCacheMap) Keys() []interface{} {
+	keys := make([]interface{}, 0, c.Len())
+	for _, s := range c.shards {
+		s.lock.Lock()
+		keys = append(keys, s.main.Keys()...)
+		keys = append(keys, s.window.Keys()...)
+		s.lock.Unlock()
+	}
+	return keys
+}
+
+func (c *TinyLFUCacheMap) Len() int {
+	l := 0
+	for _, s := range c.shards {
+		s.lock.Lock()
+		l += s.main.Len() + s.window.Len()
+		s.lock.Unlock()
+	}
+	return l
+}
+
+func (c *TinyLFUCacheMap) Purge() {
+	for _, s := range c.shards {
+		s.lock.Lock()
+		s.main.Purge()
+		s.window.Purge()
+		s.sketch.clear()
+		s.lock.Unlock()
+	}
+}
+
+// splitTinyLfuShardSize splits the size of shard into the window and the main space,
+// both of them have one slot at least.
+func splitTinyLfuShardSize(shardSize int) (winSize int, mainSize int) {
+	winSize = shardSize * windowPercent / 100
+	if winSize < 1 {
+		winSize = 1
+	}
+	mainSize = shardSize - winSize
+	if mainSize < 1 {
+		mainSize = 1
+	}
+	return
+}
+
+// NewTinyLFUCacheMap creates the sharded TinyLFU cache with the total size,
+// the shardCount is rounded up to the power of two, and DefaultShardCount is used if shardCount is not positive.
+func NewTinyLFUCacheMap(size int, shardCount int) ConcurrentCounterCache {
+	if size <= 0 {
+		return nil
+	}
+	shardCount = normalizeShardCount(size, shardCount)
+	shardSize := (size + shardCount - 1) / shardCount
+	winSize, mainSize := splitTinyLfuShardSize(shardSize)
+	shards := make([]*tinyLfuShard, shardCount)
+	for i := range shards {
+		window, err := NewLRU(winSize, nil)
+		if err != nil {
+			return nil
+		}
+		main, err := NewLRU(mainSize, nil)
+		if err != nil {
+			return nil
+		}
+		shards[i] = &tinyLfuShard{
+			window:   window,
+			main:     main,
+			winSize:  winSize,
+			mainSize: mainSize,
+			sketch:   newCountMinSketch(shardSize),
+		}
+	}
+	return &TinyLFUCacheMap{
+		shards: shards,
+		mask:   uint64(shardCount - 1),
+	}
+}
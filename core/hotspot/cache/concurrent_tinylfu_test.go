@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_tinyLfuCounterCacheMap_Add_Get(t *testing.T) {
+	t.Run("Test_tinyLfuCounterCacheMap_Add_Get", func(t *testing.T) {
+		c := NewTinyLFUCacheMap(1000, 8)
+		for i := 1; i <= 100; i++ {
+			val := int64(i)
+			c.Add(strconv.Itoa(i), &val)
+		}
+		assert.True(t, c.Len() == 100)
+		val, found := c.Get("1")
+		assert.True(t, found && *val == 1)
+		val, found = c.(PeekableCounterCache).Peek("100")
+		assert.True(t, found && *val == 100)
+		v := int64(1000)
+		assert.True(t, *c.AddIfAbsent("1", &v) == 1)
+		assert.True(t, c.Remove("1"))
+		assert.False(t, c.Contains("1"))
+		c.Purge()
+		assert.True(t, c.Len() == 0)
+	})
+}
+
+func Test_tinyLfuCounterCacheMap_Admission(t *testing.T) {
+	t.Run("Test_tinyLfuCounterCacheMap_Admission", func(t *testing.T) {
+		// One slot of the window and ten slots of the main space.
+		c := NewTinyLFUCacheMap(11, 1)
+		// The hot parameters are accessed frequently.
+		for round := 0; round < sketchMaxCounter; round++ {
+			for i := 0; i < 10; i++ {
+				val := int64(i)
+				c.AddIfAbsent("hot-"+strconv.Itoa(i), &val)
+			}
+		}
+		// The burst of one-off parameters doesn't evict the hot ones.
+		for i := 0; i < 200; i++ {
+			val := int64(i)
+			c.AddIfAbsent("cold-"+strconv.Itoa(i), &val)
+		}
+		for i := 0; i < 10; i++ {
+			assert.True(t, c.Contains("hot-"+strconv.Itoa(i)))
+		}
+		// The latest one-off parameter is kept in the window.
+		assert.True(t, c.Contains("cold-199"))
+		assert.True(t, c.Len() == 11)
+	})
+}
+
+func Test_tinyLfuCounterCacheMap_NewKeyAlwaysCached(t *testing.T) {
+	t.Run("Test_tinyLfuCounterCacheMap_NewKeyAlwaysCached", func(t *testing.T) {
+		c := NewTinyLFUCacheMap(11, 1)
+		for round := 0; round < sketchMaxCounter; round++ {
+			for i := 0; i < 10; i++ {
+				val := int64(i)
+				c.AddIfAbsent("hot-"+strconv.Itoa(i), &val)
+			}
+		}
+		// The new parameter is cached on the first access even if it's not admitted into the main space.
+		val := int64(100)
+		assert.Nil(t, c.AddIfAbsent("new", &val))
+		prior := c.AddIfAbsent("new", &val)
+		assert.True(t, prior != nil && *prior == 100)
+		found, ok := c.Get("new")
+		assert.True(t, ok && *found == 100)
+	})
+}
+
+func Test_countMinSketch(t *testing.T) {
+	t.Run("Test_countMinSketch", func(t *testing.T) {
+		s := newCountMinSketch(16)
+		h := hashKey("a")
+		for i := 0; i < 20; i++ {
+			s.increment(h)
+		}
+		assert.Equal(t, uint8(sketchMaxCounter), s.estimate(h))
+		s.reset()
+		assert.Equal(t, uint8(sketchMaxCounter/2), s.estimate(h))
+		s.clear()
+		assert.Equal(t, uint8(0), s.estimate(h))
+	})
+}
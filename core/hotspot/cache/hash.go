@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+func hashString(s string) uint64 {
+	h := uint64(fnvOffset64)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime64
+	}
+	return h
+}
+
+// hashUint64 mixes the bits of v by the finalizer of SplitMix64.
+func hashUint64(v uint64) uint64 {
+	v ^= v >> 30
+	v *= 0xbf58476d1ce4e5b9
+	v ^= v >> 27
+	v *= 0x94d049bb133111eb
+	v ^= v >> 31
+	return v
+}
+
+// hashKey returns the hash of the hotspot parameter,
+// the common kinds of parameter are hashed directly and the others are hashed by the formatted string.
+func hashKey(key interface{}) uint64 {
+	switch k := key.(type) {
+	case string:
+		return hashString(k)
+	case int:
+		return hashUint64(uint64(k))
+	case int8:
+		return hashUint64(uint64(k))
+	case int16:
+		return hashUint64(uint64(k))
+	case int32:
+		return hashUint64(uint64(k))
+	case int64:
+		return hashUint64(uint64(k))
+	case uint:
+		return hashUint64(uint64(k))
+	case uint8:
+		return hashUint64(uint64(k))
+	case uint16:
+		return hashUint64(uint64(k))
+	case uint32:
+		return hashUint64(uint64(k))
+	case uint64:
+		return hashUint64(k)
+	case float32:
+		return hashUint64(uint64(math.Float32bits(k)))
+	case float64:
+		return hashUint64(math.Float64bits(k))
+	case bool:
+		if k {
+			return hashUint64(1)
+		}
+		return hashUint64(0)
+	case fmt.Stringer:
+		return hashString(k.String())
+	default:
+		return hashString(fmt.Sprintf("%T:%v", key, key))
+	}
+}
+
+// ceilingPowerOfTwo returns the smallest power of two which is greater than or equal to n.
+func ceilingPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
@@ -0,0 +1,198 @@
+package hotspot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CompositeKeySeparator is the separator between the parameter values of the CompositeKey.
+	CompositeKeySeparator = "|"
+	// compositeKeyEscape escapes the separator and itself in the parameter values.
+	compositeKeyEscape = '\\'
+	// compositeKeyTypeSeparator is the separator between the type and the value of each parameter.
+	compositeKeyTypeSeparator = ':'
+)
+
+// CompositeKey is the hotspot parameter composed of multiple parameter values, e.g. (tenantID, apiName).
+// It is comparable so that it could be used as the key of ParamsMetric caches and Rule.SpecificItems.
+// Each parameter value is encoded as its type and its default format (fmt.Sprint), e.g. "string:tenantA",
+// the special characters are escaped by '\' and the parameters are joined by CompositeKeySeparator,
+// so the different parameter values (e.g. ("a|b", "c") and ("a", "b|c"), or (1, "1")) never collide.
+type CompositeKey string
+
+// NewCompositeKey builds the CompositeKey from the given parameter values in order.
+func NewCompositeKey(values ...interface{}) CompositeKey {
+	var sb strings.Builder
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteString(CompositeKeySeparator)
+		}
+		writeEscaped(&sb, fmt.Sprintf("%T", v), true)
+		sb.WriteByte(compositeKeyTypeSeparator)
+		writeEscaped(&sb, fmt.Sprint(v), false)
+	}
+	return CompositeKey(sb.String())
+}
+
+// ParseCompositeKey parses the CompositeKey from the parameter values joined by CompositeKeySeparator,
+// e.g. "tenantA|getUser". Each parameter value could be prefixed by its type and ':', e.g. "int:42|string:getUser",
+// so that it matches the non-string argument, the supported types are string, bool, int, int8, int16, int32, int64,
+// uint, uint8, uint16, uint32, uint64, float32 and float64. The value without a supported type prefix is a string.
+// The special characters in the values should be escaped by '\', e.g. "a\|b|c" means ("a|b", "c"),
+// and "int\:42" means the string "int:42".
+func ParseCompositeKey(s string) (CompositeKey, error) {
+	values := make([]interface{}, 0, strings.Count(s, CompositeKeySeparator)+1)
+	var sb strings.Builder
+	escaped := false
+	// typeEnd is the position of the first unescaped type separator of the current part, -1 if absent.
+	typeEnd := -1
+	appendValue := func() error {
+		v, err := parseCompositeKeyPart(sb.String(), typeEnd)
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
+		sb.Reset()
+		typeEnd = -1
+		return nil
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			sb.WriteByte(c)
+			escaped = false
+		case c == compositeKeyEscape:
+			escaped = true
+		case c == CompositeKeySeparator[0]:
+			if err := appendValue(); err != nil {
+				return "", err
+			}
+		case c == compositeKeyTypeSeparator && typeEnd < 0:
+			typeEnd = sb.Len()
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	if err := appendValue(); err != nil {
+		return "", err
+	}
+	return NewCompositeKey(values...), nil
+}
+
+// parseCompositeKeyPart parses the unescaped parameter value, the value is a string if the type is absent or unsupported.
+func parseCompositeKeyPart(part string, typeEnd int) (interface{}, error) {
+	if typeEnd < 0 {
+		return part, nil
+	}
+	typ, val := part[:typeEnd], part[typeEnd+1:]
+	var (
+		v   interface{}
+		err error
+	)
+	switch typ {
+	case "string":
+		return val, nil
+	case "bool":
+		v, err = strconv.ParseBool(val)
+	case "int", "int8", "int16", "int32", "int64":
+		v, err = parseCompositeKeyInt(typ, val)
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		v, err = parseCompositeKeyUint(typ, val)
+	case "float32":
+		var f float64
+		f, err = strconv.ParseFloat(val, 32)
+		v = float32(f)
+	case "float64":
+		v, err = strconv.ParseFloat(val, 64)
+	default:
+		return part, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s value of composite key: %s", typ, val)
+	}
+	return v, nil
+}
+
+func parseCompositeKeyInt(typ, val string) (interface{}, error) {
+	switch typ {
+	case "int8":
+		i, err := strconv.ParseInt(val, 10, 8)
+		return int8(i), err
+	case "int16":
+		i, err := strconv.ParseInt(val, 10, 16)
+		return int16(i), err
+	case "int32":
+		i, err := strconv.ParseInt(val, 10, 32)
+		return int32(i), err
+	case "int64":
+		return strconv.ParseInt(val, 10, 64)
+	default:
+		i, err := strconv.ParseInt(val, 10, strconv.IntSize)
+		return int(i), err
+	}
+}
+
+func parseCompositeKeyUint(typ, val string) (interface{}, error) {
+	switch typ {
+	case "uint8":
+		i, err := strconv.ParseUint(val, 10, 8)
+		return uint8(i), err
+	case "uint16":
+		i, err := strconv.ParseUint(val, 10, 16)
+		return uint16(i), err
+	case "uint32":
+		i, err := strconv.ParseUint(val, 10, 32)
+		return uint32(i), err
+	case "uint64":
+		return strconv.ParseUint(val, 10, 64)
+	default:
+		i, err := strconv.ParseUint(val, 10, strconv.IntSize)
+		return uint(i), err
+	}
+}
+
+// plainString returns the parameter values joined by CompositeKeySeparator without the types and the escapes,
+// e.g. "tenantA|getUser" for NewCompositeKey("tenantA", "getUser"), which is matched by MatchPrefix and MatchRegex.
+func (k CompositeKey) plainString() string {
+	s := string(k)
+	var sb strings.Builder
+	sb.Grow(len(s))
+	inType := true
+	escaped := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			if !inType {
+				sb.WriteByte(c)
+			}
+			escaped = false
+		case c == compositeKeyEscape:
+			escaped = true
+		case c == CompositeKeySeparator[0]:
+			sb.WriteByte(c)
+			inType = true
+		case c == compositeKeyTypeSeparator && inType:
+			inType = false
+		case !inType:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func writeEscaped(sb *strings.Builder, s string, isType bool) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == compositeKeyEscape || c == CompositeKeySeparator[0] || (isType && c == compositeKeyTypeSeparator) {
+			sb.WriteByte(compositeKeyEscape)
+		}
+		sb.WriteByte(c)
+	}
+}
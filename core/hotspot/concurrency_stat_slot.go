@@ -24,9 +24,11 @@ func (s *ConcurrencyStatSlot) Order() uint32 {
 
 func (c *ConcurrencyStatSlot) OnEntryPassed(ctx *base.EntryContext) {
 	res := ctx.Resource.Name()
+	batch := int64(ctx.Input.BatchCount)
 	tcs := getTrafficControllersFor(res)
+	paramStat := ParamStatEnabled()
 	for _, tc := range tcs {
-		if tc.BoundRule().MetricType != Concurrency {
+		if !paramStat && tc.BoundRule().MetricType != Concurrency {
 			continue
 		}
 		arg := tc.ExtractArgs(ctx)
@@ -34,6 +36,13 @@ func (c *ConcurrencyStatSlot) OnEntryPassed(ctx *base.EntryContext) {
 			continue
 		}
 		metric := tc.BoundMetric()
+		if paramStat {
+			passCounter, _ := metric.paramStatCounters(true)
+			addCount(passCounter, arg, batch)
+		}
+		if tc.BoundRule().MetricType != Concurrency {
+			continue
+		}
 		concurrencyPtr, existed := metric.ConcurrencyCounter.Get(arg)
 		if !existed || concurrencyPtr == nil {
 			if logging.DebugEnabled() {
@@ -46,7 +55,24 @@ func (c *ConcurrencyStatSlot) OnEntryPassed(ctx *base.EntryContext) {
 }
 
 func (c *ConcurrencyStatSlot) OnEntryBlocked(ctx *base.EntryContext, blockError *base.BlockError) {
-	// Do nothing
+	if !ParamStatEnabled() || blockError == nil || blockError.BlockType() != base.BlockTypeHotSpotParamFlow {
+		return
+	}
+	res := ctx.Resource.Name()
+	batch := int64(ctx.Input.BatchCount)
+	tcs := getTrafficControllersFor(res)
+	for _, tc := range tcs {
+		// Only the rule which triggered the blocking records the blocked request.
+		if blockError.TriggeredRule() != base.SentinelRule(tc.BoundRule()) {
+			continue
+		}
+		arg := tc.ExtractArgs(ctx)
+		if arg == nil {
+			continue
+		}
+		_, blockCounter := tc.BoundMetric().paramStatCounters(true)
+		addCount(blockCounter, arg, batch)
+	}
 }
 
 func (c *ConcurrencyStatSlot) OnCompleted(ctx *base.EntryContext) { // 并发计数
@@ -1,6 +1,73 @@
 package hotspot
 
-import "github.com/alibaba/sentinel-golang/core/hotspot/cache"
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/alibaba/sentinel-golang/core/hotspot/cache"
+	"github.com/pkg/errors"
+)
+
+// CacheType represents the implementation of cache.ConcurrentCounterCache used by ParamsMetric.
+type CacheType int32
+
+const (
+	// DefaultCacheType follows the global default cache type, see SetDefaultCacheType.
+	DefaultCacheType CacheType = iota
+	// LRUCache is the LRU cache guarded by a single lock.
+	LRUCache
+	// ShardedLRUCache is the LRU cache split into shards, each shard has its own lock.
+	ShardedLRUCache
+	// TinyLFUCache is the sharded cache with the TinyLFU admission policy,
+	// which keeps the frequent parameters under the burst of one-off parameters.
+	TinyLFUCache
+)
+
+func (t CacheType) String() string {
+	switch t {
+	case DefaultCacheType:
+		return "Default"
+	case LRUCache:
+		return "LRU"
+	case ShardedLRUCache:
+		return "ShardedLRU"
+	case TinyLFUCache:
+		return "TinyLFU"
+	default:
+		return "Undefined"
+	}
+}
+
+var globalCacheType = int32(LRUCache)
+
+// SetDefaultCacheType sets the global cache type of the rules whose ParamsCacheType is DefaultCacheType.
+// It only takes effect for the statistic created afterwards.
+func SetDefaultCacheType(t CacheType) error {
+	if t <= DefaultCacheType || t > TinyLFUCache {
+		return errors.Errorf("invalid cache type: %d", t)
+	}
+	atomic.StoreInt32(&globalCacheType, int32(t))
+	return nil
+}
+
+// GetDefaultCacheType returns the global cache type.
+func GetDefaultCacheType() CacheType {
+	return CacheType(atomic.LoadInt32(&globalCacheType))
+}
+
+func newCounterCache(t CacheType, size int) cache.ConcurrentCounterCache {
+	if t == DefaultCacheType {
+		t = GetDefaultCacheType()
+	}
+	switch t {
+	case ShardedLRUCache:
+		return cache.NewShardedLRUCacheMap(size, cache.DefaultShardCount)
+	case TinyLFUCache:
+		return cache.NewTinyLFUCacheMap(size, cache.DefaultShardCount)
+	default:
+		return cache.NewLRUCacheMap(size)
+	}
+}
 
 const (
 	ConcurrencyMaxCount = 4000
@@ -14,4 +81,74 @@ type ParamsMetric struct {
 	RuleTimeCounter    cache.ConcurrentCounterCache // 记录最后添加的令牌时间戳。
 	RuleTokenCounter   cache.ConcurrentCounterCache // 记录令牌的数量。
 	ConcurrencyCounter cache.ConcurrentCounterCache // 实时并发
+	PassCounter        cache.ConcurrentCounterCache // 记录累计通过的请求数，在开启参数统计后首次记录时创建，nil表示未开启。
+	BlockCounter       cache.ConcurrentCounterCache // 记录被当前规则拒绝的累计请求数，创建方式同PassCounter。
+
+	// paramStatMux 保护PassCounter和BlockCounter的延迟创建。
+	paramStatMux sync.Mutex
+	// paramStatCreated 表示PassCounter和BlockCounter已创建，为1时可无锁读取。
+	paramStatCreated int32
+	// paramStatCacheType 和 paramStatCacheSize 是延迟创建的计数器的缓存类型和容量，
+	// 容量为0表示计数器由外部设置，不会被延迟创建。
+	paramStatCacheType CacheType
+	paramStatCacheSize int
+}
+
+// withParamStatCache sets the cache type and size of the lazily created PassCounter and BlockCounter.
+func (m *ParamsMetric) withParamStatCache(t CacheType, size int) *ParamsMetric {
+	if t == DefaultCacheType {
+		t = GetDefaultCacheType()
+	}
+	m.paramStatCacheType = t
+	m.paramStatCacheSize = size
+	return m
+}
+
+// paramStatCounters returns the PassCounter and BlockCounter, which are created on first call with create
+// set to true. The counters are nil if they haven't been created, which means the parameter statistic is disabled.
+func (m *ParamsMetric) paramStatCounters(create bool) (pass, block cache.ConcurrentCounterCache) {
+	if m.paramStatCacheSize <= 0 || atomic.LoadInt32(&m.paramStatCreated) == 1 {
+		return m.PassCounter, m.BlockCounter
+	}
+	if !create {
+		return nil, nil
+	}
+	m.paramStatMux.Lock()
+	defer m.paramStatMux.Unlock()
+	if m.paramStatCreated == 0 {
+		m.PassCounter = newCounterCache(m.paramStatCacheType, m.paramStatCacheSize)
+		m.BlockCounter = newCounterCache(m.paramStatCacheType, m.paramStatCacheSize)
+		atomic.StoreInt32(&m.paramStatCreated, 1)
+	}
+	return m.PassCounter, m.BlockCounter
+}
+
+// addCount adds the delta to the counter of arg in the given cache.
+func addCount(counter cache.ConcurrentCounterCache, arg interface{}, delta int64) {
+	if counter == nil {
+		return
+	}
+	initCount := delta
+	if countPtr := counter.AddIfAbsent(arg, &initCount); countPtr != nil {
+		atomic.AddInt64(countPtr, delta)
+	}
+}
+
+// peekCount returns the current count of arg in the given cache,
+// the recent-ness is not updated unless the cache doesn't implement cache.PeekableCounterCache.
+func peekCount(counter cache.ConcurrentCounterCache, arg interface{}) int64 {
+	if counter == nil {
+		return 0
+	}
+	var countPtr *int64
+	var existed bool
+	if peekable, ok := counter.(cache.PeekableCounterCache); ok {
+		countPtr, existed = peekable.Peek(arg)
+	} else {
+		countPtr, existed = counter.Get(arg)
+	}
+	if !existed || countPtr == nil {
+		return 0
+	}
+	return atomic.LoadInt64(countPtr)
 }
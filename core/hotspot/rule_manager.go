@@ -396,6 +396,25 @@ func IsValidRule(rule *Rule) error {
 	if rule.ParamIndex > 0 && rule.ParamKey != "" {
 		return errors.New("invalid param index and param key are mutually exclusive")
 	}
+	for _, key := range rule.ParamKeys {
+		if key == "" {
+			return errors.New("empty param key in ParamKeys")
+		}
+	}
+	if rule.ParamPath != "" {
+		if len(rule.ParamIndexes) > 0 || len(rule.ParamKeys) > 0 {
+			return errors.New("param path and composite params are mutually exclusive")
+		}
+		if _, err := parseArgPath(rule.ParamPath); err != nil {
+			return err
+		}
+	}
+	if rule.ParamsCacheType < DefaultCacheType || rule.ParamsCacheType > TinyLFUCache {
+		return errors.New("invalid params cache type")
+	}
+	if _, err := compileSpecificMatchers(rule.SpecificMatchers); err != nil {
+		return err
+	}
 	return checkControlBehaviorField(rule)
 }
 
@@ -0,0 +1,212 @@
+package hotspot
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// MatchKind represents the kind of SpecificMatcher.
+type MatchKind int32
+
+const (
+	// MatchPrefix matches the string parameter which has the prefix of Pattern.
+	// The CompositeKey is matched by its parameter values joined by CompositeKeySeparator without the types
+	// and the escapes, e.g. "tenantA|getUser", so the prefix "tenantA|" matches the first value.
+	MatchPrefix MatchKind = iota
+	// MatchRegex matches the string parameter by the regular expression Pattern,
+	// the CompositeKey is matched in the same form as MatchPrefix, e.g. `^tenantA\|`.
+	MatchRegex
+	// MatchRange matches the numeric parameter in the closed interval [Min, Max].
+	MatchRange
+	// MatchCIDR matches the IP parameter within the CIDR Pattern, e.g. 10.0.0.0/8. The IP parameter is a string
+	// of the IP address with or without the port, the net.IP parameter is extracted as its string form.
+	MatchCIDR
+)
+
+func (k MatchKind) String() string {
+	switch k {
+	case MatchPrefix:
+		return "Prefix"
+	case MatchRegex:
+		return "Regex"
+	case MatchRange:
+		return "Range"
+	case MatchCIDR:
+		return "CIDR"
+	default:
+		return "Undefined"
+	}
+}
+
+// SpecificMatcher gives the class of parameters a specific threshold.
+// SpecificMatchers are evaluated in order after the exact matches of SpecificItems,
+// and the threshold of the first matched one takes effect.
+type SpecificMatcher struct {
+	Kind MatchKind `json:"kind"`
+	// Pattern is the prefix, the regular expression or the CIDR, depends on Kind.
+	Pattern string `json:"pattern,omitempty"`
+	// Min and Max are the inclusive bounds of MatchRange.
+	Min       float64 `json:"min,omitempty"`
+	Max       float64 `json:"max,omitempty"`
+	Threshold int64   `json:"threshold"`
+}
+
+func (m *SpecificMatcher) String() string {
+	return fmt.Sprintf("{Kind:%s, Pattern:%s, Min:%v, Max:%v, Threshold:%d}", m.Kind, m.Pattern, m.Min, m.Max, m.Threshold)
+}
+
+// paramMatcher is the compiled SpecificMatcher.
+type paramMatcher struct {
+	match     func(arg interface{}) bool
+	threshold int64
+}
+
+func compileSpecificMatcher(m *SpecificMatcher) (*paramMatcher, error) {
+	var match func(arg interface{}) bool
+	switch m.Kind {
+	case MatchPrefix:
+		if m.Pattern == "" {
+			return nil, errors.New("empty prefix")
+		}
+		prefix := m.Pattern
+		match = func(arg interface{}) bool {
+			s, ok := stringOf(arg)
+			return ok && strings.HasPrefix(s, prefix)
+		}
+	case MatchRegex:
+		re, err := regexp.Compile(m.Pattern)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid regex")
+		}
+		cache := &regexMatchCache{re: re}
+		match = func(arg interface{}) bool {
+			s, ok := stringOf(arg)
+			return ok && cache.matchString(s)
+		}
+	case MatchRange:
+		if m.Min > m.Max {
+			return nil, errors.New("min of range is greater than max")
+		}
+		min, max := m.Min, m.Max
+		match = func(arg interface{}) bool {
+			f, ok := float64Of(arg)
+			return ok && f >= min && f <= max
+		}
+	case MatchCIDR:
+		_, ipNet, err := net.ParseCIDR(m.Pattern)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid CIDR")
+		}
+		match = func(arg interface{}) bool {
+			ip := ipOf(arg)
+			return ip != nil && ipNet.Contains(ip)
+		}
+	default:
+		return nil, errors.Errorf("unsupported match kind: %d", m.Kind)
+	}
+	return &paramMatcher{match: match, threshold: m.Threshold}, nil
+}
+
+// compileSpecificMatchers compiles the matchers in order, the invalid ones are omitted
+// and the error of the first invalid one is returned.
+func compileSpecificMatchers(matchers []SpecificMatcher) ([]*paramMatcher, error) {
+	ret := make([]*paramMatcher, 0, len(matchers))
+	var firstErr error
+	for i := range matchers {
+		pm, err := compileSpecificMatcher(&matchers[i])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "invalid specific matcher: %s", matchers[i].String())
+			}
+			continue
+		}
+		ret = append(ret, pm)
+	}
+	return ret, firstErr
+}
+
+// regexMatchCacheSize is the max amount of the cached matching results of each regex matcher,
+// which bounds the memory as the parameter values are unbounded.
+const regexMatchCacheSize = 4096
+
+// regexMatchCache caches the result of regex matching by the parameter value.
+type regexMatchCache struct {
+	re      *regexp.Regexp
+	matched sync.Map
+	size    int32
+}
+
+func (c *regexMatchCache) matchString(s string) bool {
+	if matched, ok := c.matched.Load(s); ok {
+		return matched.(bool)
+	}
+	matched := c.re.MatchString(s)
+	if atomic.LoadInt32(&c.size) < regexMatchCacheSize {
+		if _, loaded := c.matched.LoadOrStore(s, matched); !loaded {
+			atomic.AddInt32(&c.size, 1)
+		}
+	}
+	return matched
+}
+
+func stringOf(arg interface{}) (string, bool) {
+	switch v := arg.(type) {
+	case string:
+		return v, true
+	case CompositeKey:
+		return v.plainString(), true
+	case fmt.Stringer:
+		return v.String(), true
+	}
+	v := reflect.ValueOf(arg)
+	if v.Kind() == reflect.String {
+		return v.String(), true
+	}
+	return "", false
+}
+
+func float64Of(arg interface{}) (float64, bool) {
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// ipOf parses the IP parameter, the net.IP parameter has been converted to string by normalizeArg.
+func ipOf(arg interface{}) net.IP {
+	v, ok := arg.(string)
+	if !ok {
+		return nil
+	}
+	// The address may carry the port, e.g. 10.0.0.1:8080
+	if host, _, err := net.SplitHostPort(v); err == nil {
+		return net.ParseIP(host)
+	}
+	return net.ParseIP(v)
+}
+
+// normalizeArg converts the net.IP parameter, which is unhashable, to its string form,
+// so that it could be the key of cache and matched by MatchCIDR.
+func normalizeArg(arg interface{}) interface{} {
+	if ip, ok := arg.(net.IP); ok {
+		if ip == nil {
+			return nil
+		}
+		return ip.String()
+	}
+	return arg
+}
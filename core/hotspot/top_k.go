@@ -0,0 +1,241 @@
+package hotspot
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/hotspot/cache"
+	metric_exporter "github.com/alibaba/sentinel-golang/exporter/metric"
+	"github.com/alibaba/sentinel-golang/util"
+)
+
+const (
+	// DefaultTopKParamsCount is the default amount of parameters reported for each rule.
+	DefaultTopKParamsCount = 10
+	// MaxTopKParamsCount is the upper bound of parameters reported for each rule by the metric exporter,
+	// which bounds the cardinality of the exported metrics.
+	MaxTopKParamsCount = 100
+	// maxParamLabelLength is the max length of the parameter value used as metric label.
+	maxParamLabelLength = 64
+)
+
+var (
+	topKReporterMux = new(sync.Mutex)
+	// topKReporterStopChan is closed to stop the running reporter, nil if the reporter isn't running.
+	topKReporterStopChan chan struct{}
+	// paramStatEnabled indicates whether the pass and block amount of each parameter are recorded.
+	paramStatEnabled util.AtomicBool
+
+	paramPassGauge = metric_exporter.NewGauge(
+		"hotspot_param_passed_requests",
+		"Accumulated passed request amount of the top-K hot parameters",
+		[]string{"resource", "rule_id", "param"})
+	paramBlockGauge = metric_exporter.NewGauge(
+		"hotspot_param_blocked_requests",
+		"Accumulated blocked request amount of the top-K hot parameters",
+		[]string{"resource", "rule_id", "param"})
+	paramConcurrencyGauge = metric_exporter.NewGauge(
+		"hotspot_param_concurrency",
+		"Current concurrency of the top-K hot parameters",
+		[]string{"resource", "rule_id", "param"})
+)
+
+func init() {
+	metric_exporter.Register(paramPassGauge)
+	metric_exporter.Register(paramBlockGauge)
+	metric_exporter.Register(paramConcurrencyGauge)
+}
+
+// ParamStat is the statistic of a hot parameter value under a specific rule.
+type ParamStat struct {
+	Value interface{} `json:"value"`
+	// PassCount is the accumulated passed request amount since the statistic of the rule was created.
+	PassCount int64 `json:"passCount"`
+	// BlockCount is the accumulated amount of the requests blocked by the rule.
+	BlockCount int64 `json:"blockCount"`
+	// Concurrency is the current concurrency, only available for the rule with Concurrency metric type.
+	Concurrency int64 `json:"concurrency"`
+}
+
+// RuleTopKParams is the top-K hot parameter values of a rule, ordered by the total request amount (pass + block) descending.
+type RuleTopKParams struct {
+	Resource string      `json:"resource"`
+	RuleId   string      `json:"ruleId"`
+	Rule     Rule        `json:"rule"`
+	Params   []ParamStat `json:"params"`
+}
+
+// SetParamStatEnabled enables or disables recording the pass and block amount of each parameter,
+// which is disabled by default and enabled by InitTopKParamsReporter.
+func SetParamStatEnabled(enabled bool) {
+	paramStatEnabled.Set(enabled)
+}
+
+// ParamStatEnabled indicates whether the pass and block amount of each parameter are recorded.
+func ParamStatEnabled() bool {
+	return paramStatEnabled.Get()
+}
+
+// GetTopKParams returns the top-K hot parameter values of all rules of the given resource.
+// The parameter values are traced by the bounded cache of the rule,
+// so the least recently used values may have been evicted.
+// The pass and block amount are only recorded when ParamStatEnabled.
+func GetTopKParams(res string, k int) []RuleTopKParams {
+	return topKParamsOf(getTrafficControllersFor(res), k)
+}
+
+// GetAllTopKParams returns the top-K hot parameter values of all rules.
+func GetAllTopKParams(k int) []RuleTopKParams {
+	tcMux.RLock()
+	tcs := make([]TrafficShapingController, 0, len(tcMap))
+	for _, resTcs := range tcMap {
+		tcs = append(tcs, resTcs...)
+	}
+	tcMux.RUnlock()
+
+	ret := topKParamsOf(tcs, k)
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Resource < ret[j].Resource
+	})
+	return ret
+}
+
+func topKParamsOf(tcs []TrafficShapingController, k int) []RuleTopKParams {
+	ret := make([]RuleTopKParams, 0, len(tcs))
+	if k <= 0 {
+		return ret
+	}
+	for _, tc := range tcs {
+		if tc == nil || tc.BoundRule() == nil || tc.BoundMetric() == nil {
+			continue
+		}
+		r := tc.BoundRule()
+		ret = append(ret, RuleTopKParams{
+			Resource: r.Resource,
+			RuleId:   r.ID,
+			Rule:     *r,
+			Params:   topKParamsOfMetric(tc.BoundMetric(), k),
+		})
+	}
+	return ret
+}
+
+func topKParamsOfMetric(m *ParamsMetric, k int) []ParamStat {
+	// The pass and block counters are nil if the parameter statistic has never been enabled.
+	passCounter, blockCounter := m.paramStatCounters(false)
+	keys := make(map[interface{}]struct{})
+	for _, counter := range []cache.ConcurrentCounterCache{passCounter, blockCounter, m.ConcurrencyCounter} {
+		if counter == nil {
+			continue
+		}
+		for _, key := range counter.Keys() {
+			keys[key] = struct{}{}
+		}
+	}
+
+	stats := make([]ParamStat, 0, len(keys))
+	for key := range keys {
+		stats = append(stats, ParamStat{
+			Value:       key,
+			PassCount:   peekCount(passCounter, key),
+			BlockCount:  peekCount(blockCounter, key),
+			Concurrency: peekCount(m.ConcurrencyCounter, key),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		ti, tj := stats[i].PassCount+stats[i].BlockCount, stats[j].PassCount+stats[j].BlockCount
+		if ti != tj {
+			return ti > tj
+		}
+		if stats[i].Concurrency != stats[j].Concurrency {
+			return stats[i].Concurrency > stats[j].Concurrency
+		}
+		return fmt.Sprint(stats[i].Value) < fmt.Sprint(stats[j].Value)
+	})
+	if len(stats) > k {
+		stats = stats[:k]
+	}
+	return stats
+}
+
+// InitTopKParamsReporter starts a background task which exports the top-K hot parameter values
+// of all rules to the metric exporter every intervalMs milliseconds, the parameter statistic is enabled as well.
+// The k is bounded by MaxTopKParamsCount, and the DefaultTopKParamsCount would be used if k is not positive.
+// It does nothing if the reporter is running.
+func InitTopKParamsReporter(intervalMs uint32, k int) {
+	if intervalMs == 0 {
+		return
+	}
+	if k <= 0 {
+		k = DefaultTopKParamsCount
+	}
+	if k > MaxTopKParamsCount {
+		k = MaxTopKParamsCount
+	}
+	topKReporterMux.Lock()
+	defer topKReporterMux.Unlock()
+	if topKReporterStopChan != nil {
+		return
+	}
+	stopChan := make(chan struct{})
+	topKReporterStopChan = stopChan
+	SetParamStatEnabled(true)
+	reportTopKParams(k)
+
+	ticker := util.NewTicker(time.Duration(intervalMs) * time.Millisecond)
+	go util.RunWithRecover(func() {
+		for {
+			select {
+			case <-ticker.C():
+				reportTopKParams(k)
+			case <-stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	})
+}
+
+// StopTopKParamsReporter stops the running reporter, disables the parameter statistic and clears the exported gauges.
+func StopTopKParamsReporter() {
+	topKReporterMux.Lock()
+	defer topKReporterMux.Unlock()
+	if topKReporterStopChan == nil {
+		return
+	}
+	close(topKReporterStopChan)
+	topKReporterStopChan = nil
+	SetParamStatEnabled(false)
+	paramPassGauge.Reset()
+	paramBlockGauge.Reset()
+	paramConcurrencyGauge.Reset()
+}
+
+// reportTopKParams refreshes the top-K gauges. The gauges are reset first
+// so that the parameters that are no longer hot would not be exported anymore.
+func reportTopKParams(k int) {
+	all := GetAllTopKParams(k)
+	paramPassGauge.Reset()
+	paramBlockGauge.Reset()
+	paramConcurrencyGauge.Reset()
+	for _, rp := range all {
+		for _, p := range rp.Params {
+			param := paramLabelOf(p.Value)
+			paramPassGauge.Set(float64(p.PassCount), rp.Resource, rp.RuleId, param)
+			paramBlockGauge.Set(float64(p.BlockCount), rp.Resource, rp.RuleId, param)
+			if rp.Rule.MetricType == Concurrency {
+				paramConcurrencyGauge.Set(float64(p.Concurrency), rp.Resource, rp.RuleId, param)
+			}
+		}
+	}
+}
+
+func paramLabelOf(value interface{}) string {
+	label := fmt.Sprint(value)
+	if len(label) > maxParamLabelLength {
+		return label[:maxParamLabelLength]
+	}
+	return label
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/alibaba/sentinel-golang/core/base"
-	"github.com/alibaba/sentinel-golang/core/hotspot/cache"
 	"github.com/alibaba/sentinel-golang/logging"
 	"github.com/alibaba/sentinel-golang/util"
 	"github.com/pkg/errors"
@@ -33,8 +32,12 @@ type baseTrafficShapingController struct {
 	metricType    MetricType
 	paramIndex    int
 	paramKey      string
+	paramIndexes  []int
+	paramKeys     []string
+	paramPath     *argPath
 	threshold     int64
 	specificItems map[interface{}]int64
+	matchers      []*paramMatcher
 	durationInSec int64
 
 	metric *ParamsMetric
@@ -44,14 +47,30 @@ func newBaseTrafficShapingControllerWithMetric(r *Rule, metric *ParamsMetric) *b
 	if r.SpecificItems == nil {
 		r.SpecificItems = make(map[interface{}]int64)
 	}
+	var paramPath *argPath
+	if r.ParamPath != "" {
+		path, err := parseArgPath(r.ParamPath)
+		if err != nil {
+			logging.Warn("[HotSpot newBaseTrafficShapingControllerWithMetric] Ignoring the invalid param path", "rule", r, "err", err.Error())
+		}
+		paramPath = path
+	}
+	matchers, err := compileSpecificMatchers(r.SpecificMatchers)
+	if err != nil {
+		logging.Warn("[HotSpot newBaseTrafficShapingControllerWithMetric] Ignoring the invalid specific matchers", "rule", r, "err", err.Error())
+	}
 	return &baseTrafficShapingController{
 		r:             r,
 		res:           r.Resource,
 		metricType:    r.MetricType,
 		paramIndex:    r.ParamIndex,
 		paramKey:      r.ParamKey,
+		paramIndexes:  r.ParamIndexes,
+		paramKeys:     r.ParamKeys,
+		paramPath:     paramPath,
 		threshold:     r.Threshold,
 		specificItems: r.SpecificItems,
+		matchers:      matchers,
 		durationInSec: r.DurationInSec,
 		metric:        metric,
 	}
@@ -74,9 +93,10 @@ func newBaseTrafficShapingController(r *Rule) *baseTrafficShapingController {
 			size = ParamsMaxCapacity
 		}
 		metric := &ParamsMetric{
-			RuleTimeCounter:  cache.NewLRUCacheMap(size),
-			RuleTokenCounter: cache.NewLRUCacheMap(size),
+			RuleTimeCounter:  newCounterCache(r.ParamsCacheType, size),
+			RuleTokenCounter: newCounterCache(r.ParamsCacheType, size),
 		}
+		metric.withParamStatCache(r.ParamsCacheType, size)
 		return newBaseTrafficShapingControllerWithMetric(r, metric)
 	case Concurrency:
 		size := 0
@@ -86,8 +106,9 @@ func newBaseTrafficShapingController(r *Rule) *baseTrafficShapingController {
 			size = ConcurrencyMaxCount // 4000
 		}
 		metric := &ParamsMetric{
-			ConcurrencyCounter: cache.NewLRUCacheMap(size),
+			ConcurrencyCounter: newCounterCache(r.ParamsCacheType, size),
 		}
+		metric.withParamStatCache(r.ParamsCacheType, size)
 		return newBaseTrafficShapingControllerWithMetric(r, metric)
 	default:
 		logging.Error(errors.New("unsupported metric type"), "Ignoring the rule due to unsupported  metric type in Rule.newBaseTrafficShapingController()", "MetricType", r.MetricType.String())
@@ -99,8 +120,21 @@ func (c *baseTrafficShapingController) BoundMetric() *ParamsMetric {
 	return c.metric
 }
 
+// specificThresholdOf returns the specific threshold of arg, the exact matches of specificItems go first,
+// then the matchers in order.
+func (c *baseTrafficShapingController) specificThresholdOf(arg interface{}) (int64, bool) {
+	if threshold, existed := c.specificItems[arg]; existed {
+		return threshold, true
+	}
+	for _, m := range c.matchers {
+		if m.match(arg) {
+			return m.threshold, true
+		}
+	}
+	return 0, false
+}
+
 func (c *baseTrafficShapingController) performCheckingForConcurrencyMetric(arg interface{}) *base.TokenResult {
-	specificItem := c.specificItems
 	initConcurrency := int64(0)
 	concurrencyPtr := c.metric.ConcurrencyCounter.AddIfAbsent(arg, &initConcurrency)
 	if concurrencyPtr == nil {
@@ -109,7 +143,7 @@ func (c *baseTrafficShapingController) performCheckingForConcurrencyMetric(arg i
 	}
 	concurrency := atomic.LoadInt64(concurrencyPtr)
 	concurrency++
-	if specificConcurrency, existed := specificItem[arg]; existed {
+	if specificConcurrency, existed := c.specificThresholdOf(arg); existed {
 		if concurrency <= specificConcurrency {
 			return nil
 		}
@@ -150,6 +184,12 @@ func (c *baseTrafficShapingController) ExtractArgs(ctx *base.EntryContext) (valu
 	if c == nil {
 		return nil
 	}
+	if c.paramPath != nil {
+		return c.paramPath.extract(c, ctx)
+	}
+	if len(c.paramIndexes) > 0 || len(c.paramKeys) > 0 {
+		return c.extractCompositeArgs(ctx)
+	}
 	value = c.extractAttachmentArgs(ctx)
 	if value != nil {
 		return
@@ -160,54 +200,85 @@ func (c *baseTrafficShapingController) ExtractArgs(ctx *base.EntryContext) (valu
 	}
 	return
 }
+
+// extractCompositeArgs builds the CompositeKey from the values of paramIndexes and paramKeys,
+// return nil if any of the values doesn't exist.
+func (c *baseTrafficShapingController) extractCompositeArgs(ctx *base.EntryContext) interface{} {
+	values := make([]interface{}, 0, len(c.paramIndexes)+len(c.paramKeys))
+	for _, idx := range c.paramIndexes {
+		arg := c.extractArgOfIndex(ctx, idx)
+		if arg == nil {
+			return nil
+		}
+		values = append(values, arg)
+	}
+	for _, key := range c.paramKeys {
+		arg := c.extractAttachmentOfKey(ctx, key)
+		if arg == nil {
+			return nil
+		}
+		values = append(values, arg)
+	}
+	return NewCompositeKey(values...)
+}
+
 func (c *baseTrafficShapingController) extractArgs(ctx *base.EntryContext) interface{} {
+	return c.extractArgOfIndex(ctx, c.BoundParamIndex())
+}
+
+func (c *baseTrafficShapingController) extractArgOfIndex(ctx *base.EntryContext, paramIndex int) interface{} {
 	args := ctx.Input.Args
-	idx := c.BoundParamIndex()
+	idx := paramIndex
 	if idx < 0 {
 		idx = len(args) + idx
 	}
 	if idx < 0 {
 		if logging.DebugEnabled() {
 			logging.Debug("[extractArgs] The param index of hotspot traffic shaping controller is invalid",
-				"args", args, "paramIndex", c.BoundParamIndex())
+				"args", args, "paramIndex", paramIndex)
 		}
 		return nil
 	}
 	if idx >= len(args) {
 		if logging.DebugEnabled() {
 			logging.Debug("[extractArgs] The argument in index doesn't exist",
-				"args", args, "paramIndex", c.BoundParamIndex())
+				"args", args, "paramIndex", paramIndex)
 		}
 		return nil
 	}
-	return args[idx]
+	return normalizeArg(args[idx])
 }
+
 func (c *baseTrafficShapingController) extractAttachmentArgs(ctx *base.EntryContext) interface{} {
+	return c.extractAttachmentOfKey(ctx, c.paramKey)
+}
+
+func (c *baseTrafficShapingController) extractAttachmentOfKey(ctx *base.EntryContext, paramKey string) interface{} {
 	attachments := ctx.Input.Attachments
 
 	if attachments == nil {
 		if logging.DebugEnabled() {
 			logging.Debug("[paramKey] The attachments of ctx is nil",
-				"args", attachments, "paramKey", c.paramKey)
+				"args", attachments, "paramKey", paramKey)
 		}
 		return nil
 	}
-	if c.paramKey == "" {
+	if paramKey == "" {
 		if logging.DebugEnabled() {
 			logging.Debug("[paramKey] The param key is nil",
-				"args", attachments, "paramKey", c.paramKey)
+				"args", attachments, "paramKey", paramKey)
 		}
 		return nil
 	}
-	arg, ok := attachments[c.paramKey]
+	arg, ok := attachments[paramKey]
 	if !ok {
 		if logging.DebugEnabled() {
 			logging.Debug("[paramKey] extracted data does not exist",
-				"args", attachments, "paramKey", c.paramKey)
+				"args", attachments, "paramKey", paramKey)
 		}
 	}
 
-	return arg
+	return normalizeArg(arg)
 }
 
 func (c *rejectTrafficShapingController) PerformChecking(arg interface{}, batchCount int64) *base.TokenResult {
@@ -230,7 +301,7 @@ func (c *rejectTrafficShapingController) PerformChecking(arg interface{}, batchC
 
 	// calculate available token
 	tokenCount := c.threshold
-	val, existed := c.specificItems[arg]
+	val, existed := c.specificThresholdOf(arg)
 	if existed {
 		tokenCount = val
 	}
@@ -299,6 +370,12 @@ func (c *rejectTrafficShapingController) PerformChecking(arg interface{}, batchC
 					msg := fmt.Sprintf("hotspot reject check blocked, request batch count is more than available token count, arg: %v", arg)
 					return base.NewTokenResultBlockedWithCause(base.BlockTypeHotSpotParamFlow, msg, c.BoundRule(), nil)
 				}
+			} else {
+				// The token counter of arg has been evicted while the time counter is kept, refill the tokens.
+				leftCount := maxCount - batchCount
+				if tokenCounter.AddIfAbsent(arg, &leftCount) == nil {
+					return nil
+				}
 			}
 			runtime.Gosched()
 		}
@@ -325,7 +402,7 @@ func (c *throttlingTrafficShapingController) PerformChecking(arg interface{}, ba
 
 	// calculate available token
 	tokenCount := c.threshold
-	val, existed := c.specificItems[arg]
+	val, existed := c.specificThresholdOf(arg)
 	if existed {
 		tokenCount = val
 	}
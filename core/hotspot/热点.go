@@ -56,6 +56,15 @@ type Rule struct {
 	// ParamKey can be used as a supplement to ParamIndex to facilitate rules to quickly obtain parameter from a large number of parameters
 	// ParamKey is mutually exclusive with ParamIndex, ParamKey has the higher priority than ParamIndex
 	ParamKey string `json:"paramKey"`
+	// ParamIndexes and ParamKeys compose the composite parameter, the values of the indexes in context arguments slice
+	// and the values of the keys in EntryContext.Input.Attachments map form a CompositeKey in order (indexes first).
+	// ParamIndexes and ParamKeys have the higher priority than ParamIndex and ParamKey.
+	ParamIndexes []int    `json:"paramIndexes,omitempty"`
+	ParamKeys    []string `json:"paramKeys,omitempty"`
+	// ParamPath is the field path into an argument or an attachment, e.g. "args[0].User.ID" or "attachments[req]/user/id".
+	// The path is resolved by cached reflection or the FieldExtractor registered via RegisterFieldExtractor.
+	// ParamPath has the highest priority and is mutually exclusive with ParamIndexes and ParamKeys.
+	ParamPath string `json:"paramPath,omitempty"`
 	// Threshold is the threshold to trigger rejection
 	Threshold int64 `json:"threshold"`
 	// MaxQueueingTimeMs only takes effect when ControlBehavior is Throttling and MetricType is QPS
@@ -68,28 +77,37 @@ type Rule struct {
 	DurationInSec     int64                 `json:"durationInSec"`
 	ParamsMaxCapacity int64                 `json:"paramsMaxCapacity"` // cache 最大容量
 	SpecificItems     map[interface{}]int64 `json:"specificItems"`     // 特定值的特殊阈值
+	// SpecificMatchers are the prefix/regex/range/CIDR matchers with their own thresholds,
+	// which are evaluated in order after the exact matches of SpecificItems.
+	SpecificMatchers []SpecificMatcher `json:"specificMatchers,omitempty"`
+	// ParamsCacheType is the cache implementation of the statistic, DefaultCacheType follows the global default one.
+	ParamsCacheType CacheType `json:"paramsCacheType,omitempty"`
 }
 
 func (r *Rule) String() string {
 	b, err := json.Marshal(r)
 	if err != nil {
 		// Return the fallback string
-		return fmt.Sprintf("{Id:%s, Resource:%s, MetricType:%+v, ControlBehavior:%+v, ParamIndex:%d, ParamKey:%s, Threshold:%d, MaxQueueingTimeMs:%d, BurstCount:%d, DurationInSec:%d, ParamsMaxCapacity:%d, SpecificItems:%+v}",
-			r.ID, r.Resource, r.MetricType, r.ControlBehavior, r.ParamIndex, r.ParamKey, r.Threshold, r.MaxQueueingTimeMs, r.BurstCount, r.DurationInSec, r.ParamsMaxCapacity, r.SpecificItems)
+		return fmt.Sprintf("{Id:%s, Resource:%s, MetricType:%+v, ControlBehavior:%+v, ParamIndex:%d, ParamKey:%s, ParamIndexes:%v, ParamKeys:%v, ParamPath:%s, Threshold:%d, MaxQueueingTimeMs:%d, BurstCount:%d, DurationInSec:%d, ParamsMaxCapacity:%d, ParamsCacheType:%s, SpecificItems:%+v, SpecificMatchers:%+v}",
+			r.ID, r.Resource, r.MetricType, r.ControlBehavior, r.ParamIndex, r.ParamKey, r.ParamIndexes, r.ParamKeys, r.ParamPath, r.Threshold, r.MaxQueueingTimeMs, r.BurstCount, r.DurationInSec, r.ParamsMaxCapacity, r.ParamsCacheType, r.SpecificItems, r.SpecificMatchers)
 	}
 	return string(b)
 }
+
 func (r *Rule) ResourceName() string {
 	return r.Resource
 }
 
 // IsStatReusable checks whether current rule is "statistically" equal to the given rule.
 func (r *Rule) IsStatReusable(newRule *Rule) bool {
-	return r.Resource == newRule.Resource && r.ControlBehavior == newRule.ControlBehavior && r.ParamsMaxCapacity == newRule.ParamsMaxCapacity && r.DurationInSec == newRule.DurationInSec && r.MetricType == newRule.MetricType
+	return r.Resource == newRule.Resource && r.ControlBehavior == newRule.ControlBehavior && r.ParamsMaxCapacity == newRule.ParamsMaxCapacity && r.DurationInSec == newRule.DurationInSec && r.MetricType == newRule.MetricType &&
+		r.ParamsCacheType == newRule.ParamsCacheType
 }
 
 func (r *Rule) Equals(newRule *Rule) bool {
-	baseCheck := r.Resource == newRule.Resource && r.MetricType == newRule.MetricType && r.ControlBehavior == newRule.ControlBehavior && r.ParamsMaxCapacity == newRule.ParamsMaxCapacity && r.ParamIndex == newRule.ParamIndex && r.ParamKey == newRule.ParamKey && r.Threshold == newRule.Threshold && r.DurationInSec == newRule.DurationInSec && reflect.DeepEqual(r.SpecificItems, newRule.SpecificItems)
+	baseCheck := r.Resource == newRule.Resource && r.MetricType == newRule.MetricType && r.ControlBehavior == newRule.ControlBehavior && r.ParamsMaxCapacity == newRule.ParamsMaxCapacity && r.ParamsCacheType == newRule.ParamsCacheType && r.ParamIndex == newRule.ParamIndex && r.ParamKey == newRule.ParamKey &&
+		reflect.DeepEqual(r.ParamIndexes, newRule.ParamIndexes) && reflect.DeepEqual(r.ParamKeys, newRule.ParamKeys) && r.ParamPath == newRule.ParamPath && r.Threshold == newRule.Threshold && r.DurationInSec == newRule.DurationInSec && reflect.DeepEqual(r.SpecificItems, newRule.SpecificItems) &&
+		reflect.DeepEqual(r.SpecificMatchers, newRule.SpecificMatchers)
 	if !baseCheck {
 		return false
 	}
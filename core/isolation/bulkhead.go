@@ -0,0 +1,260 @@
+package isolation
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	metric_exporter "github.com/alibaba/sentinel-golang/exporter/metric"
+	"github.com/alibaba/sentinel-golang/util"
+)
+
+const (
+	// bulkheadReservationsKey is the key in EntryContext.Data, which records the bulkheads
+	// reserved the slots for the current entry.
+	bulkheadReservationsKey = "$isolation.bulkheadReservations"
+)
+
+var (
+	bulkheadMap = make(map[*Rule]*bulkhead)
+
+	queueLengthGauge = metric_exporter.NewGauge(
+		"isolation_queue_length",
+		"Amount of callers waiting in the isolation bulkhead queue",
+		[]string{"resource"})
+	queueWaitTimeHistogram = metric_exporter.NewHistogram(
+		"isolation_queue_wait_time_ms",
+		"Time that callers wait in the isolation bulkhead queue in milliseconds",
+		[]float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000},
+		[]string{"resource", "result"})
+)
+
+func init() {
+	metric_exporter.Register(queueLengthGauge)
+	metric_exporter.Register(queueWaitTimeHistogram)
+}
+
+type waiter struct {
+	batchCount uint32
+	ch         chan struct{}
+	granted    bool
+}
+
+// bulkhead lets at most MaxQueueingCount callers wait in FIFO order for the free slot
+// when the concurrency exceeds the threshold of the rule.
+type bulkhead struct {
+	rule    *Rule
+	mux     sync.Mutex
+	waiters *list.List
+	// reserved is the amount of slots granted to the waiters whose concurrency is not counted yet.
+	reserved uint32
+}
+
+func newBulkhead(rule *Rule) *bulkhead {
+	return &bulkhead{
+		rule:    rule,
+		waiters: list.New(),
+	}
+}
+
+// bulkheadResult is the result of acquiring the slot from bulkhead.
+type bulkheadResult int
+
+const (
+	bulkheadPassed bulkheadResult = iota
+	bulkheadQueueFull
+	bulkheadTimeout
+	bulkheadCanceled
+)
+
+func (r bulkheadResult) String() string {
+	switch r {
+	case bulkheadPassed:
+		return "passed"
+	case bulkheadQueueFull:
+		return "queue full"
+	case bulkheadTimeout:
+		return "timeout"
+	case bulkheadCanceled:
+		return "canceled"
+	default:
+		return "undefined"
+	}
+}
+
+// acquire tries to acquire batchCount slots, the caller waits in the queue if there is no free slot.
+// The returned bool indicates whether the slots are reserved by the bulkhead, which must be released
+// after the concurrency of the entry is counted.
+func (b *bulkhead) acquire(ctx *base.EntryContext) (bulkheadResult, bool) {
+	batchCount := ctx.Input.BatchCount
+	b.mux.Lock()
+	// Read the concurrency with the lock held, so that the completion right before queueing wouldn't be missed.
+	curCount := currentConcurrencyOf(ctx.StatNode)
+	if b.waiters.Len() == 0 && curCount+b.reserved+batchCount <= b.rule.Threshold {
+		b.mux.Unlock()
+		return bulkheadPassed, false
+	}
+	if uint32(b.waiters.Len()) >= b.rule.MaxQueueingCount {
+		b.mux.Unlock()
+		return bulkheadQueueFull, false
+	}
+	w := &waiter{
+		batchCount: batchCount,
+		ch:         make(chan struct{}, 1),
+	}
+	elem := b.waiters.PushBack(w)
+	queueLengthGauge.Set(float64(b.waiters.Len()), b.rule.Resource)
+	b.mux.Unlock()
+
+	start := util.CurrentTimeNano()
+	timer := time.NewTimer(time.Duration(b.rule.MaxQueueingTimeMs) * time.Millisecond)
+	defer timer.Stop()
+	var done <-chan struct{}
+	if ctx.Input.Context != nil {
+		done = ctx.Input.Context.Done()
+	}
+
+	result := bulkheadPassed
+	select {
+	case <-w.ch:
+	case <-timer.C:
+		result = bulkheadTimeout
+	case <-done:
+		result = bulkheadCanceled
+	}
+	if result != bulkheadPassed {
+		b.mux.Lock()
+		if w.granted {
+			// The slot is granted right before giving up waiting.
+			result = bulkheadPassed
+		} else {
+			b.waiters.Remove(elem)
+			queueLengthGauge.Set(float64(b.waiters.Len()), b.rule.Resource)
+		}
+		b.mux.Unlock()
+	}
+	queueWaitTimeHistogram.Observe(float64(util.CurrentTimeNano()-start)/float64(time.Millisecond), b.rule.Resource, result.String())
+	return result, result == bulkheadPassed
+}
+
+// release releases the slots reserved for the waiter, and wakes up the next waiters if the slots are still free.
+func (b *bulkhead) release(batchCount uint32, statNode base.StatNode) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.reserved >= batchCount {
+		b.reserved -= batchCount
+	} else {
+		b.reserved = 0
+	}
+	b.grantLocked(currentConcurrencyOf(statNode))
+}
+
+// notify wakes up the waiters in FIFO order while there are free slots.
+func (b *bulkhead) notify(statNode base.StatNode) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.grantLocked(currentConcurrencyOf(statNode))
+}
+
+func (b *bulkhead) grantLocked(curCount uint32) {
+	for {
+		front := b.waiters.Front()
+		if front == nil {
+			break
+		}
+		w := front.Value.(*waiter)
+		if curCount+b.reserved+w.batchCount > b.rule.Threshold {
+			break
+		}
+		b.waiters.Remove(front)
+		b.reserved += w.batchCount
+		w.granted = true
+		w.ch <- struct{}{}
+	}
+	queueLengthGauge.Set(float64(b.waiters.Len()), b.rule.Resource)
+}
+
+func getBulkhead(rule *Rule) *bulkhead {
+	rwMux.RLock()
+	defer rwMux.RUnlock()
+	return bulkheadMap[rule]
+}
+
+// bulkheadsOfResource returns the bulkheads of the resource, rwMux must be held.
+func bulkheadsOfResource(res string) []*bulkhead {
+	rules := ruleMap[res]
+	ret := make([]*bulkhead, 0, len(rules))
+	for _, r := range rules {
+		if b, ok := bulkheadMap[r]; ok {
+			ret = append(ret, b)
+		}
+	}
+	return ret
+}
+
+// rebuildBulkheadsOf creates the bulkheads for the rules of the resource, the bulkheads of the unchanged rules are reused.
+// rwMux must be held.
+func rebuildBulkheadsOf(res string, oldRules []*Rule, newRules []*Rule) {
+	retained := make(map[*Rule]struct{}, len(newRules))
+	for _, r := range newRules {
+		retained[r] = struct{}{}
+		if r.MaxQueueingCount == 0 {
+			continue
+		}
+		if _, ok := bulkheadMap[r]; !ok {
+			bulkheadMap[r] = newBulkhead(r)
+		}
+	}
+	for _, r := range oldRules {
+		if _, ok := retained[r]; !ok {
+			// The waiters of the removed bulkhead would be rejected after the max queueing time.
+			delete(bulkheadMap, r)
+		}
+	}
+}
+
+func currentConcurrencyOf(statNode base.StatNode) uint32 {
+	if statNode == nil {
+		return 0
+	}
+	if cur := statNode.CurrentConcurrency(); cur > 0 {
+		return uint32(cur)
+	}
+	return 0
+}
+
+// notifyBulkheads wakes up the waiters of the resource's bulkheads when an entry completes.
+func notifyBulkheads(ctx *base.EntryContext) {
+	if ctx.Resource == nil {
+		return
+	}
+	rwMux.RLock()
+	bulkheads := bulkheadsOfResource(ctx.Resource.Name())
+	rwMux.RUnlock()
+	for _, b := range bulkheads {
+		b.notify(ctx.StatNode)
+	}
+}
+
+func releaseReservations(ctx *base.EntryContext) {
+	if ctx.Data == nil {
+		return
+	}
+	reserved, ok := ctx.Data[bulkheadReservationsKey].([]*bulkhead)
+	if !ok {
+		return
+	}
+	delete(ctx.Data, bulkheadReservationsKey)
+	for _, b := range reserved {
+		b.release(ctx.Input.BatchCount, ctx.StatNode)
+	}
+}
+
+func addReservation(ctx *base.EntryContext, b *bulkhead) {
+	if ctx.Data == nil {
+		ctx.Data = make(map[interface{}]interface{})
+	}
+	reserved, _ := ctx.Data[bulkheadReservationsKey].([]*bulkhead)
+	ctx.Data[bulkheadReservationsKey] = append(reserved, b)
+}
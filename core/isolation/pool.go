@@ -0,0 +1,351 @@
+package isolation
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	metric_exporter "github.com/alibaba/sentinel-golang/exporter/metric"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/pkg/errors"
+)
+
+const (
+	// poolAcquisitionsKey is the key in EntryContext.Data, which records the pool members acquired by the current entry.
+	poolAcquisitionsKey = "$isolation.poolAcquisitions"
+)
+
+// PoolMember describes the resources which join the pool.
+type PoolMember struct {
+	// Resource is the exact resource name of the member.
+	Resource string `json:"resource,omitempty"`
+	// ResourcePattern is the regular expression of the resource names, it only takes effect when Resource is empty.
+	ResourcePattern string `json:"resourcePattern,omitempty"`
+	// Reservation is the amount of slots reserved for the member, which the other members can't take.
+	Reservation uint32 `json:"reservation,omitempty"`
+	// Cap is the max amount of slots the member can take, 0 means no limitation besides the threshold of pool.
+	Cap uint32 `json:"cap,omitempty"`
+}
+
+func (m *PoolMember) name() string {
+	if len(m.Resource) > 0 {
+		return m.Resource
+	}
+	return m.ResourcePattern
+}
+
+// Pool is a named concurrency budget shared by several resources.
+// A resource takes the slots from the first member it matches.
+type Pool struct {
+	Name      string       `json:"name"`
+	Threshold uint32       `json:"threshold"`
+	Members   []PoolMember `json:"members"`
+}
+
+func (p *Pool) String() string {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Sprintf("{Name=%s, Threshold=%d, Members=%+v}", p.Name, p.Threshold, p.Members)
+	}
+	return string(b)
+}
+
+// ResourceName returns the name of pool, so that the pool could be the triggered rule of BlockError.
+func (p *Pool) ResourceName() string {
+	return p.Name
+}
+
+// PoolUsage is the current concurrency of the pool and its members.
+type PoolUsage struct {
+	Name        string            `json:"name"`
+	Threshold   uint32            `json:"threshold"`
+	Concurrency uint32            `json:"concurrency"`
+	Members     map[string]uint32 `json:"members"`
+}
+
+type poolMemberStat struct {
+	member   PoolMember
+	pattern  *regexp.Regexp
+	inUse    uint32
+	poolStat *poolStat
+}
+
+// sharedInUse returns the slots taken beyond the reservation.
+func (m *poolMemberStat) sharedInUse(inUse uint32) uint32 {
+	if inUse > m.member.Reservation {
+		return inUse - m.member.Reservation
+	}
+	return 0
+}
+
+type poolStat struct {
+	pool    *Pool
+	mux     sync.Mutex
+	members []*poolMemberStat
+	// sharedCapacity is the threshold minus the total reservations.
+	sharedCapacity uint32
+	sharedInUse    uint32
+	inUse          uint32
+}
+
+func newPoolStat(p *Pool) *poolStat {
+	ps := &poolStat{
+		pool:    p,
+		members: make([]*poolMemberStat, 0, len(p.Members)),
+	}
+	reserved := uint32(0)
+	for _, m := range p.Members {
+		ms := &poolMemberStat{member: m, poolStat: ps}
+		if len(m.Resource) == 0 {
+			// The pattern is validated before.
+			ms.pattern = regexp.MustCompile(m.ResourcePattern)
+		}
+		ps.members = append(ps.members, ms)
+		reserved += m.Reservation
+	}
+	ps.sharedCapacity = p.Threshold - reserved
+	return ps
+}
+
+func (ps *poolStat) memberOf(res string) *poolMemberStat {
+	for _, m := range ps.members {
+		if len(m.member.Resource) > 0 {
+			if m.member.Resource == res {
+				return m
+			}
+			continue
+		}
+		if m.pattern.MatchString(res) {
+			return m
+		}
+	}
+	return nil
+}
+
+// tryAcquire takes batchCount slots for the member, the slots within the reservation go first.
+func (m *poolMemberStat) tryAcquire(batchCount uint32) bool {
+	ps := m.poolStat
+	ps.mux.Lock()
+	defer ps.mux.Unlock()
+
+	newInUse := m.inUse + batchCount
+	if m.member.Cap > 0 && newInUse > m.member.Cap {
+		return false
+	}
+	sharedDelta := m.sharedInUse(newInUse) - m.sharedInUse(m.inUse)
+	if ps.sharedInUse+sharedDelta > ps.sharedCapacity {
+		return false
+	}
+	m.inUse = newInUse
+	ps.sharedInUse += sharedDelta
+	ps.inUse += batchCount
+	poolConcurrencyGauge.Set(float64(m.inUse), ps.pool.Name, m.member.name())
+	return true
+}
+
+func (m *poolMemberStat) release(batchCount uint32) {
+	ps := m.poolStat
+	ps.mux.Lock()
+	defer ps.mux.Unlock()
+
+	if batchCount > m.inUse {
+		batchCount = m.inUse
+	}
+	newInUse := m.inUse - batchCount
+	ps.sharedInUse -= m.sharedInUse(m.inUse) - m.sharedInUse(newInUse)
+	m.inUse = newInUse
+	ps.inUse -= batchCount
+	poolConcurrencyGauge.Set(float64(m.inUse), ps.pool.Name, m.member.name())
+}
+
+var (
+	currentPools = make([]*Pool, 0)
+	poolStats    = make([]*poolStat, 0)
+	poolMux      = new(sync.RWMutex)
+	// resPoolMembers caches the pool members of the resource, the resources out of pools are not cached,
+	// so that the cache is bounded by the members of pools.
+	resPoolMembers = new(sync.Map)
+
+	poolConcurrencyGauge = metric_exporter.NewGauge(
+		"isolation_pool_concurrency",
+		"Current concurrency of the isolation pool members",
+		[]string{"pool", "member"})
+)
+
+func init() {
+	metric_exporter.Register(poolConcurrencyGauge)
+}
+
+// LoadPools replaces all the isolation pools with the given pools.
+// The usage of the pools with the same definition is retained.
+func LoadPools(pools []*Pool) (bool, error) {
+	poolMux.Lock()
+	defer poolMux.Unlock()
+	if reflect.DeepEqual(currentPools, pools) {
+		logging.Info("[Isolation] Load pools is the same with current pools, so ignore load operation.")
+		return false, nil
+	}
+
+	newStats := make([]*poolStat, 0, len(pools))
+	for _, p := range pools {
+		if err := IsValidPool(p); err != nil {
+			logging.Warn("[Isolation LoadPools] Ignoring invalid isolation pool", "pool", p, "reason", err.Error())
+			continue
+		}
+		var reused *poolStat
+		for _, old := range poolStats {
+			if reflect.DeepEqual(old.pool, p) {
+				reused = old
+				break
+			}
+		}
+		if reused == nil {
+			reused = newPoolStat(p)
+		}
+		newStats = append(newStats, reused)
+	}
+	poolStats = newStats
+	currentPools = pools
+	resPoolMembers = new(sync.Map)
+	logging.Info("[Isolation] Isolation pools were loaded", "pools", pools)
+	return true, nil
+}
+
+// ClearPools clears all the isolation pools.
+func ClearPools() error {
+	_, err := LoadPools(nil)
+	return err
+}
+
+// GetPools returns all the valid isolation pools based on copy.
+func GetPools() []Pool {
+	poolMux.RLock()
+	defer poolMux.RUnlock()
+	ret := make([]Pool, 0, len(poolStats))
+	for _, ps := range poolStats {
+		ret = append(ret, *ps.pool)
+	}
+	return ret
+}
+
+// GetPoolUsage returns the current concurrency of the pool and its members.
+func GetPoolUsage(name string) (PoolUsage, bool) {
+	poolMux.RLock()
+	defer poolMux.RUnlock()
+	for _, ps := range poolStats {
+		if ps.pool.Name != name {
+			continue
+		}
+		ps.mux.Lock()
+		usage := PoolUsage{
+			Name:        name,
+			Threshold:   ps.pool.Threshold,
+			Concurrency: ps.inUse,
+			Members:     make(map[string]uint32, len(ps.members)),
+		}
+		for _, m := range ps.members {
+			usage.Members[m.member.name()] = m.inUse
+		}
+		ps.mux.Unlock()
+		return usage, true
+	}
+	return PoolUsage{}, false
+}
+
+// IsValidPool checks whether the given Pool is valid.
+func IsValidPool(p *Pool) error {
+	if p == nil {
+		return errors.New("nil isolation pool")
+	}
+	if len(p.Name) == 0 {
+		return errors.New("empty pool name")
+	}
+	if p.Threshold == 0 {
+		return errors.New("zero threshold")
+	}
+	if len(p.Members) == 0 {
+		return errors.New("empty members")
+	}
+	reserved := uint64(0)
+	for _, m := range p.Members {
+		if len(m.Resource) == 0 {
+			if len(m.ResourcePattern) == 0 {
+				return errors.New("empty resource and resource pattern of member")
+			}
+			if _, err := regexp.Compile(m.ResourcePattern); err != nil {
+				return errors.Wrapf(err, "invalid resource pattern: %s", m.ResourcePattern)
+			}
+		}
+		if m.Cap > 0 && m.Cap < m.Reservation {
+			return errors.Errorf("cap is less than reservation of member: %s", m.name())
+		}
+		reserved += uint64(m.Reservation)
+	}
+	if reserved > uint64(p.Threshold) {
+		return errors.New("total reservation exceeds the threshold")
+	}
+	return nil
+}
+
+func poolMembersOf(res string) []*poolMemberStat {
+	poolMux.RLock()
+	defer poolMux.RUnlock()
+	if len(poolStats) == 0 {
+		return nil
+	}
+	if members, ok := resPoolMembers.Load(res); ok {
+		return members.([]*poolMemberStat)
+	}
+	members := make([]*poolMemberStat, 0)
+	for _, ps := range poolStats {
+		if m := ps.memberOf(res); m != nil {
+			members = append(members, m)
+		}
+	}
+	if len(members) > 0 {
+		resPoolMembers.Store(res, members)
+	}
+	return members
+}
+
+// checkPoolPass acquires the slots from all the pools the resource joins.
+func checkPoolPass(ctx *base.EntryContext) (bool, *Pool, uint32) {
+	members := poolMembersOf(ctx.Resource.Name())
+	if len(members) == 0 {
+		return true, nil, 0
+	}
+	batchCount := ctx.Input.BatchCount
+	for i, m := range members {
+		if !m.tryAcquire(batchCount) {
+			for _, acquired := range members[:i] {
+				acquired.release(batchCount)
+			}
+			m.poolStat.mux.Lock()
+			snapshot := m.poolStat.inUse
+			m.poolStat.mux.Unlock()
+			return false, m.poolStat.pool, snapshot
+		}
+	}
+	if ctx.Data == nil {
+		ctx.Data = make(map[interface{}]interface{})
+	}
+	ctx.Data[poolAcquisitionsKey] = members
+	return true, nil, 0
+}
+
+func releasePools(ctx *base.EntryContext) {
+	if ctx.Data == nil {
+		return
+	}
+	members, ok := ctx.Data[poolAcquisitionsKey].([]*poolMemberStat)
+	if !ok {
+		return
+	}
+	delete(ctx.Data, poolAcquisitionsKey)
+	for _, m := range members {
+		m.release(ctx.Input.BatchCount)
+	}
+}
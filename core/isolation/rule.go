@@ -26,12 +26,18 @@ type Rule struct {
 	Resource   string     `json:"resource"`
 	MetricType MetricType `json:"metricType"`
 	Threshold  uint32     `json:"threshold"`
+	// MaxQueueingCount is the max amount of callers waiting for the free slot when the concurrency exceeds the threshold.
+	// The callers are rejected immediately if MaxQueueingCount is 0.
+	MaxQueueingCount uint32 `json:"maxQueueingCount,omitempty"`
+	// MaxQueueingTimeMs is the max time that a caller waits in the queue, it must be positive if MaxQueueingCount is set.
+	MaxQueueingTimeMs uint32 `json:"maxQueueingTimeMs,omitempty"`
 }
 
 func (r *Rule) String() string {
 	b, err := json.Marshal(r)
 	if err != nil {
-		return fmt.Sprintf("{Id=%s, Resource=%s, MetricType=%s, Threshold=%d}", r.ID, r.Resource, r.MetricType.String(), r.Threshold)
+		return fmt.Sprintf("{Id=%s, Resource=%s, MetricType=%s, Threshold=%d, MaxQueueingCount=%d, MaxQueueingTimeMs=%d}",
+			r.ID, r.Resource, r.MetricType.String(), r.Threshold, r.MaxQueueingCount, r.MaxQueueingTimeMs)
 	}
 	return string(b)
 }
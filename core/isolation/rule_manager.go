@@ -58,6 +58,14 @@ func onRuleUpdate(rawResRulesMap map[string][]*Rule) (err error) {
 
 	start := util.CurrentTimeNano()
 	rwMux.Lock()
+	for res := range ruleMap {
+		if _, ok := validResRulesMap[res]; !ok {
+			rebuildBulkheadsOf(res, ruleMap[res], nil)
+		}
+	}
+	for res, rules := range validResRulesMap {
+		rebuildBulkheadsOf(res, ruleMap[res], rules)
+	}
 	ruleMap = validResRulesMap
 	rwMux.Unlock()
 	currentRules = rawResRulesMap
@@ -81,6 +89,7 @@ func LoadRulesOfResource(res string, rules []*Rule) (bool, error) {
 		delete(currentRules, res)
 		// clear ruleMap
 		rwMux.Lock()
+		rebuildBulkheadsOf(res, ruleMap[res], nil)
 		delete(ruleMap, res)
 		rwMux.Unlock()
 		logging.Info("[Isolation] clear resource level rules", "resource", res)
@@ -109,6 +118,7 @@ func onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
 
 	start := util.CurrentTimeNano()
 	rwMux.Lock()
+	rebuildBulkheadsOf(res, ruleMap[res], validResRules)
 	if len(validResRules) == 0 {
 		delete(ruleMap, res)
 	} else {
@@ -219,5 +229,8 @@ func IsValidRule(r *Rule) error {
 	if r.Threshold == 0 {
 		return errors.New("zero threshold")
 	}
+	if r.MaxQueueingCount > 0 && r.MaxQueueingTimeMs == 0 {
+		return errors.New("zero max queueing time with positive max queueing count")
+	}
 	return nil
 }
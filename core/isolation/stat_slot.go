@@ -0,0 +1,38 @@
+package isolation
+
+import (
+	"github.com/alibaba/sentinel-golang/core/base"
+)
+
+const (
+	StatSlotOrder = 3000
+)
+
+var (
+	DefaultStatSlot = &StatSlot{}
+)
+
+// StatSlot releases the slots reserved by the bulkheads and the pools, and wakes up the waiters of bulkheads
+// when the entries complete. It must be placed after the stat.Slot, so that the concurrency of resource is updated.
+// The slot chain with the isolation Slot must contain the StatSlot, otherwise the waiters of bulkheads
+// are not woken up when the entries complete.
+type StatSlot struct {
+}
+
+func (s *StatSlot) Order() uint32 {
+	return StatSlotOrder
+}
+
+func (s *StatSlot) OnEntryPassed(ctx *base.EntryContext) {
+	releaseReservations(ctx)
+}
+
+func (s *StatSlot) OnEntryBlocked(ctx *base.EntryContext, blockError *base.BlockError) {
+	releaseReservations(ctx)
+	releasePools(ctx)
+}
+
+func (s *StatSlot) OnCompleted(ctx *base.EntryContext) {
+	releasePools(ctx)
+	notifyBulkheads(ctx)
+}
@@ -14,6 +14,11 @@ var (
 	DefaultSlot = &Slot{}
 )
 
+// Slot checks the isolation rules and pools of the resource. It must be paired with the DefaultStatSlot,
+// which releases the reserved slots as soon as the concurrency of the entry is counted and wakes up the
+// waiters of bulkheads when the entries complete. Without the DefaultStatSlot, the reserved slots and
+// the pool slots are still released when the entry exits, but the waiters are only woken up by
+// the other entries' releases, or rejected after the max queueing time.
 type Slot struct {
 }
 
@@ -27,18 +32,49 @@ func (s *Slot) Check(ctx *base.EntryContext) *base.TokenResult {
 	if len(resource) == 0 {
 		return result
 	}
-	if passed, rule, snapshot := checkPass(ctx); !passed {
-		msg := "concurrency exceeds threshold"
+	defer releaseOnExit(ctx)
+	if passed, msg, rule, snapshot := checkPass(ctx); !passed {
 		if result == nil {
 			result = base.NewTokenResultBlockedWithCause(base.BlockTypeIsolation, msg, rule, snapshot)
 		} else {
 			result.ResetToBlockedWithCause(base.BlockTypeIsolation, msg, rule, snapshot)
 		}
+		return result
+	}
+	if passed, pool, snapshot := checkPoolPass(ctx); !passed {
+		msg := "pool concurrency exceeds threshold"
+		if result == nil {
+			result = base.NewTokenResultBlockedWithCause(base.BlockTypeIsolation, msg, pool, snapshot)
+		} else {
+			result.ResetToBlockedWithCause(base.BlockTypeIsolation, msg, pool, snapshot)
+		}
 	}
 	return result
 }
 
-func checkPass(ctx *base.EntryContext) (bool, *Rule, uint32) {
+// releaseOnExit registers the exit handler to release the slots reserved by the bulkheads and acquired
+// from the pools for the entry, in case the DefaultStatSlot is absent from the slot chain.
+func releaseOnExit(ctx *base.EntryContext) {
+	if ctx.Data == nil {
+		return
+	}
+	_, reserved := ctx.Data[bulkheadReservationsKey]
+	_, acquired := ctx.Data[poolAcquisitionsKey]
+	if !reserved && !acquired {
+		return
+	}
+	if e := ctx.Entry(); e != nil {
+		e.WhenExit(releaseOnExitHandler)
+	}
+}
+
+func releaseOnExitHandler(_ *base.SentinelEntry, ctx *base.EntryContext) error {
+	releaseReservations(ctx)
+	releasePools(ctx)
+	return nil
+}
+
+func checkPass(ctx *base.EntryContext) (bool, string, *Rule, uint32) {
 	statNode := ctx.StatNode
 	batchCount := ctx.Input.BatchCount
 	curCount := uint32(0)
@@ -51,10 +87,22 @@ func checkPass(ctx *base.EntryContext) (bool, *Rule, uint32) {
 				curCount = 0
 				logging.Error(errors.New("negative concurrency"), "Negative concurrency in isolation.checkPass()", "rule", rule)
 			}
+			if rule.MaxQueueingCount > 0 {
+				if b := getBulkhead(rule); b != nil {
+					result, reserved := b.acquire(ctx)
+					if reserved {
+						addReservation(ctx, b)
+					}
+					if result != bulkheadPassed {
+						return false, "concurrency exceeds threshold, bulkhead " + result.String(), rule, currentConcurrencyOf(statNode)
+					}
+					continue
+				}
+			}
 			if curCount+batchCount > threshold {
-				return false, rule, curCount
+				return false, "concurrency exceeds threshold", rule, curCount
 			}
 		}
 	}
-	return true, nil, curCount
+	return true, "", nil, curCount
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/alibaba/sentinel-golang/core/base"
 	"github.com/alibaba/sentinel-golang/core/config"
 	"github.com/alibaba/sentinel-golang/core/stat"
+	metric_exporter "github.com/alibaba/sentinel-golang/exporter/metric"
 	"github.com/alibaba/sentinel-golang/logging"
 	"github.com/alibaba/sentinel-golang/util"
 )
@@ -24,8 +25,17 @@ var (
 	stopChan            = make(chan struct{})
 	metricWriter  MetricLogWriter
 	initOnce      sync.Once
+
+	rtQuantileGauge = metric_exporter.NewGauge(
+		"resource_rt_ms",
+		"Response time percentiles and max of the resource within the latest second in milliseconds",
+		[]string{"resource", "quantile"})
 )
 
+func init() {
+	metric_exporter.Register(rtQuantileGauge)
+}
+
 func InitTask() (err error) {
 	initOnce.Do(func() {
 		flushInterval := config.MetricLogFlushIntervalSec()
@@ -106,9 +116,13 @@ func doAggregate() {
 }
 
 func aggregateIntoMap(mm metricTimeMap, metrics map[uint64]*base.MetricItem, node *stat.ResourceNode) {
+	var latest *base.MetricItem
 	for t, item := range metrics {
 		item.Resource = node.ResourceName()
 		item.Classification = int32(node.ResourceType())
+		if latest == nil || item.Timestamp > latest.Timestamp {
+			latest = item
+		}
 		items, exists := mm[t]
 		if exists {
 			mm[t] = append(items, item)
@@ -116,6 +130,16 @@ func aggregateIntoMap(mm metricTimeMap, metrics map[uint64]*base.MetricItem, nod
 			mm[t] = []*base.MetricItem{item}
 		}
 	}
+	if latest != nil && latest.CompleteQps > 0 {
+		exportRtQuantiles(latest)
+	}
+}
+
+func exportRtQuantiles(item *base.MetricItem) {
+	rtQuantileGauge.Set(float64(item.P50Rt), item.Resource, "0.5")
+	rtQuantileGauge.Set(float64(item.P90Rt), item.Resource, "0.9")
+	rtQuantileGauge.Set(float64(item.P99Rt), item.Resource, "0.99")
+	rtQuantileGauge.Set(float64(item.MaxRt), item.Resource, "max")
 }
 
 func isActiveMetricItem(item *base.MetricItem) bool {
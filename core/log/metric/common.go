@@ -39,6 +39,14 @@ type MetricSearcher interface {
 	FindFromTimeWithMaxLines(beginTimeMs uint64, maxLines uint32) ([]*base.MetricItem, error)
 }
 
+// MetricQuerier aggregates the metric items from the metric log file under the condition of the query.
+// It's implemented by DefaultMetricSearcher, so the searcher created by NewDefaultMetricSearcher could be
+// asserted to MetricQuerier.
+type MetricQuerier interface {
+	// Query aggregates the metric items by the resources, the group and the downsampling interval of the query.
+	Query(query *MetricQuery) ([]*MetricSeries, error)
+}
+
 // Generate the metric file name from the service name.
 func FormMetricFileName(serviceName string, withPid bool) string {
 	dot := "."
@@ -55,8 +63,9 @@ func FormMetricFileName(serviceName string, withPid bool) string {
 }
 
 // Generate the metric index filename from the metric log filename.
+// The index file of the compressed metric file is named after the metric file before compression.
 func formMetricIdxFileName(metricFilename string) string {
-	return metricFilename + MetricIdxSuffix
+	return trimCompressedSuffix(metricFilename) + MetricIdxSuffix
 }
 
 func filenameMatches(filename, baseFilename string) bool {
@@ -79,7 +88,8 @@ func listMetricFilesConditional(baseDir string, filePattern string, predicate fu
 			continue
 		}
 		name := f.Name()
-		if predicate(name, filePattern) && !strings.HasSuffix(name, MetricIdxSuffix) && !strings.HasSuffix(name, FileLockSuffix) {
+		if predicate(name, filePattern) && !strings.HasSuffix(name, MetricIdxSuffix) && !strings.HasSuffix(name, FileLockSuffix) &&
+			!strings.HasSuffix(name, compressingSuffix) {
 			// Put the absolute path into the slice.
 			arr = append(arr, filepath.Join(baseDir, name))
 		}
@@ -99,8 +109,9 @@ func listMetricFiles(baseDir, filePattern string) ([]string, error) {
 
 func filenameComparator(arr []string) func(i, j int) bool {
 	return func(i, j int) bool {
-		name1 := filepath.Base(arr[i])
-		name2 := filepath.Base(arr[j])
+		// The compressed file keeps the order of the original file.
+		name1 := filepath.Base(trimCompressedSuffix(arr[i]))
+		name2 := filepath.Base(trimCompressedSuffix(arr[j]))
 		a1 := strings.Split(name1, `.`)
 		a2 := strings.Split(name2, `.`)
 		dateStr1 := a1[2]
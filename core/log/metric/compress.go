@@ -0,0 +1,181 @@
+package metric
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+const (
+	// GzipSuffix represents the suffix of the gzip compressed metric file.
+	GzipSuffix = ".gz"
+	// ZstdSuffix represents the suffix of the zstd compressed metric file.
+	ZstdSuffix = ".zst"
+	// compressingSuffix represents the suffix of the metric file being compressed.
+	compressingSuffix = ".tmp"
+)
+
+// MetricLogCompressor compresses the rolled metric files, and decompresses them when searching.
+// The index file of the compressed metric file is kept as it is, and the offsets in the index file
+// are the offsets in the decompressed content.
+type MetricLogCompressor interface {
+	// Suffix is appended to the name of the compressed metric file, such as ".gz".
+	Suffix() string
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+type gzipCompressor struct{}
+
+func (c *gzipCompressor) Suffix() string {
+	return GzipSuffix
+}
+
+func (c *gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (c *gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type zstdCompressor struct{}
+
+func (c *zstdCompressor) Suffix() string {
+	return ZstdSuffix
+}
+
+func (c *zstdCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w)
+}
+
+func (c *zstdCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
+
+var (
+	compressors = map[string]MetricLogCompressor{
+		config.MetricLogCompressionGzip: &gzipCompressor{},
+		config.MetricLogCompressionZstd: &zstdCompressor{},
+	}
+	compressorsMux = new(sync.RWMutex)
+)
+
+// RegisterMetricLogCompressor registers the compressor of the given compression name.
+// The gzip and zstd compressors are built-in and could be set in the config. The other compressors
+// could be registered and used by the writer created with NewDefaultMetricLogWriterWithOptions:
+//
+//	metric.RegisterMetricLogCompressor("lz4", myLz4Compressor)
+//	w, err := metric.NewDefaultMetricLogWriterWithOptions(maxSize, maxFileAmount, appName, metric.MetricLogWriterOptions{Compression: "lz4"})
+func RegisterMetricLogCompressor(name string, c MetricLogCompressor) {
+	if c == nil {
+		return
+	}
+	compressorsMux.Lock()
+	defer compressorsMux.Unlock()
+	compressors[name] = c
+}
+
+func compressorOf(name string) MetricLogCompressor {
+	compressorsMux.RLock()
+	defer compressorsMux.RUnlock()
+	return compressors[name]
+}
+
+// compressorOfFile returns the compressor matching the suffix of the filename, nil if the file is not compressed.
+func compressorOfFile(filename string) MetricLogCompressor {
+	compressorsMux.RLock()
+	defer compressorsMux.RUnlock()
+	for _, c := range compressors {
+		if suffix := c.Suffix(); suffix != "" && strings.HasSuffix(filename, suffix) {
+			return c
+		}
+	}
+	return nil
+}
+
+// trimCompressedSuffix returns the name of the metric file before compression.
+func trimCompressedSuffix(filename string) string {
+	if c := compressorOfFile(filename); c != nil {
+		return strings.TrimSuffix(filename, c.Suffix())
+	}
+	return filename
+}
+
+// compressFile compresses the file to filename+suffix and removes the original file.
+// The content is written to a temporary file first, so that a broken compressed file won't be left if the process exits.
+func compressFile(filename string, c MetricLogCompressor) (err error) {
+	src, err := os.Open(filename)
+	if err != nil {
+		return errors.Wrap(err, "failed to open metric file: "+filename)
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	target := filename + c.Suffix()
+	tmp := target + compressingSuffix
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return errors.Wrap(err, "failed to create compressed metric file: "+tmp)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+	w, err := c.NewWriter(dst)
+	if err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if _, err = io.Copy(w, src); err != nil {
+		_ = w.Close()
+		_ = dst.Close()
+		return err
+	}
+	if err = w.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, target); err != nil {
+		return err
+	}
+	// Keep the modification time, which is used by the age-based retention.
+	if chErr := os.Chtimes(target, info.ModTime(), info.ModTime()); chErr != nil {
+		logging.Warn("[MetricWriter] Failed to keep the modification time of the compressed metric file", "target", target, "err", chErr.Error())
+	}
+	if rmErr := os.Remove(filename); rmErr != nil {
+		logging.Warn("[MetricWriter] Failed to remove the metric file after compression", "filename", filename, "err", rmErr.Error())
+	}
+	logging.Info("[MetricWriter] Metric log file compressed", "filename", filename, "target", target)
+	return nil
+}
+
+// decompressedReadCloser closes both the decompressor and the underlying file.
+type decompressedReadCloser struct {
+	io.Reader
+	decompressor io.Closer
+	file         io.Closer
+}
+
+func (r *decompressedReadCloser) Close() error {
+	_ = r.decompressor.Close()
+	return r.file.Close()
+}
@@ -0,0 +1,294 @@
+package metric
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/pkg/errors"
+)
+
+// MetricGroupBy represents how the metric items of different resources are grouped into series.
+type MetricGroupBy int32
+
+const (
+	// GroupByResource makes a series for each resource.
+	GroupByResource MetricGroupBy = iota
+	// GroupByClassification makes a series for each resource classification (base.ResourceType).
+	GroupByClassification
+)
+
+func (g MetricGroupBy) String() string {
+	switch g {
+	case GroupByResource:
+		return "Resource"
+	case GroupByClassification:
+		return "Classification"
+	default:
+		return strconv.Itoa(int(g))
+	}
+}
+
+// MetricAggregation represents how the per-second values are downsampled into a bucket.
+type MetricAggregation int32
+
+const (
+	// AggregationSum sums the values of the seconds in the bucket.
+	AggregationSum MetricAggregation = iota
+	// AggregationAvg averages the values over the seconds of the bucket (rounded down), the seconds without metric items count as 0.
+	AggregationAvg
+	// AggregationMax takes the max value of the seconds in the bucket.
+	AggregationMax
+)
+
+func (a MetricAggregation) String() string {
+	switch a {
+	case AggregationSum:
+		return "Sum"
+	case AggregationAvg:
+		return "Avg"
+	case AggregationMax:
+		return "Max"
+	default:
+		return strconv.Itoa(int(a))
+	}
+}
+
+// MetricQuery represents the condition of querying the aggregated metrics from the metric logs.
+type MetricQuery struct {
+	// BeginTimeMs and EndTimeMs are the time range (both inclusive) of the query.
+	BeginTimeMs uint64
+	EndTimeMs   uint64
+	// Resources and ResourcePrefix select the resources, an item is selected if its resource is in Resources or
+	// has the ResourcePrefix. All resources are selected if both are empty.
+	Resources      []string
+	ResourcePrefix string
+	GroupBy        MetricGroupBy
+	// IntervalSec is the length of the downsampling bucket in seconds, 0 is regarded as 1 (no downsampling).
+	// The buckets are aligned to the multiples of IntervalSec since the epoch.
+	IntervalSec uint32
+	// Aggregation is applied to the QPS fields and the concurrency when downsampling.
+	// The AvgRt is always weighted by the complete QPS, and the RT percentiles and the MaxRt always take the max,
+	// since they can't be summed or averaged.
+	Aggregation MetricAggregation
+	// TopN keeps the N series with the most block QPS within the time range, 0 means all series.
+	TopN uint32
+}
+
+// MetricSeries is the downsampled metrics of a group.
+type MetricSeries struct {
+	// Group is the resource name for GroupByResource, or the classification for GroupByClassification.
+	Group          string
+	Classification int32
+	// Points are sorted by the time, whose Timestamp is the start of the bucket and Resource is the Group.
+	Points []*base.MetricItem
+}
+
+// seriesAccumulator accumulates the metric items of a group in seconds.
+type seriesAccumulator struct {
+	group          string
+	classification int32
+	// seconds holds the merged item of each second, whose AvgRt is the sum of RT.
+	seconds    map[uint64]*base.MetricItem
+	totalBlock uint64
+}
+
+type metricQueryAggregator struct {
+	query     *MetricQuery
+	resources map[string]struct{}
+	series    map[string]*seriesAccumulator
+}
+
+func newMetricQueryAggregator(q *MetricQuery) *metricQueryAggregator {
+	resources := make(map[string]struct{}, len(q.Resources))
+	for _, r := range q.Resources {
+		resources[r] = struct{}{}
+	}
+	return &metricQueryAggregator{
+		query:     q,
+		resources: resources,
+		series:    make(map[string]*seriesAccumulator),
+	}
+}
+
+func (a *metricQueryAggregator) selects(resource string) bool {
+	if len(a.resources) == 0 && a.query.ResourcePrefix == "" {
+		return true
+	}
+	if _, ok := a.resources[resource]; ok {
+		return true
+	}
+	return a.query.ResourcePrefix != "" && strings.HasPrefix(resource, a.query.ResourcePrefix)
+}
+
+// add merges the item into the second of its group, the resources of the same group within a second are summed up.
+func (a *metricQueryAggregator) add(item *base.MetricItem) bool {
+	if !a.selects(item.Resource) {
+		return true
+	}
+	group := item.Resource
+	if a.query.GroupBy == GroupByClassification {
+		group = strconv.Itoa(int(item.Classification))
+	}
+	acc, ok := a.series[group]
+	if !ok {
+		acc = &seriesAccumulator{
+			group:          group,
+			classification: item.Classification,
+			seconds:        make(map[uint64]*base.MetricItem),
+		}
+		a.series[group] = acc
+	}
+	sec := item.Timestamp / 1000
+	merged, ok := acc.seconds[sec]
+	if !ok {
+		merged = &base.MetricItem{Resource: group, Classification: item.Classification, Timestamp: sec * 1000}
+		acc.seconds[sec] = merged
+	}
+	merged.PassQps += item.PassQps
+	merged.BlockQps += item.BlockQps
+	merged.CompleteQps += item.CompleteQps
+	merged.ErrorQps += item.ErrorQps
+	merged.OccupiedPassQps += item.OccupiedPassQps
+	merged.Concurrency += item.Concurrency
+	merged.AvgRt += item.AvgRt * item.CompleteQps
+	merged.P50Rt = maxUint64(merged.P50Rt, item.P50Rt)
+	merged.P90Rt = maxUint64(merged.P90Rt, item.P90Rt)
+	merged.P99Rt = maxUint64(merged.P99Rt, item.P99Rt)
+	merged.MaxRt = maxUint64(merged.MaxRt, item.MaxRt)
+	acc.totalBlock += item.BlockQps
+	return true
+}
+
+func (a *metricQueryAggregator) result() []*MetricSeries {
+	accs := make([]*seriesAccumulator, 0, len(a.series))
+	for _, acc := range a.series {
+		accs = append(accs, acc)
+	}
+	if a.query.TopN > 0 {
+		sort.Slice(accs, func(i, j int) bool {
+			if accs[i].totalBlock != accs[j].totalBlock {
+				return accs[i].totalBlock > accs[j].totalBlock
+			}
+			return accs[i].group < accs[j].group
+		})
+		if len(accs) > int(a.query.TopN) {
+			accs = accs[:a.query.TopN]
+		}
+	} else {
+		sort.Slice(accs, func(i, j int) bool {
+			return accs[i].group < accs[j].group
+		})
+	}
+	ret := make([]*MetricSeries, 0, len(accs))
+	for _, acc := range accs {
+		ret = append(ret, &MetricSeries{
+			Group:          acc.group,
+			Classification: acc.classification,
+			Points:         a.downsample(acc),
+		})
+	}
+	return ret
+}
+
+type downsampleBucket struct {
+	point    *base.MetricItem
+	rtSum    uint64
+	complete uint64
+}
+
+func (a *metricQueryAggregator) downsample(acc *seriesAccumulator) []*base.MetricItem {
+	interval := uint64(a.query.IntervalSec)
+	if interval == 0 {
+		interval = 1
+	}
+	buckets := make(map[uint64]*downsampleBucket)
+	for sec, item := range acc.seconds {
+		start := sec / interval * interval
+		b, ok := buckets[start]
+		if !ok {
+			b = &downsampleBucket{point: &base.MetricItem{Resource: acc.group, Classification: acc.classification, Timestamp: start * 1000}}
+			buckets[start] = b
+		}
+		p := b.point
+		if a.query.Aggregation == AggregationMax {
+			p.PassQps = maxUint64(p.PassQps, item.PassQps)
+			p.BlockQps = maxUint64(p.BlockQps, item.BlockQps)
+			p.CompleteQps = maxUint64(p.CompleteQps, item.CompleteQps)
+			p.ErrorQps = maxUint64(p.ErrorQps, item.ErrorQps)
+			p.OccupiedPassQps = maxUint64(p.OccupiedPassQps, item.OccupiedPassQps)
+			if item.Concurrency > p.Concurrency {
+				p.Concurrency = item.Concurrency
+			}
+		} else {
+			p.PassQps += item.PassQps
+			p.BlockQps += item.BlockQps
+			p.CompleteQps += item.CompleteQps
+			p.ErrorQps += item.ErrorQps
+			p.OccupiedPassQps += item.OccupiedPassQps
+			p.Concurrency += item.Concurrency
+		}
+		p.P50Rt = maxUint64(p.P50Rt, item.P50Rt)
+		p.P90Rt = maxUint64(p.P90Rt, item.P90Rt)
+		p.P99Rt = maxUint64(p.P99Rt, item.P99Rt)
+		p.MaxRt = maxUint64(p.MaxRt, item.MaxRt)
+		b.rtSum += item.AvgRt
+		b.complete += item.CompleteQps
+	}
+
+	beginSec := a.query.BeginTimeMs / 1000
+	endSec := a.query.EndTimeMs / 1000
+	points := make([]*base.MetricItem, 0, len(buckets))
+	for start, b := range buckets {
+		p := b.point
+		if b.complete > 0 {
+			p.AvgRt = b.rtSum / b.complete
+		}
+		if a.query.Aggregation == AggregationAvg {
+			// Only the seconds of the bucket within the time range are counted.
+			first, last := start, start+interval-1
+			if first < beginSec {
+				first = beginSec
+			}
+			if last > endSec {
+				last = endSec
+			}
+			seconds := last - first + 1
+			p.PassQps /= seconds
+			p.BlockQps /= seconds
+			p.CompleteQps /= seconds
+			p.ErrorQps /= seconds
+			p.OccupiedPassQps /= seconds
+			p.Concurrency /= uint32(seconds)
+		}
+		points = append(points, p)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp < points[j].Timestamp
+	})
+	return points
+}
+
+func maxUint64(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func checkMetricQuery(q *MetricQuery) error {
+	if q == nil {
+		return errors.New("nil metric query")
+	}
+	if q.BeginTimeMs > q.EndTimeMs {
+		return errors.Errorf("invalid time range of metric query: [%d, %d]", q.BeginTimeMs, q.EndTimeMs)
+	}
+	if q.GroupBy != GroupByResource && q.GroupBy != GroupByClassification {
+		return errors.Errorf("unknown group by of metric query: %s", q.GroupBy)
+	}
+	if q.Aggregation != AggregationSum && q.Aggregation != AggregationAvg && q.Aggregation != AggregationMax {
+		return errors.Errorf("unknown aggregation of metric query: %s", q.Aggregation)
+	}
+	return nil
+}
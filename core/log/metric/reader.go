@@ -3,7 +3,9 @@ package metric
 import (
 	"bufio"
 	"io"
+	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/alibaba/sentinel-golang/core/base"
 	"github.com/alibaba/sentinel-golang/logging"
@@ -18,6 +20,13 @@ type MetricLogReader interface {
 	ReadMetricsByEndTime(nameList []string, fileNo uint32, startOffset uint64, beginMs uint64, endMs uint64, resource string) ([]*base.MetricItem, error)
 }
 
+// MetricLogVisitor visits the metric items without keeping them in memory, which is implemented by the default reader.
+type MetricLogVisitor interface {
+	// VisitMetricsByEndTime visits all the metric items within [beginMs, endMs] without the limit of the amount,
+	// the visit returns false to stop reading.
+	VisitMetricsByEndTime(nameList []string, fileNo uint32, startOffset uint64, beginMs uint64, endMs uint64, visit func(*base.MetricItem) bool) error
+}
+
 // Not thread-safe itself, but guarded by the outside MetricSearcher.
 type defaultMetricLogReader struct {
 }
@@ -55,35 +64,36 @@ func (r *defaultMetricLogReader) ReadMetrics(nameList []string, fileNo uint32, s
 }
 
 func (r *defaultMetricLogReader) ReadMetricsByEndTime(nameList []string, fileNo uint32, startOffset uint64, beginMs uint64, endMs uint64, resource string) ([]*base.MetricItem, error) {
-	if len(nameList) == 0 {
-		return make([]*base.MetricItem, 0), nil
-	}
-	// startOffset: the offset of the first file to read
-	items, shouldContinue, err := r.readMetricsInOneFileByEndTime(nameList[fileNo], startOffset, beginMs, endMs, resource, 0)
+	items := make([]*base.MetricItem, 0, 1024)
+	err := r.VisitMetricsByEndTime(nameList, fileNo, startOffset, beginMs, endMs, func(item *base.MetricItem) bool {
+		// empty resource name indicates "fetch all"
+		if resource == "" || resource == item.Resource {
+			items = append(items, item)
+		}
+		// Max items limit to avoid infinite reading
+		return len(items) < maxItemAmount
+	})
 	if err != nil {
 		return nil, err
 	}
-	if !shouldContinue {
-		return items, nil
-	}
-	fileNo++
-	// Continue reading until the size or time does not satisfy the condition
-	for {
-		if int(fileNo) >= len(nameList) {
-			// No files to read.
-			break
-		}
-		arr, shouldContinue, err := r.readMetricsInOneFileByEndTime(nameList[fileNo], 0, beginMs, endMs, resource, uint32(len(items)))
+	return items, nil
+}
+
+func (r *defaultMetricLogReader) VisitMetricsByEndTime(nameList []string, fileNo uint32, startOffset uint64, beginMs uint64, endMs uint64, visit func(*base.MetricItem) bool) error {
+	// startOffset: the offset of the first file to read
+	offset := startOffset
+	// Continue reading until the time does not satisfy the condition
+	for ; int(fileNo) < len(nameList); fileNo++ {
+		shouldContinue, err := r.visitMetricsInOneFileByEndTime(nameList[fileNo], offset, beginMs, endMs, visit)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		items = append(items, arr...)
 		if !shouldContinue {
 			break
 		}
-		fileNo++
+		offset = 0
 	}
-	return items, nil
+	return nil
 }
 
 func (r *defaultMetricLogReader) readMetricsInOneFile(filename string, offset uint64, maxLines uint32, lastSec uint64, prevSize uint32) ([]*base.MetricItem, bool, error) {
@@ -104,7 +114,7 @@ func (r *defaultMetricLogReader) readMetricsInOneFile(filename string, offset ui
 			}
 			return nil, false, errors.Wrap(err, "error when reading lines from file")
 		}
-		item, err := base.MetricItemFromFatString(line)
+		item, err := parseMetricItem(line)
 		if err != nil {
 			logging.Error(err, "Failed to convert MetricItem to string in defaultMetricLogReader.readMetricsInOneFile()")
 			continue
@@ -119,47 +129,49 @@ func (r *defaultMetricLogReader) readMetricsInOneFile(filename string, offset ui
 	}
 }
 
-func (r *defaultMetricLogReader) readMetricsInOneFileByEndTime(filename string, offset uint64, beginMs uint64, endMs uint64, resource string, prevSize uint32) ([]*base.MetricItem, bool, error) {
+func (r *defaultMetricLogReader) visitMetricsInOneFileByEndTime(filename string, offset uint64, beginMs uint64, endMs uint64, visit func(*base.MetricItem) bool) (bool, error) {
 	beginSec := beginMs / 1000
 	endSec := endMs / 1000
 	file, err := openFileAndSeekTo(filename, offset)
 	if err != nil {
-		return nil, false, err
+		return false, err
 	}
 	defer file.Close()
 
 	bufReader := bufio.NewReaderSize(file, 8192)
-	items := make([]*base.MetricItem, 0, 1024)
 	for {
 		line, err := readLine(bufReader)
 		if err != nil {
 			if err == io.EOF {
-				return items, true, nil
+				return true, nil
 			}
-			return nil, false, errors.Wrap(err, "error when reading lines from file")
+			return false, errors.Wrap(err, "error when reading lines from file")
 		}
-		item, err := base.MetricItemFromFatString(line)
+		item, err := parseMetricItem(line)
 		if err != nil {
-			logging.Error(err, "Invalid line of metric file in defaultMetricLogReader.readMetricsInOneFileByEndTime()", "fileLine", line)
+			logging.Error(err, "Invalid line of metric file in defaultMetricLogReader.visitMetricsInOneFileByEndTime()", "fileLine", line)
 			continue
 		}
 		tsSec := item.Timestamp / 1000
 		// currentSecond should in [beginSec, endSec]
 		if tsSec < beginSec || tsSec > endSec {
-			return items, false, nil
-		}
-
-		// empty resource name indicates "fetch all"
-		if resource == "" || resource == item.Resource {
-			items = append(items, item)
+			return false, nil
 		}
-		// Max items limit to avoid infinite reading
-		if len(items)+int(prevSize) >= maxItemAmount {
-			return items, false, nil
+		if !visit(item) {
+			return false, nil
 		}
 	}
 }
 
+// parseMetricItem parses the line of both the fat format and the JSON-lines format,
+// so that the metric files written before and after switching the format can be read together.
+func parseMetricItem(line string) (*base.MetricItem, error) {
+	if strings.HasPrefix(strings.TrimSpace(line), "{") {
+		return base.MetricItemFromJsonString(line)
+	}
+	return base.MetricItemFromFatString(line)
+}
+
 func readLine(bufReader *bufio.Reader) (string, error) {
 	buf := make([]byte, 0, 64)
 	for {
@@ -182,12 +194,28 @@ func getLatestSecond(items []*base.MetricItem) uint64 {
 	return items[len(items)-1].Timestamp / 1000
 }
 
-func openFileAndSeekTo(filename string, offset uint64) (*os.File, error) {
-	file, err := os.Open(filename)
+// openFileAndSeekTo opens the file and sets the position to the offset.
+// For the compressed metric file, the offset is the offset in the decompressed content.
+func openFileAndSeekTo(filename string, offset uint64) (io.ReadCloser, error) {
+	file, filename, err := openMetricFile(filename)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to open file: "+filename)
 	}
 
+	if c := compressorOfFile(filename); c != nil {
+		dr, err := c.NewReader(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, errors.Wrap(err, "failed to decompress file: "+filename)
+		}
+		if _, err = io.CopyN(ioutil.Discard, dr, int64(offset)); err != nil {
+			_ = dr.Close()
+			_ = file.Close()
+			return nil, errors.Wrapf(err, "failed to skip to offset %d of the compressed file", offset)
+		}
+		return &decompressedReadCloser{Reader: dr, decompressor: dr, file: file}, nil
+	}
+
 	// Set position to the offset recorded in the idx file
 	_, err = file.Seek(int64(offset), io.SeekStart)
 	if err != nil {
@@ -197,6 +225,30 @@ func openFileAndSeekTo(filename string, offset uint64) (*os.File, error) {
 	return file, nil
 }
 
+// openMetricFile opens the metric file and returns the name of the opened file.
+// The rolled file may be compressed and removed after it's listed, so the compressed file is opened instead
+// if the file doesn't exist.
+func openMetricFile(filename string) (*os.File, string, error) {
+	file, err := os.Open(filename)
+	if err == nil || !os.IsNotExist(err) || compressorOfFile(filename) != nil {
+		return file, filename, err
+	}
+	compressorsMux.RLock()
+	suffixes := make([]string, 0, len(compressors))
+	for _, c := range compressors {
+		if suffix := c.Suffix(); suffix != "" {
+			suffixes = append(suffixes, suffix)
+		}
+	}
+	compressorsMux.RUnlock()
+	for _, suffix := range suffixes {
+		if f, e := os.Open(filename + suffix); e == nil {
+			return f, filename + suffix, nil
+		}
+	}
+	return nil, filename, err
+}
+
 func newDefaultMetricLogReader() MetricLogReader {
 	return &defaultMetricLogReader{}
 }
@@ -47,6 +47,40 @@ func (s *DefaultMetricSearcher) FindFromTimeWithMaxLines(beginTimeMs uint64, max
 	})
 }
 
+// Query reads the metric items under the condition of the query, and aggregates them into the series
+// without keeping the raw items, so that a long time range can be queried.
+func (s *DefaultMetricSearcher) Query(query *MetricQuery) ([]*MetricSeries, error) {
+	if err := checkMetricQuery(query); err != nil {
+		return nil, err
+	}
+	agg := newMetricQueryAggregator(query)
+	_, err := s.searchOffsetAndRead(query.BeginTimeMs, func(filenames []string, fileNo uint32, offset uint64) ([]*base.MetricItem, error) {
+		return nil, s.visitMetricsByEndTime(filenames, fileNo, offset, query.BeginTimeMs, query.EndTimeMs, agg.add)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return agg.result(), nil
+}
+
+// visitMetricsByEndTime visits the metric items by the reader, the items are read at once if the reader
+// doesn't implement MetricLogVisitor.
+func (s *DefaultMetricSearcher) visitMetricsByEndTime(nameList []string, fileNo uint32, startOffset uint64, beginMs uint64, endMs uint64, visit func(*base.MetricItem) bool) error {
+	if v, ok := s.reader.(MetricLogVisitor); ok {
+		return v.VisitMetricsByEndTime(nameList, fileNo, startOffset, beginMs, endMs, visit)
+	}
+	items, err := s.reader.ReadMetricsByEndTime(nameList, fileNo, startOffset, beginMs, endMs, "")
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if !visit(item) {
+			break
+		}
+	}
+	return nil
+}
+
 func (s *DefaultMetricSearcher) searchOffsetAndRead(beginTimeMs uint64, doRead func([]string, uint32, uint64) ([]*base.MetricItem, error)) ([]*base.MetricItem, error) {
 	filenames, err := listMetricFiles(s.baseDir, s.baseFilename)
 	if err != nil {
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/alibaba/sentinel-golang/core/base"
 	"github.com/alibaba/sentinel-golang/core/config"
@@ -23,6 +24,15 @@ type DefaultMetricLogWriter struct {
 
 	maxSingleSize uint64
 	maxFileAmount uint32
+	format        string
+
+	// compressor compresses the rolled files, nil indicates no compression.
+	compressor MetricLogCompressor
+	// compressCh notifies the background worker to compress the rolled files and remove the deprecated files,
+	// it's nil if there is no compressor or the writer is closed.
+	compressCh     chan struct{}
+	maxAgeMs       uint64
+	totalSizeLimit uint64
 
 	timezoneOffsetSec int64
 	latestOpSec       int64
@@ -92,6 +102,11 @@ func (d *DefaultMetricLogWriter) Close() error {
 	d.mux.Lock()
 	defer d.mux.Unlock()
 
+	if d.compressCh != nil {
+		close(d.compressCh)
+		d.compressCh = nil
+	}
+
 	if d.curMetricIdxFile != nil {
 		d.curMetricIdxFile.Close()
 	}
@@ -103,7 +118,7 @@ func (d *DefaultMetricLogWriter) Close() error {
 
 func (d *DefaultMetricLogWriter) writeItemsAndFlush(items []*base.MetricItem) error {
 	for _, item := range items {
-		s, err := item.ToFatString()
+		s, err := formatMetricItem(item, d.format)
 		if err != nil {
 			logging.Warn("[writeItemsAndFlush] Failed to convert MetricItem to string", "resourceName", item.Resource, "err", err.Error())
 			continue
@@ -119,6 +134,13 @@ func (d *DefaultMetricLogWriter) writeItemsAndFlush(items []*base.MetricItem) er
 	return d.metricOut.Flush()
 }
 
+func formatMetricItem(item *base.MetricItem, format string) (string, error) {
+	if format == config.MetricLogFormatJson {
+		return item.ToJsonString()
+	}
+	return item.ToFatString()
+}
+
 func (d *DefaultMetricLogWriter) rollFileIfSizeExceeded(time uint64) error {
 	if d.curMetricFile == nil {
 		return nil
@@ -159,13 +181,40 @@ func (d *DefaultMetricLogWriter) writeIndex(time, offset int64) error {
 	return out.Flush()
 }
 
+// removeDeprecatedFiles removes the oldest files exceeding the maxFileAmount, the files older than the maxAgeMs,
+// and the oldest files until the total size of the files (with the room of the current file) is within the totalSizeLimit.
+// The current file is never removed.
 func (d *DefaultMetricLogWriter) removeDeprecatedFiles() error {
-	files, err := listMetricFiles(d.baseDir, d.baseFilename)
+	files, err := d.listRolledFiles()
 	if err != nil || len(files) == 0 {
 		return err
 	}
+	// The files are sorted from the oldest to the newest, so it's only needed to count the oldest files to remove.
 	amountToRemove := len(files) - int(d.maxFileAmount) + 1
-	for i := 0; i < amountToRemove; i++ {
+	if d.maxAgeMs > 0 {
+		deadline := int64(util.CurrentTimeMillis()) - int64(d.maxAgeMs)
+		for i, f := range files {
+			info, statErr := os.Stat(f)
+			if statErr == nil && info.ModTime().UnixNano()/int64(time.Millisecond) < deadline && i+1 > amountToRemove {
+				amountToRemove = i + 1
+			}
+		}
+	}
+	if d.totalSizeLimit > 0 {
+		sizes := make([]uint64, len(files))
+		total := d.maxSingleSize
+		for i, f := range files {
+			sizes[i] = fileSize(f) + fileSize(formMetricIdxFileName(f))
+			total += sizes[i]
+		}
+		for i := 0; i < len(files) && total > d.totalSizeLimit; i++ {
+			total -= sizes[i]
+			if i+1 > amountToRemove {
+				amountToRemove = i + 1
+			}
+		}
+	}
+	for i := 0; i < amountToRemove && i < len(files); i++ {
 		filename := files[i]
 		idxFilename := formMetricIdxFileName(filename)
 		err = os.Remove(filename)
@@ -185,6 +234,66 @@ func (d *DefaultMetricLogWriter) removeDeprecatedFiles() error {
 	return err
 }
 
+// compressLoop compresses the rolled files and then removes the deprecated files in the background
+// each time the file is rolled, so that the writing isn't blocked by the compression.
+func (d *DefaultMetricLogWriter) compressLoop(compressCh <-chan struct{}) {
+	for range compressCh {
+		d.compressRolledFiles()
+		d.mux.Lock()
+		err := d.removeDeprecatedFiles()
+		d.mux.Unlock()
+		if err != nil {
+			logging.Error(err, "Failed to remove deprecated metric log files in DefaultMetricLogWriter.compressLoop()")
+		}
+	}
+}
+
+// compressRolledFiles compresses all the rolled files which are not compressed yet,
+// including the files left by the previous process.
+func (d *DefaultMetricLogWriter) compressRolledFiles() {
+	if d.compressor == nil {
+		return
+	}
+	d.mux.RLock()
+	files, err := d.listRolledFiles()
+	d.mux.RUnlock()
+	if err != nil {
+		logging.Error(err, "Failed to list metric log files in DefaultMetricLogWriter.compressRolledFiles()")
+		return
+	}
+	for _, f := range files {
+		if compressorOfFile(f) != nil {
+			continue
+		}
+		if err := compressFile(f, d.compressor); err != nil {
+			logging.Error(err, "Failed to compress metric log file in DefaultMetricLogWriter.compressRolledFiles()", "filename", f)
+		}
+	}
+}
+
+// listRolledFiles lists the metric files except the current one, from the oldest to the newest.
+func (d *DefaultMetricLogWriter) listRolledFiles() ([]string, error) {
+	files, err := listMetricFiles(d.baseDir, d.baseFilename)
+	if err != nil || d.curMetricFile == nil {
+		return files, err
+	}
+	ret := make([]string, 0, len(files))
+	for _, f := range files {
+		if f != d.curMetricFile.Name() {
+			ret = append(ret, f)
+		}
+	}
+	return ret, nil
+}
+
+func fileSize(filename string) uint64 {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return 0
+	}
+	return uint64(info.Size())
+}
+
 func (d *DefaultMetricLogWriter) nextFileNameOfTime(time uint64) (string, error) {
 	dateStr := util.FormatDate(time)
 	filePattern := d.baseFilename + "." + dateStr
@@ -197,7 +306,7 @@ func (d *DefaultMetricLogWriter) nextFileNameOfTime(time uint64) (string, error)
 	if len(list) == 0 {
 		return filepath.Join(d.baseDir, filePattern), nil
 	}
-	last := list[len(list)-1]
+	last := trimCompressedSuffix(list[len(list)-1])
 	var n uint32 = 0
 	items := strings.Split(last, ".")
 	if len(items) > 0 {
@@ -210,18 +319,13 @@ func (d *DefaultMetricLogWriter) nextFileNameOfTime(time uint64) (string, error)
 }
 
 func (d *DefaultMetricLogWriter) closeCurAndNewFile(filename string) error {
-	err := d.removeDeprecatedFiles()
-	if err != nil {
-		return err
-	}
-
 	if d.curMetricFile != nil {
-		if err = d.curMetricFile.Close(); err != nil {
+		if err := d.curMetricFile.Close(); err != nil {
 			logging.Error(err, "Failed to close metric log file in DefaultMetricLogWriter.closeCurAndNewFile()", "curMetricFile", d.curMetricFile.Name())
 		}
 	}
 	if d.curMetricIdxFile != nil {
-		if err = d.curMetricIdxFile.Close(); err != nil {
+		if err := d.curMetricIdxFile.Close(); err != nil {
 			logging.Error(err, "Failed to close metric index file in DefaultMetricLogWriter.closeCurAndNewFile()", "curMetricIdxFile", d.curMetricIdxFile.Name())
 		}
 	}
@@ -245,7 +349,17 @@ func (d *DefaultMetricLogWriter) closeCurAndNewFile(filename string) error {
 	d.curMetricIdxFile = mif
 	d.idxOut = bufio.NewWriter(mif)
 
-	return nil
+	// The rolled files are compressed and removed after the new file is ready,
+	// so that the writer is always available even if the retention fails.
+	if d.compressCh != nil {
+		// The compression is in progress if the channel is full, which will handle the files rolled just now.
+		select {
+		case d.compressCh <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+	return d.removeDeprecatedFiles()
 }
 
 func (d *DefaultMetricLogWriter) initialize() error {
@@ -276,9 +390,56 @@ func NewDefaultMetricLogWriter(maxSize uint64, maxFileAmount uint32) (MetricLogW
 }
 
 func NewDefaultMetricLogWriterOfApp(maxSize uint64, maxFileAmount uint32, appName string) (MetricLogWriter, error) {
+	return NewDefaultMetricLogWriterWithOptions(maxSize, maxFileAmount, appName, metricLogWriterOptionsFromConfig())
+}
+
+// NewDefaultMetricLogWriterWithFormat creates the writer of the given format (config.MetricLogFormatFat or config.MetricLogFormatJson).
+// The files of both formats can be read by the DefaultMetricSearcher.
+func NewDefaultMetricLogWriterWithFormat(maxSize uint64, maxFileAmount uint32, appName string, format string) (MetricLogWriter, error) {
+	opts := metricLogWriterOptionsFromConfig()
+	opts.Format = format
+	return NewDefaultMetricLogWriterWithOptions(maxSize, maxFileAmount, appName, opts)
+}
+
+// MetricLogWriterOptions represents the format, the compression and the retention policies of the DefaultMetricLogWriter.
+type MetricLogWriterOptions struct {
+	// Format is config.MetricLogFormatFat (default) or config.MetricLogFormatJson.
+	Format string
+	// Compression is the name of the registered MetricLogCompressor, empty or config.MetricLogCompressionNone means no compression.
+	Compression string
+	// MaxAgeDays is the max days to keep the metric files, 0 means no age-based retention.
+	MaxAgeDays uint32
+	// TotalSizeLimit is the max total bytes of the metric files and the index files, 0 means no limit.
+	TotalSizeLimit uint64
+}
+
+func metricLogWriterOptionsFromConfig() MetricLogWriterOptions {
+	return MetricLogWriterOptions{
+		Format:         config.MetricLogFormat(),
+		Compression:    config.MetricLogCompression(),
+		MaxAgeDays:     config.MetricLogMaxAgeDays(),
+		TotalSizeLimit: config.MetricLogTotalSizeLimit(),
+	}
+}
+
+func NewDefaultMetricLogWriterWithOptions(maxSize uint64, maxFileAmount uint32, appName string, opts MetricLogWriterOptions) (MetricLogWriter, error) {
 	if maxSize == 0 || maxFileAmount == 0 {
 		return nil, errors.New("invalid maxSize or maxFileAmount")
 	}
+	format := opts.Format
+	if format == "" {
+		format = config.MetricLogFormatFat
+	}
+	if format != config.MetricLogFormatFat && format != config.MetricLogFormatJson {
+		return nil, errors.Errorf("unknown metric log format: %s", format)
+	}
+	var compressor MetricLogCompressor
+	if opts.Compression != "" && opts.Compression != config.MetricLogCompressionNone {
+		compressor = compressorOf(opts.Compression)
+		if compressor == nil {
+			return nil, errors.Errorf("metric log compressor of %s is not registered", opts.Compression)
+		}
+	}
 	_, offset := util.Now().Zone()
 
 	logDir := config.LogBaseDir()
@@ -291,12 +452,23 @@ func NewDefaultMetricLogWriterOfApp(maxSize uint64, maxFileAmount uint32, appNam
 	writer := &DefaultMetricLogWriter{
 		maxSingleSize:     maxSize,
 		maxFileAmount:     maxFileAmount,
+		format:            format,
+		compressor:        compressor,
+		maxAgeMs:          uint64(opts.MaxAgeDays) * 24 * 3600 * 1000,
+		totalSizeLimit:    opts.TotalSizeLimit,
 		timezoneOffsetSec: int64(offset),
 		latestOpSec:       0,
 		baseDir:           baseDir,
 		baseFilename:      baseFilename,
 		mux:               new(sync.RWMutex),
 	}
+	if compressor != nil {
+		writer.compressCh = make(chan struct{}, 1)
+		compressCh := writer.compressCh
+		go util.RunWithRecover(func() {
+			writer.compressLoop(compressCh)
+		})
+	}
 	err := writer.initialize()
 	return writer, err
 }
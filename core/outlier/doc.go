@@ -0,0 +1,3 @@
+// Package outlier provides implementation of per-instance outlier detection,
+// which ejects the unhealthy instances (target addresses) of a resource from client-side load balancing.
+package outlier
@@ -0,0 +1,307 @@
+package outlier
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	sbase "github.com/alibaba/sentinel-golang/core/stat/base"
+	metric_exporter "github.com/alibaba/sentinel-golang/exporter/metric"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+var (
+	ejectionCounter = metric_exporter.NewCounter(
+		"outlier_ejection_total",
+		"Outlier detection total instance ejection count",
+		[]string{"resource", "address"})
+)
+
+func init() {
+	metric_exporter.Register(ejectionCounter)
+}
+
+// OnRequestComplete records the completed request to the given instance (target address) of the resource,
+// and ejects the instance if it is regarded as outlier.
+func OnRequestComplete(resource, address string, rt uint64, err error) {
+	d := getDetector(resource)
+	if d == nil {
+		return
+	}
+	d.onRequestComplete(address, rt, err)
+}
+
+// IsEjected checks whether the given instance (target address) of the resource is ejected currently.
+func IsEjected(resource, address string) bool {
+	d := getDetector(resource)
+	if d == nil {
+		return false
+	}
+	return d.isEjected(address, util.CurrentTimeMillis())
+}
+
+// GetEjectedInstances returns the addresses of the ejected instances of the resource.
+func GetEjectedInstances(resource string) []string {
+	d := getDetector(resource)
+	if d == nil {
+		return nil
+	}
+	return d.ejectedInstances(util.CurrentTimeMillis())
+}
+
+// RemoveInstance removes the statistic of the given instance (target address) of the resource,
+// it should be invoked when the instance is removed from the load balancer.
+func RemoveInstance(resource, address string) {
+	d := getDetector(resource)
+	if d == nil {
+		return
+	}
+	d.removeInstance(address)
+}
+
+// detector tracks all the instances of a resource.
+type detector struct {
+	rule      *Rule
+	mux       sync.RWMutex
+	instances map[string]*instance
+}
+
+type instance struct {
+	address string
+	stat    *instanceLeapArray
+	// ejectedUntilMs is the timestamp (in milliseconds) when the ejection ends.
+	ejectedUntilMs uint64
+	// ejectionTimes is the count of consecutive ejections, which is reset once the instance is regarded as healthy.
+	ejectionTimes uint32
+}
+
+func newDetector(r *Rule) *detector {
+	return &detector{
+		rule:      r,
+		instances: make(map[string]*instance),
+	}
+}
+
+func (d *detector) getOrCreateInstance(address string) (*instance, error) {
+	d.mux.RLock()
+	inst, ok := d.instances[address]
+	d.mux.RUnlock()
+	if ok {
+		return inst, nil
+	}
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if inst, ok = d.instances[address]; ok {
+		return inst, nil
+	}
+	stat := &instanceLeapArray{}
+	leapArray, err := sbase.NewLeapArray(getRuleStatSlidingWindowBucketCount(d.rule), d.rule.StatIntervalMs, stat)
+	if err != nil {
+		return nil, err
+	}
+	stat.data = leapArray
+	inst = &instance{
+		address: address,
+		stat:    stat,
+	}
+	d.instances[address] = inst
+	return inst, nil
+}
+
+func (d *detector) removeInstance(address string) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	delete(d.instances, address)
+}
+
+func (d *detector) isEjected(address string, now uint64) bool {
+	d.mux.RLock()
+	inst, ok := d.instances[address]
+	d.mux.RUnlock()
+	return ok && inst.isEjected(now)
+}
+
+func (d *detector) ejectedInstances(now uint64) []string {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+
+	ret := make([]string, 0)
+	for addr, inst := range d.instances {
+		if inst.isEjected(now) {
+			ret = append(ret, addr)
+		}
+	}
+	return ret
+}
+
+func (d *detector) onRequestComplete(address string, rt uint64, err error) {
+	inst, e := d.getOrCreateInstance(address)
+	if e != nil {
+		logging.Error(e, "Fail to create instance statistic in outlier.detector.onRequestComplete()", "rule", d.rule, "address", address)
+		return
+	}
+	errorClass := d.classifyError(err)
+	if errorClass == circuitbreaker.ErrorClassIgnored {
+		return
+	}
+	counter, e := inst.stat.currentCounter()
+	if e != nil {
+		logging.Error(e, "Fail to get current counter in outlier.detector.onRequestComplete()", "rule", d.rule, "address", address)
+		return
+	}
+	if errorClass == circuitbreaker.ErrorClassFailure {
+		atomic.AddUint64(&counter.errorCount, 1)
+	}
+	atomic.AddUint64(&counter.totalCount, 1)
+	atomic.AddUint64(&counter.rtSum, rt)
+
+	now := util.CurrentTimeMillis()
+	if inst.isEjected(now) {
+		return
+	}
+	errorCount, totalCount, rtSum := uint64(0), uint64(0), uint64(0)
+	for _, c := range inst.stat.allCounter() {
+		errorCount += atomic.LoadUint64(&c.errorCount)
+		totalCount += atomic.LoadUint64(&c.totalCount)
+		rtSum += atomic.LoadUint64(&c.rtSum)
+	}
+	if totalCount == 0 || totalCount < d.rule.MinRequestAmount {
+		return
+	}
+	errorRatio := float64(errorCount) / float64(totalCount)
+	avgRt := rtSum / totalCount
+	if (d.rule.MaxErrorRatio > 0.0 && errorRatio >= d.rule.MaxErrorRatio) || (d.rule.MaxAvgRtMs > 0 && avgRt > d.rule.MaxAvgRtMs) {
+		d.tryEject(inst, now, errorRatio, avgRt)
+		return
+	}
+	// the instance is healthy, so reset the ejection backoff
+	atomic.StoreUint32(&inst.ejectionTimes, 0)
+}
+
+// classifyError classifies the error of the completed request by the classifier of the rule.
+// The nil error always counts as success.
+func (d *detector) classifyError(err error) circuitbreaker.ErrorClass {
+	if err == nil {
+		return circuitbreaker.ErrorClassSuccess
+	}
+	if len(d.rule.ErrorClassifierName) == 0 {
+		return circuitbreaker.ContextCanceledClassifier(err)
+	}
+	classifier, ok := circuitbreaker.GetErrorClassifier(d.rule.ErrorClassifierName)
+	if !ok {
+		return circuitbreaker.ErrorClassFailure
+	}
+	return classifier(err)
+}
+
+// tryEject ejects the instance unless the fraction of the ejected instances exceeds MaxEjectionPercent.
+func (d *detector) tryEject(inst *instance, now uint64, errorRatio float64, avgRt uint64) {
+	d.mux.Lock()
+	if inst.isEjected(now) {
+		d.mux.Unlock()
+		return
+	}
+	ejected := 0
+	for _, i := range d.instances {
+		if i.isEjected(now) {
+			ejected++
+		}
+	}
+	if float64(ejected+1) > d.rule.MaxEjectionPercent*float64(len(d.instances)) {
+		d.mux.Unlock()
+		logging.Debug("[Outlier] Skip ejecting the outlier instance since the max ejection percent is reached", "resource", d.rule.Resource, "address", inst.address)
+		return
+	}
+	times := atomic.AddUint32(&inst.ejectionTimes, 1)
+	duration := uint64(d.rule.BaseEjectionTimeMs) * uint64(times)
+	if d.rule.MaxEjectionTimeMs > 0 && duration > uint64(d.rule.MaxEjectionTimeMs) {
+		duration = uint64(d.rule.MaxEjectionTimeMs)
+	}
+	atomic.StoreUint64(&inst.ejectedUntilMs, now+duration)
+	d.mux.Unlock()
+
+	// the instance will be evaluated with fresh statistic after ejection
+	inst.stat.reset()
+	ejectionCounter.Add(float64(1), d.rule.Resource, inst.address)
+	logging.Info("[Outlier] The outlier instance was ejected", "resource", d.rule.Resource, "address", inst.address,
+		"errorRatio", errorRatio, "avgRt", avgRt, "ejectionTimeMs", duration)
+}
+
+func (i *instance) isEjected(now uint64) bool {
+	return now < atomic.LoadUint64(&i.ejectedUntilMs)
+}
+
+type instanceCounter struct {
+	totalCount uint64
+	errorCount uint64
+	rtSum      uint64
+}
+
+func (c *instanceCounter) reset() {
+	atomic.StoreUint64(&c.totalCount, 0)
+	atomic.StoreUint64(&c.errorCount, 0)
+	atomic.StoreUint64(&c.rtSum, 0)
+}
+
+type instanceLeapArray struct {
+	data *sbase.LeapArray
+}
+
+func (s *instanceLeapArray) NewEmptyBucket() interface{} {
+	return &instanceCounter{}
+}
+
+func (s *instanceLeapArray) ResetBucketTo(bw *sbase.BucketWrap, startTime uint64) *sbase.BucketWrap {
+	atomic.StoreUint64(&bw.BucketStart, startTime)
+	bw.Value.Store(&instanceCounter{})
+	return bw
+}
+
+func (s *instanceLeapArray) currentCounter() (*instanceCounter, error) {
+	curBucket, err := s.data.CurrentBucket(s)
+	if err != nil {
+		return nil, err
+	}
+	if curBucket == nil {
+		return nil, errors.New("nil BucketWrap")
+	}
+	mb := curBucket.Value.Load()
+	if mb == nil {
+		return nil, errors.New("nil instanceCounter")
+	}
+	counter, ok := mb.(*instanceCounter)
+	if !ok {
+		return nil, errors.Errorf("bucket fail to do type assert, expect: *instanceCounter, in fact: %s", reflect.TypeOf(mb).Name())
+	}
+	return counter, nil
+}
+
+func (s *instanceLeapArray) allCounter() []*instanceCounter {
+	buckets := s.data.Values()
+	ret := make([]*instanceCounter, 0, len(buckets))
+	for _, b := range buckets {
+		mb := b.Value.Load()
+		if mb == nil {
+			logging.Error(errors.New("current bucket atomic Value is nil"), "Current bucket atomic Value is nil in instanceLeapArray.allCounter()")
+			continue
+		}
+		counter, ok := mb.(*instanceCounter)
+		if !ok {
+			logging.Error(errors.New("bucket data type error"), "Bucket data type error in instanceLeapArray.allCounter()", "expect type", "*instanceCounter", "actual type", reflect.TypeOf(mb).Name())
+			continue
+		}
+		ret = append(ret, counter)
+	}
+	return ret
+}
+
+func (s *instanceLeapArray) reset() {
+	for _, c := range s.allCounter() {
+		c.reset()
+	}
+}
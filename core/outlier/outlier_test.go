@@ -0,0 +1,202 @@
+package outlier
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRule(res string) *Rule {
+	return &Rule{
+		Resource:           res,
+		MaxErrorRatio:      0.5,
+		MinRequestAmount:   4,
+		StatIntervalMs:     10000,
+		BaseEjectionTimeMs: 1000,
+		MaxEjectionTimeMs:  2500,
+		MaxEjectionPercent: 1.0,
+	}
+}
+
+func loadTestRule(t *testing.T, r *Rule) {
+	_, err := LoadRules([]*Rule{r})
+	assert.Nil(t, err)
+}
+
+func TestEjectionAndRecovery(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+	defer ClearRules()
+
+	res := "outlier-eject"
+	loadTestRule(t, newTestRule(res))
+
+	// not ejected before the MinRequestAmount is reached
+	for i := 0; i < 3; i++ {
+		OnRequestComplete(res, "10.0.0.1:80", 1, errors.New("biz error"))
+	}
+	assert.False(t, IsEjected(res, "10.0.0.1:80"))
+
+	OnRequestComplete(res, "10.0.0.1:80", 1, errors.New("biz error"))
+	assert.True(t, IsEjected(res, "10.0.0.1:80"))
+	assert.Equal(t, []string{"10.0.0.1:80"}, GetEjectedInstances(res))
+
+	// the instance recovers once the ejection ends
+	util.Sleep(1000 * time.Millisecond)
+	assert.False(t, IsEjected(res, "10.0.0.1:80"))
+	assert.Empty(t, GetEjectedInstances(res))
+
+	// the statistic was reset on ejection, so the success requests keep the instance healthy
+	for i := 0; i < 4; i++ {
+		OnRequestComplete(res, "10.0.0.1:80", 1, nil)
+	}
+	assert.False(t, IsEjected(res, "10.0.0.1:80"))
+}
+
+func TestEjectionBackoff(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+	defer ClearRules()
+
+	res := "outlier-backoff"
+	loadTestRule(t, newTestRule(res))
+	d := getDetector(res)
+	assert.NotNil(t, d)
+
+	eject := func() uint64 {
+		for i := 0; i < 4; i++ {
+			OnRequestComplete(res, "10.0.0.1:80", 1, errors.New("biz error"))
+		}
+		inst, err := d.getOrCreateInstance("10.0.0.1:80")
+		assert.Nil(t, err)
+		return inst.ejectedUntilMs - util.CurrentTimeMillis()
+	}
+
+	assert.Equal(t, uint64(1000), eject())
+	util.Sleep(1000 * time.Millisecond)
+	assert.Equal(t, uint64(2000), eject())
+	util.Sleep(2000 * time.Millisecond)
+	// bounded by MaxEjectionTimeMs
+	assert.Equal(t, uint64(2500), eject())
+	util.Sleep(2500 * time.Millisecond)
+
+	// the backoff is reset once the instance is regarded as healthy
+	for i := 0; i < 4; i++ {
+		OnRequestComplete(res, "10.0.0.1:80", 1, nil)
+	}
+	assert.Equal(t, uint64(1000), eject())
+}
+
+func TestEjectionByAvgRt(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+	defer ClearRules()
+
+	res := "outlier-rt"
+	r := newTestRule(res)
+	r.MaxErrorRatio = 0
+	r.MaxAvgRtMs = 100
+	loadTestRule(t, r)
+
+	for i := 0; i < 4; i++ {
+		OnRequestComplete(res, "10.0.0.1:80", 100, nil)
+		OnRequestComplete(res, "10.0.0.2:80", 200, nil)
+	}
+	assert.False(t, IsEjected(res, "10.0.0.1:80"))
+	assert.True(t, IsEjected(res, "10.0.0.2:80"))
+}
+
+func TestMaxEjectionPercent(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+	defer ClearRules()
+
+	res := "outlier-max-percent"
+	r := newTestRule(res)
+	r.MaxEjectionPercent = 0.5
+	loadTestRule(t, r)
+
+	addrs := []string{"10.0.0.1:80", "10.0.0.2:80", "10.0.0.3:80", "10.0.0.4:80"}
+	for _, addr := range addrs {
+		OnRequestComplete(res, addr, 1, nil)
+	}
+	for _, addr := range addrs {
+		for i := 0; i < 8; i++ {
+			OnRequestComplete(res, addr, 1, errors.New("biz error"))
+		}
+	}
+	ejected := GetEjectedInstances(res)
+	sort.Strings(ejected)
+	assert.Equal(t, []string{"10.0.0.1:80", "10.0.0.2:80"}, ejected)
+
+	// the removed instance no longer counts
+	RemoveInstance(res, "10.0.0.1:80")
+	assert.False(t, IsEjected(res, "10.0.0.1:80"))
+	OnRequestComplete(res, "10.0.0.3:80", 1, errors.New("biz error"))
+	assert.False(t, IsEjected(res, "10.0.0.3:80"))
+
+	// only one of the three instances could be ejected after the ejection ends
+	util.Sleep(1000 * time.Millisecond)
+	OnRequestComplete(res, "10.0.0.3:80", 1, errors.New("biz error"))
+	OnRequestComplete(res, "10.0.0.4:80", 1, errors.New("biz error"))
+	assert.Equal(t, []string{"10.0.0.3:80"}, GetEjectedInstances(res))
+}
+
+func TestErrorClassification(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+	defer ClearRules()
+
+	t.Run("ContextCanceledIgnoredByDefault", func(t *testing.T) {
+		res := "outlier-canceled"
+		loadTestRule(t, newTestRule(res))
+		defer ClearRules()
+
+		for i := 0; i < 8; i++ {
+			OnRequestComplete(res, "10.0.0.1:80", 1, context.Canceled)
+		}
+		assert.False(t, IsEjected(res, "10.0.0.1:80"))
+		inst, err := getDetector(res).getOrCreateInstance("10.0.0.1:80")
+		assert.Nil(t, err)
+		for _, c := range inst.stat.allCounter() {
+			assert.Equal(t, uint64(0), c.totalCount)
+		}
+
+		for i := 0; i < 4; i++ {
+			OnRequestComplete(res, "10.0.0.1:80", 1, context.DeadlineExceeded)
+		}
+		assert.True(t, IsEjected(res, "10.0.0.1:80"))
+	})
+
+	t.Run("RegisteredClassifier", func(t *testing.T) {
+		errNotFound := errors.New("not found")
+		assert.Nil(t, circuitbreaker.RegisterErrorClassifier("outlierNotFound", func(err error) circuitbreaker.ErrorClass {
+			if errors.Is(err, errNotFound) {
+				return circuitbreaker.ErrorClassSuccess
+			}
+			return circuitbreaker.ErrorClassFailure
+		}))
+		defer circuitbreaker.RemoveErrorClassifier("outlierNotFound")
+
+		res := "outlier-classifier"
+		r := newTestRule(res)
+		r.ErrorClassifierName = "outlierNotFound"
+		loadTestRule(t, r)
+		defer ClearRules()
+
+		for i := 0; i < 8; i++ {
+			OnRequestComplete(res, "10.0.0.1:80", 1, errNotFound)
+		}
+		assert.False(t, IsEjected(res, "10.0.0.1:80"))
+		for i := 0; i < 8; i++ {
+			OnRequestComplete(res, "10.0.0.1:80", 1, context.Canceled)
+		}
+		assert.True(t, IsEjected(res, "10.0.0.1:80"))
+	})
+}
@@ -0,0 +1,62 @@
+package outlier
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Rule describes the outlier detection strategy of the instances (target addresses) under a resource.
+// An instance is regarded as outlier if its error ratio or average response time in the statistic window
+// exceeds the threshold, and then will be ejected for a backoff period.
+type Rule struct {
+	ID       string `json:"id,omitempty"`
+	Resource string `json:"resource"`
+	// MaxErrorRatio represents the max error ratio (valid range: [0.0, 1.0]) of an instance, 0 means disabled.
+	MaxErrorRatio float64 `json:"maxErrorRatio"`
+	// MaxAvgRtMs represents the max average response time (in milliseconds) of an instance, 0 means disabled.
+	MaxAvgRtMs uint64 `json:"maxAvgRtMs"`
+	// MinRequestAmount represents the min request amount of an instance in the statistic window to trigger ejection.
+	MinRequestAmount             uint64 `json:"minRequestAmount"`
+	StatIntervalMs               uint32 `json:"statIntervalMs"`
+	StatSlidingWindowBucketCount uint32 `json:"statSlidingWindowBucketCount"`
+	// BaseEjectionTimeMs represents the base ejection duration (in milliseconds),
+	// the real ejection duration is BaseEjectionTimeMs multiplied by the consecutive ejection times of the instance.
+	BaseEjectionTimeMs uint32 `json:"baseEjectionTimeMs"`
+	// MaxEjectionTimeMs represents the upper bound of the ejection duration (in milliseconds), 0 means no upper bound.
+	MaxEjectionTimeMs uint32 `json:"maxEjectionTimeMs"`
+	// MaxEjectionPercent represents the max fraction (valid range: (0.0, 1.0]) of the ejected instances of the resource.
+	MaxEjectionPercent float64 `json:"maxEjectionPercent"`
+	// ErrorClassifierName represents the name of the ErrorClassifier registered in circuitbreaker package,
+	// which classifies the errors of the completed requests. Empty means circuitbreaker.ContextCanceledClassifier,
+	// so that the requests canceled by the caller are not counted.
+	ErrorClassifierName string `json:"errorClassifierName,omitempty"`
+}
+
+func (r *Rule) String() string {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Sprintf("{Id=%s, Resource=%s, MaxErrorRatio=%f, MaxAvgRtMs=%d, MinRequestAmount=%d, StatIntervalMs=%d, BaseEjectionTimeMs=%d, MaxEjectionTimeMs=%d, MaxEjectionPercent=%f, ErrorClassifierName=%s}",
+			r.ID, r.Resource, r.MaxErrorRatio, r.MaxAvgRtMs, r.MinRequestAmount, r.StatIntervalMs, r.BaseEjectionTimeMs, r.MaxEjectionTimeMs, r.MaxEjectionPercent, r.ErrorClassifierName)
+	}
+	return string(b)
+}
+
+func (r *Rule) ResourceName() string {
+	return r.Resource
+}
+
+func (r *Rule) isEqualsTo(newRule *Rule) bool {
+	if newRule == nil {
+		return false
+	}
+	return *r == *newRule
+}
+
+func getRuleStatSlidingWindowBucketCount(r *Rule) uint32 {
+	interval := r.StatIntervalMs
+	bucketCount := r.StatSlidingWindowBucketCount
+	if bucketCount == 0 || interval%bucketCount != 0 {
+		bucketCount = 1
+	}
+	return bucketCount
+}
@@ -0,0 +1,147 @@
+package outlier
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+var (
+	detectors     = make(map[string]*detector)
+	rwMux         = &sync.RWMutex{}
+	currentRules  = make([]*Rule, 0)
+	updateRuleMux = new(sync.Mutex)
+)
+
+// LoadRules loads the given outlier detection rules to the rule manager, while all previous rules will be replaced.
+// Only the first valid rule of each resource takes effect.
+// the first returned value indicates whether do real load operation, if the rules is the same with previous rules, return false
+func LoadRules(rules []*Rule) (bool, error) {
+	updateRuleMux.Lock()
+	defer updateRuleMux.Unlock()
+	isEqual := reflect.DeepEqual(currentRules, rules)
+	if isEqual {
+		logging.Info("[Outlier] Load rules is the same with current rules, so ignore load operation.")
+		return false, nil
+	}
+
+	err := onRuleUpdate(rules)
+	return true, err
+}
+
+func onRuleUpdate(rules []*Rule) (err error) {
+	validRules := make(map[string]*Rule, len(rules))
+	for _, rule := range rules {
+		if err := IsValidRule(rule); err != nil {
+			logging.Warn("[Outlier onRuleUpdate] Ignoring invalid outlier detection rule", "rule", rule, "reason", err.Error())
+			continue
+		}
+		if _, exist := validRules[rule.Resource]; exist {
+			logging.Warn("[Outlier onRuleUpdate] Ignoring duplicate outlier detection rule of the resource", "rule", rule)
+			continue
+		}
+		validRules[rule.Resource] = rule
+	}
+
+	start := util.CurrentTimeNano()
+	rwMux.RLock()
+	oldDetectors := detectors
+	rwMux.RUnlock()
+
+	newDetectors := make(map[string]*detector, len(validRules))
+	for res, rule := range validRules {
+		// reuse the detector (and the statistic of instances) if the rule is not changed
+		if old, ok := oldDetectors[res]; ok && old.rule.isEqualsTo(rule) {
+			newDetectors[res] = old
+			continue
+		}
+		newDetectors[res] = newDetector(rule)
+	}
+
+	rwMux.Lock()
+	detectors = newDetectors
+	rwMux.Unlock()
+	currentRules = rules
+
+	logging.Debug("[Outlier onRuleUpdate] Time statistic(ns) for updating outlier detection rule", "timeCost", util.CurrentTimeNano()-start)
+	if len(validRules) == 0 {
+		logging.Info("[OutlierRuleManager] Outlier detection rules were cleared")
+	} else {
+		logging.Info("[OutlierRuleManager] Outlier detection rules were loaded", "rules", validRules)
+	}
+	return
+}
+
+// ClearRules clears all the rules in outlier module.
+func ClearRules() error {
+	_, err := LoadRules(nil)
+	return err
+}
+
+// GetRules returns all the rules based on copy.
+// It doesn't take effect for outlier module if user changes the rule.
+func GetRules() []Rule {
+	rwMux.RLock()
+	defer rwMux.RUnlock()
+
+	ret := make([]Rule, 0, len(detectors))
+	for _, d := range detectors {
+		ret = append(ret, *d.rule)
+	}
+	return ret
+}
+
+// GetRuleOfResource returns specific resource's rule based on copy.
+// It doesn't take effect for outlier module if user changes the rule.
+func GetRuleOfResource(res string) (Rule, bool) {
+	d := getDetector(res)
+	if d == nil {
+		return Rule{}, false
+	}
+	return *d.rule, true
+}
+
+func getDetector(res string) *detector {
+	rwMux.RLock()
+	defer rwMux.RUnlock()
+
+	return detectors[res]
+}
+
+// IsValidRule checks whether the given Rule is valid.
+func IsValidRule(r *Rule) error {
+	if r == nil {
+		return errors.New("nil outlier detection rule")
+	}
+	if len(r.Resource) == 0 {
+		return errors.New("empty resource of outlier detection rule")
+	}
+	if r.MaxErrorRatio < 0.0 || r.MaxErrorRatio > 1.0 {
+		return errors.New("invalid MaxErrorRatio (valid range: [0.0, 1.0])")
+	}
+	if r.MaxErrorRatio <= 0.0 && r.MaxAvgRtMs == 0 {
+		return errors.New("neither MaxErrorRatio nor MaxAvgRtMs is set")
+	}
+	if r.StatIntervalMs == 0 {
+		return errors.New("invalid StatIntervalMs")
+	}
+	if r.BaseEjectionTimeMs == 0 {
+		return errors.New("invalid BaseEjectionTimeMs")
+	}
+	if r.MaxEjectionTimeMs > 0 && r.MaxEjectionTimeMs < r.BaseEjectionTimeMs {
+		return errors.New("invalid MaxEjectionTimeMs, it must not be less than BaseEjectionTimeMs")
+	}
+	if r.MaxEjectionPercent <= 0.0 || r.MaxEjectionPercent > 1.0 {
+		return errors.New("invalid MaxEjectionPercent (valid range: (0.0, 1.0])")
+	}
+	if len(r.ErrorClassifierName) > 0 {
+		if _, ok := circuitbreaker.GetErrorClassifier(r.ErrorClassifierName); !ok {
+			return errors.Errorf("unregistered ErrorClassifierName: %s", r.ErrorClassifierName)
+		}
+	}
+	return nil
+}
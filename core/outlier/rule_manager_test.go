@@ -0,0 +1,67 @@
+package outlier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidRule(t *testing.T) {
+	assert.Nil(t, IsValidRule(newTestRule("abc")))
+	assert.NotNil(t, IsValidRule(nil))
+
+	invalid := []func(r *Rule){
+		func(r *Rule) { r.Resource = "" },
+		func(r *Rule) { r.MaxErrorRatio = 1.1 },
+		func(r *Rule) { r.MaxErrorRatio = 0 },
+		func(r *Rule) { r.StatIntervalMs = 0 },
+		func(r *Rule) { r.BaseEjectionTimeMs = 0 },
+		func(r *Rule) { r.MaxEjectionTimeMs = 500 },
+		func(r *Rule) { r.MaxEjectionPercent = 0 },
+		func(r *Rule) { r.ErrorClassifierName = "unregistered" },
+	}
+	for i, f := range invalid {
+		r := newTestRule("abc")
+		f(r)
+		assert.NotNil(t, IsValidRule(r), "case %d", i)
+	}
+
+	r := newTestRule("abc")
+	r.ErrorClassifierName = "contextCanceled"
+	assert.Nil(t, IsValidRule(r))
+}
+
+func TestLoadRules(t *testing.T) {
+	defer ClearRules()
+
+	r1 := newTestRule("abc")
+	r2 := newTestRule("abc")
+	r2.MaxErrorRatio = 0.8
+	invalid := newTestRule("def")
+	invalid.MaxEjectionPercent = 0
+	ok, err := LoadRules([]*Rule{r1, r2, invalid})
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Len(t, GetRules(), 1)
+	rule, exist := GetRuleOfResource("abc")
+	assert.True(t, exist)
+	assert.Equal(t, *r1, rule)
+	_, exist = GetRuleOfResource("def")
+	assert.False(t, exist)
+
+	// the detector is reused if the rule is not changed
+	d := getDetector("abc")
+	ok, err = LoadRules([]*Rule{newTestRule("abc")})
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.True(t, d == getDetector("abc"))
+
+	ok, err = LoadRules([]*Rule{r2})
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.False(t, d == getDetector("abc"))
+
+	assert.Nil(t, ClearRules())
+	assert.Len(t, GetRules(), 0)
+	assert.Nil(t, getDetector("abc"))
+}
@@ -0,0 +1,142 @@
+package base
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+const (
+	// histogramExactBound is the bound under which every value has its own bucket.
+	histogramExactBound = 16
+	// histogramSubBucketBits is the bit count of the sub buckets in each power-of-two range,
+	// which keeps the relative error of the recorded value within 1/(2^histogramSubBucketBits).
+	histogramSubBucketBits  = 3
+	histogramSubBucketCount = 1 << histogramSubBucketBits
+	// histogramMaxExponent is the max power-of-two range, the larger values are recorded in the last bucket.
+	histogramMaxExponent = 22
+	histogramMinExponent = 4
+
+	HistogramBucketCount = histogramExactBound + (histogramMaxExponent-histogramMinExponent+1)*histogramSubBucketCount
+)
+
+// Histogram is a compact HDR-style histogram for the response time (in milliseconds).
+// Values in [0, 16) are recorded exactly, and larger values are recorded in log-scale buckets,
+// each power-of-two range is divided into 8 linear sub buckets.
+// The exact max value is tracked as well, so that the estimated percentiles never exceed it.
+// All the operations of Histogram are thread-safe.
+type Histogram struct {
+	counts [HistogramBucketCount]uint64
+	max    uint64
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+func histogramBucketIndex(v uint64) int {
+	if v < histogramExactBound {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1
+	if exp > histogramMaxExponent {
+		return HistogramBucketCount - 1
+	}
+	sub := int(v>>uint(exp-histogramSubBucketBits)) & (histogramSubBucketCount - 1)
+	return histogramExactBound + (exp-histogramMinExponent)*histogramSubBucketCount + sub
+}
+
+// histogramBucketUpperBound returns the max value which could be recorded in the bucket of the given index.
+func histogramBucketUpperBound(idx int) uint64 {
+	if idx < histogramExactBound {
+		return uint64(idx)
+	}
+	exp := (idx-histogramExactBound)/histogramSubBucketCount + histogramMinExponent
+	sub := (idx - histogramExactBound) % histogramSubBucketCount
+	width := uint64(1) << uint(exp-histogramSubBucketBits)
+	return (uint64(1) << uint(exp)) + uint64(sub+1)*width - 1
+}
+
+// Record records the given value.
+func (h *Histogram) Record(v uint64) {
+	atomic.AddUint64(&h.counts[histogramBucketIndex(v)], 1)
+	h.updateMax(v)
+}
+
+func (h *Histogram) updateMax(v uint64) {
+	for {
+		max := atomic.LoadUint64(&h.max)
+		if v <= max || atomic.CompareAndSwapUint64(&h.max, max, v) {
+			return
+		}
+	}
+}
+
+// Merge adds all the recorded values of other into h.
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil {
+		return
+	}
+	for i := 0; i < HistogramBucketCount; i++ {
+		if c := atomic.LoadUint64(&other.counts[i]); c > 0 {
+			atomic.AddUint64(&h.counts[i], c)
+		}
+	}
+	h.updateMax(atomic.LoadUint64(&other.max))
+}
+
+// Reset clears all the recorded values.
+func (h *Histogram) Reset() {
+	for i := 0; i < HistogramBucketCount; i++ {
+		atomic.StoreUint64(&h.counts[i], 0)
+	}
+	atomic.StoreUint64(&h.max, 0)
+}
+
+// Count returns the count of recorded values.
+func (h *Histogram) Count() uint64 {
+	total := uint64(0)
+	for i := 0; i < HistogramBucketCount; i++ {
+		total += atomic.LoadUint64(&h.counts[i])
+	}
+	return total
+}
+
+// Percentile returns the estimated value at the given percentile (valid range: (0.0, 100.0]),
+// which is the upper bound of the bucket where the percentile locates, clamped to the max recorded value.
+// It returns 0 if there is no recorded value.
+func (h *Histogram) Percentile(p float64) uint64 {
+	var counts [HistogramBucketCount]uint64
+	total := uint64(0)
+	for i := 0; i < HistogramBucketCount; i++ {
+		counts[i] = atomic.LoadUint64(&h.counts[i])
+		total += counts[i]
+	}
+	if total == 0 {
+		return 0
+	}
+	if p > 100.0 {
+		p = 100.0
+	}
+	rank := uint64(math.Ceil(p / 100.0 * float64(total)))
+	if rank == 0 {
+		rank = 1
+	}
+	max := atomic.LoadUint64(&h.max)
+	seen := uint64(0)
+	for i := 0; i < HistogramBucketCount; i++ {
+		seen += counts[i]
+		if seen >= rank {
+			if bound := histogramBucketUpperBound(i); bound < max {
+				return bound
+			}
+			return max
+		}
+	}
+	return max
+}
+
+// Max returns the max recorded value.
+func (h *Histogram) Max() uint64 {
+	return atomic.LoadUint64(&h.max)
+}
@@ -2,6 +2,7 @@ package base
 
 import (
 	"sync/atomic"
+	"unsafe"
 
 	"github.com/alibaba/sentinel-golang/core/base"
 	"github.com/alibaba/sentinel-golang/logging"
@@ -12,8 +13,11 @@ import (
 // 注意MetricBucket的所有操作都要求是线程安全的.   // 滑动窗口中的每个桶
 type MetricBucket struct {
 	counter        [base.MetricEventTotal]int64 // 指标统计值, 数组
+	striped        []*StripedCounter            // 分段计数器, 不为nil时代替counter统计指标, 见 SetCounterType
 	minRt          int64                        // 最小的请求时间
+	maxRt          int64                        // 最大的请求时间
 	maxConcurrency int32                        // 最大并发量
+	rtHistogram    unsafe.Pointer               // 请求时间的直方图(*Histogram), 用于估算百分位, 首次记录请求时间时才分配
 }
 
 func NewMetricBucket() *MetricBucket {
@@ -21,6 +25,12 @@ func NewMetricBucket() *MetricBucket {
 		minRt:          base.DefaultStatisticMaxRt, // 最大的请求时间
 		maxConcurrency: 0,                          //
 	}
+	if stripes := atomic.LoadUint32(&stripedCounterStripes); stripes > 0 {
+		mb.striped = make([]*StripedCounter, base.MetricEventTotal)
+		for i := range mb.striped {
+			mb.striped[i] = NewStripedCounter(stripes)
+		}
+	}
 	return mb
 }
 
@@ -39,6 +49,10 @@ func (mb *MetricBucket) Add(event base.MetricEvent, count int64) {
 
 // 重中之重 ✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️✈️
 func (mb *MetricBucket) addCount(event base.MetricEvent, count int64) {
+	if mb.striped != nil {
+		mb.striped[event].Add(count)
+		return
+	}
 	atomic.AddInt64(&mb.counter[event], count)
 }
 
@@ -48,6 +62,9 @@ func (mb *MetricBucket) Get(event base.MetricEvent) int64 {
 		logging.Error(errors.Errorf("Unknown metric event: %v", event), "")
 		return 0
 	}
+	if mb.striped != nil {
+		return mb.striped[event].Sum()
+	}
 	return atomic.LoadInt64(&mb.counter[event])
 }
 
@@ -55,8 +72,15 @@ func (mb *MetricBucket) reset() {
 	for i := 0; i < int(base.MetricEventTotal); i++ {
 		atomic.StoreInt64(&mb.counter[i], 0)
 	}
+	for _, c := range mb.striped {
+		c.Reset()
+	}
 	atomic.StoreInt64(&mb.minRt, base.DefaultStatisticMaxRt)
+	atomic.StoreInt64(&mb.maxRt, 0)
 	atomic.StoreInt32(&mb.maxConcurrency, int32(0))
+	if h := mb.RtHistogram(); h != nil {
+		h.Reset()
+	}
 }
 
 func (mb *MetricBucket) AddRt(rt int64) {
@@ -65,12 +89,43 @@ func (mb *MetricBucket) AddRt(rt int64) {
 		// Might not be accurate here.
 		atomic.StoreInt64(&mb.minRt, rt)
 	}
+	for {
+		maxRt := atomic.LoadInt64(&mb.maxRt)
+		if rt <= maxRt || atomic.CompareAndSwapInt64(&mb.maxRt, maxRt, rt) {
+			break
+		}
+	}
+	if rt >= 0 {
+		mb.loadOrNewRtHistogram().Record(uint64(rt))
+	}
 }
 
 func (mb *MetricBucket) MinRt() int64 {
 	return atomic.LoadInt64(&mb.minRt)
 }
 
+func (mb *MetricBucket) MaxRt() int64 {
+	return atomic.LoadInt64(&mb.maxRt)
+}
+
+// RtHistogram returns the histogram of the response time recorded in the bucket,
+// it returns nil if no response time has ever been recorded in the bucket.
+func (mb *MetricBucket) RtHistogram() *Histogram {
+	return (*Histogram)(atomic.LoadPointer(&mb.rtHistogram))
+}
+
+// loadOrNewRtHistogram allocates the histogram lazily, so that the idle buckets don't pay for it.
+func (mb *MetricBucket) loadOrNewRtHistogram() *Histogram {
+	if h := mb.RtHistogram(); h != nil {
+		return h
+	}
+	h := NewHistogram()
+	if atomic.CompareAndSwapPointer(&mb.rtHistogram, nil, unsafe.Pointer(h)) {
+		return h
+	}
+	return mb.RtHistogram()
+}
+
 func (mb *MetricBucket) UpdateConcurrency(concurrency int32) {
 	cc := concurrency
 	if cc > atomic.LoadInt32(&mb.maxConcurrency) {
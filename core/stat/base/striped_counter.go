@@ -0,0 +1,142 @@
+package base
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxCounterStripes is the max amount of stripes of StripedCounter.
+	MaxCounterStripes = 256
+
+	cacheLineSize = 64
+)
+
+var (
+	// stripedCounterStripes is the amount of stripes of the StripedCounter in MetricBucket, 0 means atomic counter is used.
+	stripedCounterStripes uint32 = 0
+)
+
+// SetCounterType sets the counter type of the MetricBucket created afterwards.
+// The stripes only take effect for base.CounterTypeStriped, and the default value (GOMAXPROCS) is used if it's 0.
+func SetCounterType(counterType string, stripes uint32) error {
+	switch counterType {
+	case "", base.CounterTypeAtomic:
+		atomic.StoreUint32(&stripedCounterStripes, 0)
+	case base.CounterTypeStriped:
+		if stripes == 0 {
+			stripes = uint32(runtime.GOMAXPROCS(0))
+		}
+		if stripes > MaxCounterStripes {
+			stripes = MaxCounterStripes
+		}
+		atomic.StoreUint32(&stripedCounterStripes, ceilingPowerOfTwo(stripes))
+	default:
+		return errors.Errorf("unknown counter type: %s", counterType)
+	}
+	return nil
+}
+
+// CurrentCounterType returns the counter type of the MetricBucket and the amount of stripes.
+func CurrentCounterType() (string, uint32) {
+	stripes := atomic.LoadUint32(&stripedCounterStripes)
+	if stripes == 0 {
+		return base.CounterTypeAtomic, 0
+	}
+	return base.CounterTypeStriped, stripes
+}
+
+func ceilingPowerOfTwo(n uint32) uint32 {
+	p := uint32(1)
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// stripedCell occupies a whole cache line, so that the cells are not falsely shared.
+type stripedCell struct {
+	value int64
+	_     [cacheLineSize - 8]byte
+}
+
+// StripedCounter is a LongAdder-like counter, which spreads the concurrent additions into several cells
+// to reduce the contention on a single cache line. The value is the sum of all cells.
+// The write is cheaper than the atomic int64 only under the contention of many cores (a few more ns otherwise),
+// while the read is more expensive.
+type StripedCounter struct {
+	cells []stripedCell
+	mask  uintptr
+}
+
+// NewStripedCounter creates the StripedCounter, the stripes is rounded up to the power of two.
+func NewStripedCounter(stripes uint32) *StripedCounter {
+	if stripes == 0 {
+		stripes = 1
+	}
+	if stripes > MaxCounterStripes {
+		stripes = MaxCounterStripes
+	}
+	stripes = ceilingPowerOfTwo(stripes)
+	return &StripedCounter{
+		cells: make([]stripedCell, stripes),
+		mask:  uintptr(stripes - 1),
+	}
+}
+
+// stripeHint is the hint of the cell to add to, it's cached in stripeHintPool.
+// Since sync.Pool keeps the items per P, the hint tends to stay with the P (and the goroutines running on it),
+// and it moves to another cell on contention, as the probe of LongAdder.
+type stripeHint struct {
+	seed uint32
+}
+
+var (
+	// stripeHintSeed generates the initial seeds of the hints, which are spaced by the golden ratio.
+	stripeHintSeed uint32
+	stripeHintPool = sync.Pool{
+		New: func() interface{} {
+			return &stripeHint{seed: atomic.AddUint32(&stripeHintSeed, 0x9e3779b9) | 1}
+		},
+	}
+)
+
+// advance moves the hint to another cell by xorshift.
+func (h *stripeHint) advance() {
+	x := h.seed
+	x ^= x << 13
+	x ^= x >> 17
+	x ^= x << 5
+	h.seed = x
+}
+
+func (c *StripedCounter) Add(delta int64) {
+	h := stripeHintPool.Get().(*stripeHint)
+	cell := &c.cells[uintptr(h.seed)&c.mask]
+	v := atomic.LoadInt64(&cell.value)
+	if !atomic.CompareAndSwapInt64(&cell.value, v, v+delta) {
+		// The cell is contended, move to another cell for the later additions.
+		h.advance()
+		atomic.AddInt64(&c.cells[uintptr(h.seed)&c.mask].value, delta)
+	}
+	stripeHintPool.Put(h)
+}
+
+// Sum returns the sum of all cells, which is not an atomic snapshot under concurrent updates.
+func (c *StripedCounter) Sum() int64 {
+	sum := int64(0)
+	for i := range c.cells {
+		sum += atomic.LoadInt64(&c.cells[i].value)
+	}
+	return sum
+}
+
+func (c *StripedCounter) Reset() {
+	for i := range c.cells {
+		atomic.StoreInt64(&c.cells[i].value, 0)
+	}
+}
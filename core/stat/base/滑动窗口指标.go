@@ -175,6 +175,50 @@ func (m *SlidingWindowMetric) MaxConcurrency() int32 {
 	return maxConcurrency
 }
 
+func (m *SlidingWindowMetric) MaxRT() float64 {
+	now := util.CurrentTimeMillis()
+	satisfiedBuckets := m.getSatisfiedBuckets(now)
+	maxRt := int64(0)
+	for _, w := range satisfiedBuckets {
+		mb := w.Value.Load()
+		if mb == nil {
+			logging.Error(errors.New("nil BucketWrap"), "Current bucket value is nil in SlidingWindowMetric.MaxRT()")
+			continue
+		}
+		counter, ok := mb.(*MetricBucket)
+		if !ok {
+			logging.Error(errors.New("type assert failed"), "Fail to do type assert in SlidingWindowMetric.MaxRT()", "expectType", "*MetricBucket", "actualType", reflect.TypeOf(mb).Name())
+			continue
+		}
+		if v := counter.MaxRt(); v > maxRt {
+			maxRt = v
+		}
+	}
+	return float64(maxRt)
+}
+
+// RtPercentile merges the RT histograms of the buckets in the sliding window and estimates the percentile,
+// which never exceeds the max RT in the sliding window.
+func (m *SlidingWindowMetric) RtPercentile(percentile float64) float64 {
+	now := util.CurrentTimeMillis()
+	satisfiedBuckets := m.getSatisfiedBuckets(now)
+	h := NewHistogram()
+	for _, w := range satisfiedBuckets {
+		mb := w.Value.Load()
+		if mb == nil {
+			logging.Error(errors.New("nil BucketWrap"), "Current bucket value is nil in SlidingWindowMetric.RtPercentile()")
+			continue
+		}
+		counter, ok := mb.(*MetricBucket)
+		if !ok {
+			logging.Error(errors.New("type assert failed"), "Fail to do type assert in SlidingWindowMetric.RtPercentile()", "expectType", "*MetricBucket", "actualType", reflect.TypeOf(mb).Name())
+			continue
+		}
+		h.Merge(counter.RtHistogram())
+	}
+	return float64(h.Percentile(percentile))
+}
+
 func (m *SlidingWindowMetric) AvgRT() float64 {
 	return float64(m.GetSum(base.MetricEventRt)) / float64(m.GetSum(base.MetricEventComplete))
 }
@@ -210,6 +254,7 @@ func (m *SlidingWindowMetric) SecondMetricsOnCondition(predicate base.TimePredic
 func (m *SlidingWindowMetric) metricItemFromBuckets(ts uint64, ws []*BucketWrap) *base.MetricItem {
 	item := &base.MetricItem{Timestamp: ts}
 	var allRt int64 = 0
+	h := NewHistogram()
 	for _, w := range ws {
 		mi := w.Value.Load()
 		if mi == nil {
@@ -230,12 +275,17 @@ func (m *SlidingWindowMetric) metricItemFromBuckets(ts uint64, ws []*BucketWrap)
 			item.Concurrency = mc
 		}
 		allRt += mb.Get(base.MetricEventRt)
+		if maxRt := uint64(mb.MaxRt()); maxRt > item.MaxRt {
+			item.MaxRt = maxRt
+		}
+		h.Merge(mb.RtHistogram())
 	}
 	if item.CompleteQps > 0 {
 		item.AvgRt = uint64(allRt) / item.CompleteQps
 	} else {
 		item.AvgRt = uint64(allRt)
 	}
+	fillRtPercentiles(item, h)
 	return item
 }
 
@@ -263,5 +313,24 @@ func (m *SlidingWindowMetric) metricItemFromBucket(w *BucketWrap) *base.MetricIt
 	} else {
 		item.AvgRt = uint64(mb.Get(base.MetricEventRt))
 	}
+	item.MaxRt = uint64(mb.MaxRt())
+	fillRtPercentiles(item, mb.RtHistogram())
 	return item
 }
+
+// fillRtPercentiles fills the RT percentiles of the item, which are clamped to the max RT of the item.
+func fillRtPercentiles(item *base.MetricItem, h *Histogram) {
+	if h == nil {
+		return
+	}
+	item.P50Rt = clampRt(h.Percentile(50), item.MaxRt)
+	item.P90Rt = clampRt(h.Percentile(90), item.MaxRt)
+	item.P99Rt = clampRt(h.Percentile(99), item.MaxRt)
+}
+
+func clampRt(rt, maxRt uint64) uint64 {
+	if rt > maxRt {
+		return maxRt
+	}
+	return rt
+}
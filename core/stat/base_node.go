@@ -68,6 +68,14 @@ func (n *BaseStatNode) MinRT() float64 {
 	return float64(n.metric.MinRT())
 }
 
+func (n *BaseStatNode) MaxRT() float64 {
+	return n.metric.MaxRT()
+}
+
+func (n *BaseStatNode) RtPercentile(percentile float64) float64 {
+	return n.metric.RtPercentile(percentile)
+}
+
 func (n *BaseStatNode) MaxConcurrency() int32 {
 	return n.metric.MaxConcurrency()
 }
@@ -1,6 +1,8 @@
 package stat
 
 import (
+	"sync/atomic"
+
 	"github.com/alibaba/sentinel-golang/core/base"
 	"github.com/alibaba/sentinel-golang/core/config"
 )
@@ -9,6 +11,7 @@ type ResourceNode struct {
 	BaseStatNode
 	resourceName string
 	resourceType base.ResourceType
+	trafficMask  uint32 // 资源出现过的流量类型, 每个 base.TrafficType 占一位
 }
 
 // NewResourceNode 创建具有给定名称和分类的新资源节点
@@ -27,3 +30,35 @@ func (n *ResourceNode) ResourceType() base.ResourceType {
 func (n *ResourceNode) ResourceName() string {
 	return n.resourceName
 }
+
+// HasTrafficType 判断资源是否以给定的流量类型被访问过.
+func (n *ResourceNode) HasTrafficType(trafficType base.TrafficType) bool {
+	if trafficType < 0 || trafficType >= 32 {
+		return false
+	}
+	return atomic.LoadUint32(&n.trafficMask)&(1<<uint32(trafficType)) != 0
+}
+
+// TrafficTypes 返回资源被访问过的所有流量类型.
+func (n *ResourceNode) TrafficTypes() []base.TrafficType {
+	ret := make([]base.TrafficType, 0, 2)
+	for _, t := range []base.TrafficType{base.Inbound, base.Outbound} {
+		if n.HasTrafficType(t) {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+func (n *ResourceNode) markTrafficType(trafficType base.TrafficType) {
+	if trafficType < 0 || trafficType >= 32 {
+		return
+	}
+	bit := uint32(1) << uint32(trafficType)
+	for {
+		old := atomic.LoadUint32(&n.trafficMask)
+		if old&bit != 0 || atomic.CompareAndSwapUint32(&n.trafficMask, old, old|bit) {
+			return
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package stat
+
+import (
+	"sort"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	sbase "github.com/alibaba/sentinel-golang/core/stat/base"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+)
+
+// WindowSnapshot 表示资源在一个统计窗口内的聚合统计数据.
+type WindowSnapshot struct {
+	// IntervalMs 表示统计窗口的时间跨度, 单位毫秒.
+	IntervalMs  uint32
+	PassQps     float64
+	BlockQps    float64
+	CompleteQps float64
+	ErrorQps    float64
+	// OccupiedPassQps 表示借用未来窗口通过的QPS, 目前没有统计该事件, 恒为0.
+	OccupiedPassQps float64
+	// AvgRt 和 MinRt 的单位为毫秒, 窗口内没有完成的请求时为0.
+	AvgRt          float64
+	MinRt          float64
+	MaxConcurrency int32
+}
+
+// ResourceSnapshot 表示资源在某一时刻的统计快照.
+type ResourceSnapshot struct {
+	Resource           string
+	ResourceType       base.ResourceType
+	TrafficTypes       []base.TrafficType
+	Timestamp          uint64
+	CurrentConcurrency int32
+	// Second 是秒级窗口(MetricStatisticIntervalMs)的统计数据.
+	Second WindowSnapshot
+	// Total 是整个统计窗口(GlobalStatisticIntervalMsTotal)的统计数据.
+	Total WindowSnapshot
+}
+
+type snapshotOptions struct {
+	resourceTypes []base.ResourceType
+	trafficTypes  []base.TrafficType
+}
+
+// SnapshotOption 用于过滤 Snapshot 返回的资源.
+type SnapshotOption func(*snapshotOptions)
+
+// WithResourceTypes 只返回给定分类的资源.
+func WithResourceTypes(resourceTypes ...base.ResourceType) SnapshotOption {
+	return func(opts *snapshotOptions) {
+		opts.resourceTypes = append(opts.resourceTypes, resourceTypes...)
+	}
+}
+
+// WithTrafficTypes 只返回以给定流量类型访问过的资源.
+func WithTrafficTypes(trafficTypes ...base.TrafficType) SnapshotOption {
+	return func(opts *snapshotOptions) {
+		opts.trafficTypes = append(opts.trafficTypes, trafficTypes...)
+	}
+}
+
+func (opts *snapshotOptions) matches(node *ResourceNode) bool {
+	if len(opts.resourceTypes) > 0 {
+		matched := false
+		for _, t := range opts.resourceTypes {
+			if node.ResourceType() == t {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(opts.trafficTypes) > 0 {
+		matched := false
+		for _, t := range opts.trafficTypes {
+			if node.HasTrafficType(t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Snapshot 返回所有资源的统计快照, 按资源名排序, 可以通过 SnapshotOption 按资源分类和流量类型过滤.
+// 全局入口流量节点不包含在内, 见 InboundSnapshot.
+func Snapshot(opts ...SnapshotOption) []*ResourceSnapshot {
+	options := &snapshotOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	nodes := ResourceNodeList()
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ResourceName() < nodes[j].ResourceName()
+	})
+	ret := make([]*ResourceSnapshot, 0, len(nodes))
+	for _, node := range nodes {
+		if !options.matches(node) {
+			continue
+		}
+		ret = append(ret, NodeSnapshot(node))
+	}
+	return ret
+}
+
+// ResourceSnapshotOf 返回给定资源的统计快照, 资源不存在时返回nil.
+func ResourceSnapshotOf(resource string) *ResourceSnapshot {
+	node := GetResourceNode(resource)
+	if node == nil {
+		return nil
+	}
+	return NodeSnapshot(node)
+}
+
+// InboundSnapshot 返回全局入口流量的统计快照.
+func InboundSnapshot() *ResourceSnapshot {
+	return NodeSnapshot(inboundNode)
+}
+
+// NodeSnapshot 返回给定资源节点的统计快照.
+func NodeSnapshot(node *ResourceNode) *ResourceSnapshot {
+	if node == nil {
+		return nil
+	}
+	total, err := sbase.NewSlidingWindowMetric(node.arr.SampleCount(), node.arr.IntervalInMs(), node.arr)
+	if err != nil {
+		logging.Error(err, "Fail to create the total window metric in stat.NodeSnapshot()", "resource", node.ResourceName())
+	}
+	return &ResourceSnapshot{
+		Resource:           node.ResourceName(),
+		ResourceType:       node.ResourceType(),
+		TrafficTypes:       node.TrafficTypes(),
+		Timestamp:          util.CurrentTimeMillis(),
+		CurrentConcurrency: node.CurrentConcurrency(),
+		Second:             windowSnapshotOf(node.metric, node.intervalMs),
+		Total:              windowSnapshotOf(total, node.arr.IntervalInMs()),
+	}
+}
+
+func windowSnapshotOf(m *sbase.SlidingWindowMetric, intervalMs uint32) WindowSnapshot {
+	ws := WindowSnapshot{IntervalMs: intervalMs}
+	if m == nil {
+		return ws
+	}
+	ws.PassQps = m.GetQPS(base.MetricEventPass)
+	ws.BlockQps = m.GetQPS(base.MetricEventBlock)
+	ws.CompleteQps = m.GetQPS(base.MetricEventComplete)
+	ws.ErrorQps = m.GetQPS(base.MetricEventError)
+	ws.MaxConcurrency = m.MaxConcurrency()
+	if complete := m.GetSum(base.MetricEventComplete); complete > 0 {
+		ws.AvgRt = float64(m.GetSum(base.MetricEventRt)) / float64(complete)
+		ws.MinRt = m.MinRT()
+	}
+	return ws
+}
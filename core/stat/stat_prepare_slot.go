@@ -21,5 +21,6 @@ func (s *ResourceNodePrepareSlot) Order() uint32 {
 
 func (s *ResourceNodePrepareSlot) Prepare(ctx *base.EntryContext) {
 	node := GetOrCreateResourceNode(ctx.Resource.Name(), ctx.Resource.Classification())
+	node.markTrafficType(ctx.Resource.FlowType())
 	ctx.StatNode = node
 }
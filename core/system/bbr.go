@@ -0,0 +1,176 @@
+package system
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+
+	sbase "github.com/alibaba/sentinel-golang/core/stat/base"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultBbrWindowMs    uint32  = 10000
+	DefaultBbrBucketCount uint32  = 100
+	DefaultBbrDecay       float64 = 0.95
+	DefaultBbrCooldownMs  uint32  = 1000
+)
+
+// bbrBucket is the statistic of the completed requests in a bucket, it's updated atomically.
+type bbrBucket struct {
+	pass  int64
+	rtSum int64
+}
+
+// bbrLimiter is the adaptive limiter based on the idea of TCP BBR. It smooths the metric of rule with EMA,
+// and estimates the max in-flight requests the system could take by the windowed max pass and min RT.
+// When the smoothed metric exceeds the threshold, the requests beyond the estimated capacity are shed,
+// and the shedding keeps going during the cooldown period even if the metric drops, to prevent the oscillation.
+type bbrLimiter struct {
+	threshold  float64
+	decay      float64
+	cooldownMs uint64
+	bucketMs   uint64
+
+	// stat is the sliding window of the completed requests, it's lock-free on the completion path.
+	stat *sbase.LeapArray
+
+	// mux guards the smoothed metric and the drop time.
+	mux sync.Mutex
+	// ema is the smoothed metric, it's updated at most once per bucket.
+	ema          float64
+	emaUpdatedAt uint64
+	prevDropTime uint64
+}
+
+func newBbrLimiter(rule *Rule) (*bbrLimiter, error) {
+	windowMs := rule.BbrWindowMs
+	if windowMs == 0 {
+		windowMs = DefaultBbrWindowMs
+	}
+	bucketCount := rule.BbrBucketCount
+	if bucketCount == 0 {
+		bucketCount = DefaultBbrBucketCount
+	}
+	decay := rule.BbrDecay
+	if decay == 0 {
+		decay = DefaultBbrDecay
+	}
+	cooldownMs := rule.BbrCooldownMs
+	if cooldownMs == 0 {
+		cooldownMs = DefaultBbrCooldownMs
+	}
+	bucketMs := windowMs / bucketCount
+	if bucketMs == 0 {
+		bucketMs = 1
+	}
+	l := &bbrLimiter{
+		threshold:  rule.TriggerCount,
+		decay:      decay,
+		cooldownMs: uint64(cooldownMs),
+		bucketMs:   uint64(bucketMs),
+	}
+	stat, err := sbase.NewLeapArray(bucketCount, bucketMs*bucketCount, l)
+	if err != nil {
+		return nil, err
+	}
+	l.stat = stat
+	return l, nil
+}
+
+func (l *bbrLimiter) NewEmptyBucket() interface{} {
+	return &bbrBucket{}
+}
+
+func (l *bbrLimiter) ResetBucketTo(bw *sbase.BucketWrap, startTime uint64) *sbase.BucketWrap {
+	atomic.StoreUint64(&bw.BucketStart, startTime)
+	bw.Value.Store(&bbrBucket{})
+	return bw
+}
+
+// onCompleted records the completed requests and their RT in milliseconds.
+func (l *bbrLimiter) onCompleted(count int64, rt uint64) {
+	curBucket, err := l.stat.CurrentBucket(l)
+	if err != nil {
+		logging.Error(err, "Fail to get current bucket in bbrLimiter.onCompleted()")
+		return
+	}
+	b, ok := curBucket.Value.Load().(*bbrBucket)
+	if !ok {
+		logging.Error(errors.New("bucket data type error"), "Bucket data type error in bbrLimiter.onCompleted()", "expect type", "*bbrBucket")
+		return
+	}
+	atomic.AddInt64(&b.pass, count)
+	atomic.AddInt64(&b.rtSum, int64(rt)*count)
+}
+
+// maxInflight estimates the max in-flight requests by max pass per bucket * min RT, the current bucket is excluded.
+func (l *bbrLimiter) maxInflight(now uint64) int64 {
+	currentStart := now - now%l.bucketMs
+	buckets := l.stat.ValuesConditional(now, func(start uint64) bool {
+		return start < currentStart
+	})
+	maxPass := int64(1)
+	minRt := math.MaxFloat64
+	for _, bw := range buckets {
+		b, ok := bw.Value.Load().(*bbrBucket)
+		if !ok {
+			continue
+		}
+		pass := atomic.LoadInt64(&b.pass)
+		if pass <= 0 {
+			continue
+		}
+		if pass > maxPass {
+			maxPass = pass
+		}
+		if rt := float64(atomic.LoadInt64(&b.rtSum)) / float64(pass); rt < minRt {
+			minRt = rt
+		}
+	}
+	if minRt == math.MaxFloat64 || minRt < 1 {
+		minRt = 1
+	}
+	bucketsPerSecond := 1000.0 / float64(l.bucketMs)
+	return int64(math.Ceil(float64(maxPass) * bucketsPerSecond * minRt / 1000.0))
+}
+
+func (l *bbrLimiter) updateEmaLocked(now uint64, v float64) {
+	if l.emaUpdatedAt == 0 {
+		l.ema = v
+		l.emaUpdatedAt = now
+		return
+	}
+	if now < l.emaUpdatedAt+l.bucketMs {
+		return
+	}
+	l.ema = l.ema*l.decay + v*(1-l.decay)
+	l.emaUpdatedAt = now
+}
+
+// shouldDrop checks whether the request should be shed by the current metric value and in-flight requests.
+// It returns the smoothed metric value as well.
+func (l *bbrLimiter) shouldDrop(v float64, inflight int64) (bool, float64) {
+	now := util.CurrentTimeMillis()
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.updateEmaLocked(now, v)
+	if l.ema < l.threshold {
+		if l.prevDropTime == 0 {
+			return false, l.ema
+		}
+		if now-l.prevDropTime <= l.cooldownMs {
+			// Still in cooldown, keep shedding the requests beyond the capacity.
+			return inflight > 1 && inflight > l.maxInflight(now), l.ema
+		}
+		l.prevDropTime = 0
+		return false, l.ema
+	}
+	drop := inflight > 1 && inflight > l.maxInflight(now)
+	if drop && l.prevDropTime == 0 {
+		l.prevDropTime = now
+	}
+	return drop, l.ema
+}
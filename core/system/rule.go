@@ -3,6 +3,8 @@ package system
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/alibaba/sentinel-golang/core/base"
 )
 
 type MetricType uint32 // 指标类型
@@ -13,6 +15,12 @@ const (
 	Concurrency                      // 并发性表示所有入站请求的并发性.
 	InboundQPS                       // 表示所有入站请求的QPS.
 	CpuUsage                         // 表示系统CPU占用率.
+	Goroutines                       // 表示当前goroutine数量.
+	HeapInUse                        // 表示正在使用的堆内存字节数.
+	GcPause                          // 表示采集周期内GC停顿时间的P99(毫秒).
+	GcCpuFraction                    // 表示采集周期内GC占用的CPU比例.
+	SchedLatency                     // 表示采集周期内goroutine调度延迟的P99(毫秒).
+	CustomSignal                     // 表示由 Rule.Signal 指定的自定义信号, 需通过 system_metric.RegisterMetricProvider 注册.
 	MetricTypeSize                   // MetricType枚举大小.
 )
 
@@ -28,6 +36,18 @@ func (t MetricType) String() string {
 		return "inboundQPS"
 	case CpuUsage:
 		return "cpuUsage"
+	case Goroutines:
+		return "goroutines"
+	case HeapInUse:
+		return "heapInUse"
+	case GcPause:
+		return "gcPause"
+	case GcCpuFraction:
+		return "gcCpuFraction"
+	case SchedLatency:
+		return "schedLatency"
+	case CustomSignal:
+		return "customSignal"
 	default:
 		return fmt.Sprintf("unknown(%d)", t)
 	}
@@ -38,6 +58,8 @@ type AdaptiveStrategy int32
 const (
 	NoAdaptive AdaptiveStrategy = -1
 	BBR        AdaptiveStrategy = iota // 表示基于TCP BBR思想的自适应策略.
+	// BBRLimiter 表示完整的BBR限流器: 对指标做EMA平滑, 由窗口内最大通过数和最小RT估算系统容量, 并在开始丢弃后保持冷却期.
+	BBRLimiter
 )
 
 func (t AdaptiveStrategy) String() string {
@@ -46,6 +68,8 @@ func (t AdaptiveStrategy) String() string {
 		return "none"
 	case BBR:
 		return "bbr"
+	case BBRLimiter:
+		return "bbrLimiter"
 	default:
 		return fmt.Sprintf("unknown(%d)", t)
 	}
@@ -58,6 +82,34 @@ type Rule struct {
 	// TriggerCount表示自适应策略的下界触发器, 自适应策略将不会被激活，直到目标度量达到触发计数.
 	TriggerCount float64          `json:"triggerCount"`
 	Strategy     AdaptiveStrategy `json:"strategy"` // 自适应策略
+	// ResourceTypes limits the rule to the resources of the given types, empty means no limitation on the type.
+	ResourceTypes []base.ResourceType `json:"resourceTypes,omitempty"`
+	// Resources limits the rule to the given resources.
+	Resources []string `json:"resources,omitempty"`
+	// ResourcePattern is the regular expression of the resource names which the rule applies to.
+	// The resource is in the scope if it's either in Resources or matches ResourcePattern.
+	ResourcePattern string `json:"resourcePattern,omitempty"`
+	// Priority decides which traffic gets shed first, the smaller the value, the earlier the traffic is shed.
+	// When a rule is triggered, the traffic whose rules have the smaller priority is shed too.
+	Priority int32 `json:"priority,omitempty"`
+	// The following fields are the parameters of BBRLimiter strategy, the default values are used if not set.
+	// BbrWindowMs is the length of window to compute the max pass and min RT.
+	BbrWindowMs uint32 `json:"bbrWindowMs,omitempty"`
+	// BbrBucketCount is the amount of buckets in the window.
+	BbrBucketCount uint32 `json:"bbrBucketCount,omitempty"`
+	// BbrDecay is the decay of the EMA of the metric, in [0.0, 1.0), the larger the smoother.
+	BbrDecay float64 `json:"bbrDecay,omitempty"`
+	// BbrCooldownMs is the period the shedding keeps going after the metric drops below the threshold.
+	BbrCooldownMs uint32 `json:"bbrCooldownMs,omitempty"`
+	// Signal is the name of the signal when the MetricType is CustomSignal, which could be a built-in signal as well.
+	Signal string `json:"signal,omitempty"`
+}
+
+// IsScoped indicates whether the rule is scoped by resource type or resource.
+// The rule without scope applies to all the inbound traffic, while the scoped rule of InboundQPS, Concurrency and AvgRT
+// compares against the statistic of the traffic (both inbound and outbound) in its own scope.
+func (r *Rule) IsScoped() bool {
+	return len(r.ResourceTypes) > 0 || len(r.Resources) > 0 || len(r.ResourcePattern) > 0
 }
 
 func (r *Rule) String() string {
@@ -70,5 +122,8 @@ func (r *Rule) String() string {
 }
 
 func (r *Rule) ResourceName() string {
+	if r.MetricType == CustomSignal {
+		return r.Signal
+	}
 	return r.MetricType.String()
 }
@@ -0,0 +1,116 @@
+package system
+
+import (
+	"reflect"
+	"regexp"
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/alibaba/sentinel-golang/logging"
+)
+
+// scopeStatNodesKey is the key of ctx.Data, the value is the statistic nodes of the scopes which the request matches.
+const scopeStatNodesKey = "sentinel:system:scopeStatNodes"
+
+// bbrCheckersKey is the key of ctx.Data, the value is the checkers of the BBRLimiter rules which the passed request matches.
+const bbrCheckersKey = "sentinel:system:bbrCheckers"
+
+// ruleChecker decides whether the rule applies to the resource.
+type ruleChecker struct {
+	rule      *Rule
+	resTypes  map[base.ResourceType]struct{}
+	resources map[string]struct{}
+	pattern   *regexp.Regexp
+	// matchedCache caches the result of pattern matching by resource name.
+	matchedCache sync.Map
+	// bbr is the limiter of the rule with BBRLimiter strategy.
+	bbr *bbrLimiter
+	// scopeStat is the statistic of the traffic in the scope of rule, nil if the rule isn't scoped
+	// or doesn't depend on the traffic statistic.
+	scopeStat *stat.BaseStatNode
+}
+
+func newRuleChecker(rule *Rule) *ruleChecker {
+	c := &ruleChecker{rule: rule}
+	if len(rule.ResourceTypes) > 0 {
+		c.resTypes = make(map[base.ResourceType]struct{}, len(rule.ResourceTypes))
+		for _, t := range rule.ResourceTypes {
+			c.resTypes[t] = struct{}{}
+		}
+	}
+	if len(rule.Resources) > 0 {
+		c.resources = make(map[string]struct{}, len(rule.Resources))
+		for _, r := range rule.Resources {
+			c.resources[r] = struct{}{}
+		}
+	}
+	if len(rule.ResourcePattern) > 0 {
+		// The pattern is validated before.
+		c.pattern = regexp.MustCompile(rule.ResourcePattern)
+	}
+	if rule.Strategy == BBRLimiter {
+		bbr, err := newBbrLimiter(rule)
+		if err != nil {
+			logging.Error(err, "Fail to create the bbr limiter in system.newRuleChecker()", "rule", rule)
+		}
+		c.bbr = bbr
+	}
+	if needsScopeStat(rule) {
+		c.scopeStat = stat.NewBaseStatNode(config.MetricStatisticSampleCount(), config.MetricStatisticIntervalMs())
+	}
+	return c
+}
+
+// needsScopeStat checks whether the scoped rule depends on the traffic statistic of its scope.
+func needsScopeStat(rule *Rule) bool {
+	if !rule.IsScoped() {
+		return false
+	}
+	return !isAboveThresholdMetric(rule.MetricType) || rule.Strategy == BBR || rule.Strategy == BBRLimiter
+}
+
+// statNode returns the statistic which the rule compares against, the global inbound statistic is used
+// for the rule without scope.
+func (c *ruleChecker) statNode() *stat.BaseStatNode {
+	if c.scopeStat != nil {
+		return c.scopeStat
+	}
+	return &stat.InboundNode().BaseStatNode
+}
+
+// sameScope checks whether the two rules apply to the same resources.
+func sameScope(r1, r2 *Rule) bool {
+	return reflect.DeepEqual(r1.ResourceTypes, r2.ResourceTypes) && reflect.DeepEqual(r1.Resources, r2.Resources) &&
+		r1.ResourcePattern == r2.ResourcePattern
+}
+
+// matches checks whether the resource is in the scope of rule.
+// The rule without scope only applies to the inbound traffic.
+func (c *ruleChecker) matches(res *base.ResourceWrapper) bool {
+	if !c.rule.IsScoped() {
+		return res.FlowType() == base.Inbound
+	}
+	if c.resTypes != nil {
+		if _, ok := c.resTypes[res.Classification()]; !ok {
+			return false
+		}
+	}
+	if c.resources == nil && c.pattern == nil {
+		return true
+	}
+	name := res.Name()
+	if _, ok := c.resources[name]; ok {
+		return true
+	}
+	if c.pattern == nil {
+		return false
+	}
+	if matched, ok := c.matchedCache.Load(name); ok {
+		return matched.(bool)
+	}
+	matched := c.pattern.MatchString(name)
+	c.matchedCache.Store(name, matched)
+	return matched
+}
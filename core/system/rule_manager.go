@@ -2,8 +2,11 @@ package system
 
 import (
 	"reflect"
+	"regexp"
+	"sort"
 	"sync"
 
+	"github.com/alibaba/sentinel-golang/core/stat"
 	"github.com/alibaba/sentinel-golang/logging"
 	"github.com/alibaba/sentinel-golang/util"
 	"github.com/pkg/errors"
@@ -14,6 +17,7 @@ type RuleMap map[MetricType][]*Rule
 // const
 var (
 	ruleMap       = make(RuleMap)
+	ruleCheckers  = make([]*ruleChecker, 0)
 	ruleMapMux    = new(sync.RWMutex)
 	currentRules  = make([]*Rule, 0)
 	updateRuleMux = new(sync.Mutex)
@@ -39,16 +43,12 @@ func GetRules() []Rule {
 	return ret
 }
 
-// getRules 返回所有规则.任何规则的变更只对系统模块生效
-func getRules() []*Rule {
+// getRuleCheckers returns the checkers of all rules, which are sorted by priority in ascending order.
+func getRuleCheckers() []*ruleChecker {
 	ruleMapMux.RLock()
 	defer ruleMapMux.RUnlock()
 
-	rules := make([]*Rule, 0, 8)
-	for _, rs := range ruleMap {
-		rules = append(rules, rs...)
-	}
-	return rules
+	return ruleCheckers
 }
 
 // LoadRules 将给定的系统规则加载到规则管理器，而之前的所有规则将被替换.
@@ -78,7 +78,9 @@ func ClearRules() error {
 func onRuleUpdate(r RuleMap) error {
 	start := util.CurrentTimeNano()
 	ruleMapMux.Lock()
+	checkers := buildRuleCheckers(r, ruleCheckers)
 	ruleMap = r
+	ruleCheckers = checkers
 	ruleMapMux.Unlock()
 
 	logging.Debug("[System onRuleUpdate] Time statistic(ns) for updating system rule", "timeCost", util.CurrentTimeNano()-start)
@@ -112,6 +114,60 @@ func buildRuleMap(rules []*Rule) RuleMap {
 	return m
 }
 
+// buildRuleCheckers creates the checkers of the rules, the BBR limiters of the unchanged rules
+// and the scope statistics of the unchanged scopes are reused, the rules of the same scope share the statistic.
+func buildRuleCheckers(m RuleMap, oldCheckers []*ruleChecker) []*ruleChecker {
+	checkers := make([]*ruleChecker, 0, 8)
+	for _, rules := range m {
+		for _, rule := range rules {
+			c := newRuleChecker(rule)
+			if c.bbr != nil {
+				for _, old := range oldCheckers {
+					if old.bbr != nil && reflect.DeepEqual(old.rule, rule) {
+						c.bbr = old.bbr
+						break
+					}
+				}
+			}
+			if c.scopeStat != nil {
+				if shared := findScopeStat(checkers, rule); shared != nil {
+					c.scopeStat = shared
+				} else if reused := findScopeStat(oldCheckers, rule); reused != nil {
+					c.scopeStat = reused
+				}
+			}
+			checkers = append(checkers, c)
+		}
+	}
+	sort.SliceStable(checkers, func(i, j int) bool {
+		if checkers[i].rule.Priority != checkers[j].rule.Priority {
+			return checkers[i].rule.Priority < checkers[j].rule.Priority
+		}
+		// Keep the order stable among the metric types.
+		return checkers[i].rule.MetricType < checkers[j].rule.MetricType
+	})
+	return checkers
+}
+
+// GetScopeStatNode returns the statistic of the traffic in the scope of the given rule,
+// it returns nil if there is no loaded rule of the same scope which depends on the traffic statistic.
+func GetScopeStatNode(rule *Rule) *stat.BaseStatNode {
+	if rule == nil {
+		return nil
+	}
+	return findScopeStat(getRuleCheckers(), rule)
+}
+
+// findScopeStat returns the scope statistic of the checker whose rule has the same scope as the given rule.
+func findScopeStat(checkers []*ruleChecker, rule *Rule) *stat.BaseStatNode {
+	for _, c := range checkers {
+		if c.scopeStat != nil && sameScope(c.rule, rule) {
+			return c.scopeStat
+		}
+	}
+	return nil
+}
+
 // IsValidSystemRule 判断系统规则是否有效
 func IsValidSystemRule(rule *Rule) error {
 	if rule == nil {
@@ -127,5 +183,27 @@ func IsValidSystemRule(rule *Rule) error {
 	if rule.MetricType == CpuUsage && rule.TriggerCount > 1 {
 		return errors.New("invalid CPU usage, valid range is [0.0, 1.0]")
 	}
+	if rule.MetricType == GcCpuFraction && rule.TriggerCount > 1 {
+		return errors.New("invalid GC CPU fraction, valid range is [0.0, 1.0]")
+	}
+	if rule.MetricType == CustomSignal && len(rule.Signal) == 0 {
+		return errors.New("empty signal of custom signal metric type")
+	}
+	if rule.Strategy == BBRLimiter {
+		if !isAboveThresholdMetric(rule.MetricType) {
+			return errors.New("BBR limiter strategy is not supported by the metric type")
+		}
+		if rule.BbrDecay < 0 || rule.BbrDecay >= 1 {
+			return errors.New("invalid BBR decay, valid range is [0.0, 1.0)")
+		}
+		if rule.BbrWindowMs > 0 && rule.BbrBucketCount > rule.BbrWindowMs {
+			return errors.New("BBR bucket count exceeds the window length in milliseconds")
+		}
+	}
+	if len(rule.ResourcePattern) > 0 {
+		if _, err := regexp.Compile(rule.ResourcePattern); err != nil {
+			return errors.Wrapf(err, "invalid resource pattern: %s", rule.ResourcePattern)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,73 @@
+package system
+
+import (
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/stat"
+)
+
+const (
+	StatSlotOrder = 2000
+)
+
+var (
+	DefaultAdaptiveStatSlot = &AdaptiveStatSlot{}
+)
+
+// AdaptiveStatSlot records the statistics of the scoped rules and the completed requests for the matched BBR limiters.
+type AdaptiveStatSlot struct {
+}
+
+func (s *AdaptiveStatSlot) Order() uint32 {
+	return StatSlotOrder
+}
+
+func (s *AdaptiveStatSlot) OnEntryPassed(ctx *base.EntryContext) {
+	for _, n := range scopeStatNodesOf(ctx) {
+		n.IncreaseConcurrency()
+		n.AddCount(base.MetricEventPass, int64(ctx.Input.BatchCount))
+	}
+}
+
+func (s *AdaptiveStatSlot) OnEntryBlocked(ctx *base.EntryContext, _ *base.BlockError) {
+	for _, n := range scopeStatNodesOf(ctx) {
+		n.AddCount(base.MetricEventBlock, int64(ctx.Input.BatchCount))
+	}
+}
+
+func (s *AdaptiveStatSlot) OnCompleted(ctx *base.EntryContext) {
+	if ctx.Resource == nil {
+		return
+	}
+	if nodes := scopeStatNodesOf(ctx); len(nodes) > 0 {
+		rt := ctx.Rt()
+		for _, n := range nodes {
+			if ctx.Err() != nil {
+				n.AddCount(base.MetricEventError, int64(ctx.Input.BatchCount))
+			}
+			n.AddCount(base.MetricEventRt, int64(rt))
+			n.AddCount(base.MetricEventComplete, int64(ctx.Input.BatchCount))
+			n.DecreaseConcurrency()
+		}
+	}
+	for _, c := range bbrCheckersOf(ctx) {
+		c.bbr.onCompleted(int64(ctx.Input.BatchCount), ctx.Rt())
+	}
+}
+
+// scopeStatNodesOf returns the statistic nodes of the scopes which the request matches, they're put by AdaptiveSlot.
+func scopeStatNodesOf(ctx *base.EntryContext) []*stat.BaseStatNode {
+	if ctx.Data == nil {
+		return nil
+	}
+	nodes, _ := ctx.Data[scopeStatNodesKey].([]*stat.BaseStatNode)
+	return nodes
+}
+
+// bbrCheckersOf returns the checkers of the BBRLimiter rules which the request matches, they're put by AdaptiveSlot.
+func bbrCheckersOf(ctx *base.EntryContext) []*ruleChecker {
+	if ctx.Data == nil {
+		return nil
+	}
+	checkers, _ := ctx.Data[bbrCheckersKey].([]*ruleChecker)
+	return checkers
+}
@@ -22,48 +22,102 @@ func (s *AdaptiveSlot) Order() uint32 {
 	return RuleCheckSlotOrder
 }
 
+// Check checks the system rules which apply to the resource, the rules are checked in ascending order of priority.
+// The traffic is also shed when the rule of the higher priority than its own rules is triggered,
+// so that the traffic with lower priority gets shed first.
+// The scoped rules of InboundQPS, Concurrency and AvgRT compare against the statistics of their own scope,
+// which are recorded by DefaultAdaptiveStatSlot, so the slot should work together with it.
 func (s *AdaptiveSlot) Check(ctx *base.EntryContext) *base.TokenResult {
-	if ctx == nil || ctx.Resource == nil || ctx.Resource.FlowType() != base.Inbound {
+	if ctx == nil || ctx.Resource == nil {
 		return nil
 	}
-	rules := getRules()
+	checkers := getRuleCheckers()
+	if len(checkers) == 0 {
+		return ctx.RuleCheckResult
+	}
+	hasMatched := false
+	blocked := false
+	var minPriority int32
+	var scopeNodes []*stat.BaseStatNode
+	var bbrCheckers []*ruleChecker
 	result := ctx.RuleCheckResult
-	for _, rule := range rules {
-		passed, msg, snapshotValue := s.doCheckRule(rule)
+	for _, c := range checkers {
+		if c.matches(ctx.Resource) {
+			if !hasMatched {
+				// The checkers are sorted by priority, so the first matched one has the min priority.
+				hasMatched = true
+				minPriority = c.rule.Priority
+			}
+			if c.scopeStat != nil && !containsNode(scopeNodes, c.scopeStat) {
+				scopeNodes = append(scopeNodes, c.scopeStat)
+			}
+			if c.bbr != nil {
+				bbrCheckers = append(bbrCheckers, c)
+			}
+		} else if !hasMatched || c.rule.Priority <= minPriority {
+			continue
+		}
+		if blocked {
+			// Keep collecting the scopes which the blocked request matches.
+			continue
+		}
+		rule := c.rule
+		var passed bool
+		var msg string
+		var snapshotValue float64
+		if c.bbr != nil {
+			passed, msg, snapshotValue = s.doCheckBbr(c)
+		} else {
+			passed, msg, snapshotValue = s.doCheckRule(c)
+		}
 		if passed {
 			continue
 		}
+		blocked = true
 		if result == nil {
 			result = base.NewTokenResultBlockedWithCause(base.BlockTypeSystemFlow, msg, rule, snapshotValue)
 		} else {
 			result.ResetToBlockedWithCause(base.BlockTypeSystemFlow, msg, rule, snapshotValue)
 		}
-		return result
+	}
+	// The scope nodes are put for the blocked request as well, so that the block count of the scopes is recorded.
+	if len(scopeNodes) > 0 || len(bbrCheckers) > 0 {
+		if ctx.Data == nil {
+			ctx.Data = make(map[interface{}]interface{})
+		}
+		if len(scopeNodes) > 0 {
+			ctx.Data[scopeStatNodesKey] = scopeNodes
+		}
+		if len(bbrCheckers) > 0 {
+			ctx.Data[bbrCheckersKey] = bbrCheckers
+		}
 	}
 	return result
 }
 
-func (s *AdaptiveSlot) doCheckRule(rule *Rule) (bool, string, float64) {
+func (s *AdaptiveSlot) doCheckRule(c *ruleChecker) (bool, string, float64) {
 	var msg string
 
+	rule := c.rule
+	node := c.statNode()
 	threshold := rule.TriggerCount
 	switch rule.MetricType {
 	case InboundQPS:
-		qps := stat.InboundNode().GetQPS(base.MetricEventPass)
+		qps := node.GetQPS(base.MetricEventPass)
 		res := qps < threshold
 		if !res {
 			msg = "system qps check blocked"
 		}
 		return res, msg, qps
 	case Concurrency:
-		n := float64(stat.InboundNode().CurrentConcurrency())
+		n := float64(node.CurrentConcurrency())
 		res := n < threshold
 		if !res {
 			msg = "system concurrency check blocked"
 		}
 		return res, msg, n
 	case AvgRT:
-		rt := stat.InboundNode().AvgRT()
+		rt := node.AvgRT()
 		res := rt < threshold
 		if !res {
 			msg = "system avg rt check blocked"
@@ -72,31 +126,123 @@ func (s *AdaptiveSlot) doCheckRule(rule *Rule) (bool, string, float64) {
 	case Load:
 		l := system_metric.CurrentLoad()
 		if l > threshold {
-			if rule.Strategy != BBR || !checkBbrSimple() {
+			if rule.Strategy != BBR || !checkBbrSimple(node) {
 				msg = "system load check blocked"
 				return false, msg, l
 			}
 		}
 		return true, "", l
 	case CpuUsage:
-		c := system_metric.CurrentCpuUsage()
-		if c > threshold {
-			if rule.Strategy != BBR || !checkBbrSimple() {
+		cpu := system_metric.CurrentCpuUsage()
+		if cpu > threshold {
+			if rule.Strategy != BBR || !checkBbrSimple(node) {
 				msg = "system cpu usage check blocked"
-				return false, msg, c
+				return false, msg, cpu
 			}
 		}
-		return true, "", c
+		return true, "", cpu
+	case Goroutines:
+		return checkRuntimeSignal(c, float64(system_metric.CurrentGoroutines()), "system goroutines check blocked")
+	case HeapInUse:
+		return checkRuntimeSignal(c, float64(system_metric.CurrentHeapInUse()), "system heap in use check blocked")
+	case GcPause:
+		return checkRuntimeSignal(c, system_metric.CurrentGcPause(), "system gc pause check blocked")
+	case GcCpuFraction:
+		return checkRuntimeSignal(c, system_metric.CurrentGcCpuFraction(), "system gc cpu fraction check blocked")
+	case SchedLatency:
+		return checkRuntimeSignal(c, system_metric.CurrentSchedLatency(), "system sched latency check blocked")
+	case CustomSignal:
+		return checkRuntimeSignal(c, currentSignalValue(rule.Signal), "system custom signal check blocked")
 	default:
 		msg = "system undefined metric type, pass by default"
 		return true, msg, 0.0
 	}
 }
 
-func checkBbrSimple() bool {
-	concurrency := stat.InboundNode().CurrentConcurrency()
-	minRt := stat.InboundNode().MinRT()
-	maxComplete := stat.InboundNode().GetMaxAvg(base.MetricEventComplete)
+func containsNode(nodes []*stat.BaseStatNode, node *stat.BaseStatNode) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+// doCheckBbr checks the rule with BBRLimiter strategy, the snapshot value is the smoothed metric value.
+func (s *AdaptiveSlot) doCheckBbr(c *ruleChecker) (bool, string, float64) {
+	v := currentMetricValue(c.rule)
+	if v < 0 {
+		// The metric is not retrieved yet.
+		return true, "", v
+	}
+	inflight := int64(c.statNode().CurrentConcurrency())
+	drop, ema := c.bbr.shouldDrop(v, inflight)
+	if drop {
+		return false, "system bbr limiter check blocked", ema
+	}
+	return true, "", ema
+}
+
+// isAboveThresholdMetric indicates whether the metric type blocks the traffic when the metric is above the threshold,
+// regardless of the current inbound traffic.
+func isAboveThresholdMetric(t MetricType) bool {
+	switch t {
+	case Load, CpuUsage, Goroutines, HeapInUse, GcPause, GcCpuFraction, SchedLatency, CustomSignal:
+		return true
+	default:
+		return false
+	}
+}
+
+// currentSignalValue returns the current value of the signal, -1 if the signal is not retrieved yet.
+func currentSignalValue(signal string) float64 {
+	v, ok := system_metric.CurrentSignal(signal)
+	if !ok {
+		return -1
+	}
+	return v
+}
+
+// currentMetricValue returns the current value of the metric which is above-threshold type.
+func currentMetricValue(rule *Rule) float64 {
+	switch rule.MetricType {
+	case CustomSignal:
+		return currentSignalValue(rule.Signal)
+	case Load:
+		return system_metric.CurrentLoad()
+	case CpuUsage:
+		return system_metric.CurrentCpuUsage()
+	case Goroutines:
+		return float64(system_metric.CurrentGoroutines())
+	case HeapInUse:
+		return float64(system_metric.CurrentHeapInUse())
+	case GcPause:
+		return system_metric.CurrentGcPause()
+	case GcCpuFraction:
+		return system_metric.CurrentGcCpuFraction()
+	case SchedLatency:
+		return system_metric.CurrentSchedLatency()
+	default:
+		return -1
+	}
+}
+
+// checkRuntimeSignal checks the Go runtime signal or custom signal like the load, the negative value means the signal is not retrieved yet.
+func checkRuntimeSignal(c *ruleChecker, v float64, blockedMsg string) (bool, string, float64) {
+	rule := c.rule
+	if v < 0 || v <= rule.TriggerCount {
+		return true, "", v
+	}
+	if rule.Strategy == BBR && checkBbrSimple(c.statNode()) {
+		return true, "", v
+	}
+	return false, blockedMsg, v
+}
+
+func checkBbrSimple(node *stat.BaseStatNode) bool {
+	concurrency := node.CurrentConcurrency()
+	minRt := node.MinRT()
+	maxComplete := node.GetMaxAvg(base.MetricEventComplete)
 	if concurrency > 1 && float64(concurrency) > maxComplete*minRt/1000.0 {
 		return false
 	}
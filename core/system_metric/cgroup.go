@@ -0,0 +1,501 @@
+package system_metric
+
+import (
+	"bufio"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CgroupModeAuto detects the cgroup of current process, and falls back to the process metrics
+	// if the cgroup files are not accessible.
+	CgroupModeAuto = "auto"
+	// CgroupModeV1 reads the metrics from cgroup v1 hierarchy.
+	CgroupModeV1 = "v1"
+	// CgroupModeV2 reads the metrics from cgroup v2 unified hierarchy.
+	CgroupModeV2 = "v2"
+	// CgroupModeDisabled always uses the process metrics, which is the default mode.
+	CgroupModeDisabled = "disabled"
+
+	DefaultCgroupRootPath = "/sys/fs/cgroup"
+
+	// cgroupV1UnlimitedMemory is the threshold above which the cgroup v1 memory limit is regarded as unlimited,
+	// since cgroup v1 reports a huge number rather than "max" for no limitation.
+	cgroupV1UnlimitedMemory = int64(1) << 62
+)
+
+// cgroupReader reads the CPU and memory statistic of the container from cgroup.
+type cgroupReader interface {
+	// Version returns the cgroup version, i.e. CgroupModeV1 or CgroupModeV2.
+	Version() string
+	// CpuUsageNanos returns the cumulative CPU time consumed by the cgroup in nanoseconds.
+	CpuUsageNanos() (uint64, error)
+	// CpuLimitCores returns the CPU quota in cores, 0 means no limitation.
+	CpuLimitCores() (float64, error)
+	// MemoryUsageBytes returns the working set of the cgroup, i.e. the usage excluding the inactive file cache.
+	MemoryUsageBytes() (int64, error)
+	// MemoryLimitBytes returns the memory limit of the cgroup, 0 means no limitation.
+	MemoryLimitBytes() (int64, error)
+}
+
+var (
+	// procSelfCgroupPath and procSelfMountinfoPath are used to resolve the cgroup of current process.
+	procSelfCgroupPath    = "/proc/self/cgroup"
+	procSelfMountinfoPath = "/proc/self/mountinfo"
+
+	cgroupMux      = new(sync.RWMutex)
+	activeCgroup   cgroupReader
+	cgroupCpuStats *cgroupCpuSampler
+)
+
+// InitCgroupReader chooses the cgroup reader by the given mode, the CPU usage and memory usage
+// are retrieved from the cgroup of current process instead of the process itself once the reader is chosen.
+// The cgroup is resolved by /proc/self/cgroup and /proc/self/mountinfo, rootPath is used as the mount point
+// of cgroup hierarchy when the mount info is not available.
+// The process metrics are collected if the cgroup files are not accessible.
+// It should be invoked before the system metric collectors are started.
+func InitCgroupReader(mode string, rootPath string) error {
+	if len(rootPath) == 0 {
+		rootPath = DefaultCgroupRootPath
+	}
+	var reader cgroupReader
+	switch mode {
+	case CgroupModeAuto:
+		reader = detectCgroupReader(rootPath)
+	case CgroupModeV1:
+		if r, err := newCgroupV1Reader(rootPath); err == nil {
+			reader = r
+		} else {
+			logging.Warn("[SystemMetric] Fail to use cgroup v1 reader, the process metrics would be collected", "err", err.Error())
+		}
+	case CgroupModeV2:
+		if r, err := newCgroupV2Reader(rootPath); err == nil {
+			reader = r
+		} else {
+			logging.Warn("[SystemMetric] Fail to use cgroup v2 reader, the process metrics would be collected", "err", err.Error())
+		}
+	case "", CgroupModeDisabled:
+	default:
+		return errors.Errorf("unknown cgroup mode: %s", mode)
+	}
+	setCgroupReader(reader)
+	if reader == nil {
+		logging.Info("[SystemMetric] cgroup reader is not used, the process metrics would be collected", "mode", mode)
+		return nil
+	}
+	if limit, err := reader.MemoryLimitBytes(); err == nil && limit > 0 {
+		TotalMemorySize = uint64(limit)
+	}
+	logging.Info("[SystemMetric] cgroup reader is used", "version", reader.Version(), "root", rootPath)
+	return nil
+}
+
+// CurrentCgroupVersion returns the version of cgroup reader in use, empty if the host metrics are collected.
+func CurrentCgroupVersion() string {
+	cgroupMux.RLock()
+	defer cgroupMux.RUnlock()
+	if activeCgroup == nil {
+		return ""
+	}
+	return activeCgroup.Version()
+}
+
+func setCgroupReader(reader cgroupReader) {
+	cgroupMux.Lock()
+	defer cgroupMux.Unlock()
+	activeCgroup = reader
+	if reader == nil {
+		cgroupCpuStats = nil
+		return
+	}
+	cgroupCpuStats = &cgroupCpuSampler{reader: reader}
+}
+
+func currentCgroupReader() (cgroupReader, *cgroupCpuSampler) {
+	cgroupMux.RLock()
+	defer cgroupMux.RUnlock()
+	return activeCgroup, cgroupCpuStats
+}
+
+// detectCgroupReader tries cgroup v2 first and then cgroup v1, returns nil if neither is accessible.
+func detectCgroupReader(rootPath string) cgroupReader {
+	if r, err := newCgroupV2Reader(rootPath); err == nil {
+		return r
+	}
+	if r, err := newCgroupV1Reader(rootPath); err == nil {
+		return r
+	}
+	return nil
+}
+
+// cgroupMount is the mount point of cgroup hierarchy in /proc/self/mountinfo.
+type cgroupMount struct {
+	// root is the path in the hierarchy which is mounted, it's not "/" when the cgroup namespace isn't used.
+	root        string
+	mountPoint  string
+	isV2        bool
+	controllers map[string]bool
+}
+
+// processCgroup is the cgroup of current process.
+type processCgroup struct {
+	// paths is the path of the cgroup by controller, the path of cgroup v2 is keyed by the empty string.
+	paths  map[string]string
+	mounts []cgroupMount
+}
+
+// loadProcessCgroup parses /proc/self/cgroup and /proc/self/mountinfo, the missing files are regarded as empty.
+func loadProcessCgroup() *processCgroup {
+	pc := &processCgroup{paths: make(map[string]string)}
+	if content, err := ioutil.ReadFile(procSelfCgroupPath); err == nil {
+		// The format of each line is "hierarchy-ID:controller-list:cgroup-path".
+		for _, line := range strings.Split(string(content), "\n") {
+			fields := strings.SplitN(strings.TrimSpace(line), ":", 3)
+			if len(fields) != 3 {
+				continue
+			}
+			if fields[0] == "0" && fields[1] == "" {
+				pc.paths[""] = fields[2]
+				continue
+			}
+			for _, controller := range strings.Split(fields[1], ",") {
+				pc.paths[controller] = fields[2]
+			}
+		}
+	}
+	if content, err := ioutil.ReadFile(procSelfMountinfoPath); err == nil {
+		// The format of each line is "id parent major:minor root mount-point options [optional...] - fstype source super-options".
+		for _, line := range strings.Split(string(content), "\n") {
+			fields := strings.Fields(line)
+			sep := -1
+			for i, f := range fields {
+				if f == "-" {
+					sep = i
+					break
+				}
+			}
+			if sep < 5 || sep+3 > len(fields) {
+				continue
+			}
+			switch fields[sep+1] {
+			case "cgroup2":
+				pc.mounts = append(pc.mounts, cgroupMount{root: fields[3], mountPoint: fields[4], isV2: true})
+			case "cgroup":
+				controllers := make(map[string]bool)
+				for _, opt := range strings.Split(fields[sep+3], ",") {
+					controllers[opt] = true
+				}
+				pc.mounts = append(pc.mounts, cgroupMount{root: fields[3], mountPoint: fields[4], controllers: controllers})
+			}
+		}
+	}
+	return pc
+}
+
+// dirOf returns the directory of the cgroup of current process for the controller ("" for cgroup v2),
+// the subsystem directories under rootPath are tried in order if the hierarchy is not found in mount info.
+func (pc *processCgroup) dirOf(controller string, rootPath string, subsystems ...string) string {
+	cgroupPath, ok := pc.paths[controller]
+	if !ok {
+		cgroupPath = "/"
+	}
+	for _, m := range pc.mounts {
+		if m.isV2 != (controller == "") || (!m.isV2 && !m.controllers[controller]) {
+			continue
+		}
+		rel := cgroupPath
+		if m.root != "/" {
+			if !strings.HasPrefix(cgroupPath, m.root) {
+				// The cgroup is out of the mounted part of hierarchy, the root of mount is the best effort.
+				return m.mountPoint
+			}
+			rel = strings.TrimPrefix(cgroupPath, m.root)
+		}
+		return filepath.Join(m.mountPoint, rel)
+	}
+	if len(subsystems) == 0 {
+		return filepath.Join(rootPath, cgroupPath)
+	}
+	for _, sub := range subsystems {
+		if dir := filepath.Join(rootPath, sub); fileExists(dir) {
+			return filepath.Join(dir, cgroupPath)
+		}
+	}
+	return filepath.Join(rootPath, subsystems[0], cgroupPath)
+}
+
+// checkFilesExist returns the error if any of the files doesn't exist.
+func checkFilesExist(paths ...string) error {
+	for _, p := range paths {
+		if !fileExists(p) {
+			return errors.Errorf("cgroup file %s doesn't exist", p)
+		}
+	}
+	return nil
+}
+
+// cgroupCpuSampler computes the CPU usage ratio against the CPU quota between two samples.
+type cgroupCpuSampler struct {
+	reader        cgroupReader
+	mux           sync.Mutex
+	prevUsageNano uint64
+	prevTime      time.Time
+}
+
+// sample returns the CPU usage ratio since the last sample in [0.0, 1.0], the first sample returns 0.
+func (s *cgroupCpuSampler) sample(now time.Time) (float64, error) {
+	usage, err := s.reader.CpuUsageNanos()
+	if err != nil {
+		return 0, err
+	}
+	cores, err := s.reader.CpuLimitCores()
+	if err != nil {
+		return 0, err
+	}
+	if cores <= 0 {
+		cores = float64(runtime.NumCPU())
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	prevUsage, prevTime := s.prevUsageNano, s.prevTime
+	s.prevUsageNano, s.prevTime = usage, now
+	if prevTime.IsZero() || !now.After(prevTime) || usage < prevUsage {
+		return 0, nil
+	}
+	ratio := float64(usage-prevUsage) / (float64(now.Sub(prevTime).Nanoseconds()) * cores)
+	return math.Min(ratio, 1.0), nil
+}
+
+type cgroupV1Reader struct {
+	cpuDir     string
+	cpuacctDir string
+	memoryDir  string
+}
+
+// newCgroupV1Reader resolves the cgroup v1 directories of current process,
+// returns the error if the CPU or memory statistic files are not accessible.
+func newCgroupV1Reader(rootPath string) (*cgroupV1Reader, error) {
+	pc := loadProcessCgroup()
+	r := &cgroupV1Reader{
+		cpuDir:     pc.dirOf("cpu", rootPath, "cpu", "cpu,cpuacct", "cpuacct,cpu"),
+		cpuacctDir: pc.dirOf("cpuacct", rootPath, "cpuacct", "cpu,cpuacct", "cpuacct,cpu"),
+		memoryDir:  pc.dirOf("memory", rootPath, "memory"),
+	}
+	if err := checkFilesExist(filepath.Join(r.cpuacctDir, "cpuacct.usage"),
+		filepath.Join(r.memoryDir, "memory.usage_in_bytes")); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *cgroupV1Reader) Version() string {
+	return CgroupModeV1
+}
+
+func (r *cgroupV1Reader) CpuUsageNanos() (uint64, error) {
+	return readUint(filepath.Join(r.cpuacctDir, "cpuacct.usage"))
+}
+
+func (r *cgroupV1Reader) CpuLimitCores() (float64, error) {
+	quota, err := readInt(filepath.Join(r.cpuDir, "cpu.cfs_quota_us"))
+	if err != nil {
+		return 0, err
+	}
+	if quota <= 0 {
+		return 0, nil
+	}
+	period, err := readInt(filepath.Join(r.cpuDir, "cpu.cfs_period_us"))
+	if err != nil {
+		return 0, err
+	}
+	if period <= 0 {
+		return 0, nil
+	}
+	return float64(quota) / float64(period), nil
+}
+
+func (r *cgroupV1Reader) MemoryUsageBytes() (int64, error) {
+	usage, err := readInt(filepath.Join(r.memoryDir, "memory.usage_in_bytes"))
+	if err != nil {
+		return 0, err
+	}
+	inactive, err := readStatValue(filepath.Join(r.memoryDir, "memory.stat"), "total_inactive_file")
+	if err != nil {
+		return usage, nil
+	}
+	return workingSet(usage, inactive), nil
+}
+
+func (r *cgroupV1Reader) MemoryLimitBytes() (int64, error) {
+	limit, err := readInt(filepath.Join(r.memoryDir, "memory.limit_in_bytes"))
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 || limit >= cgroupV1UnlimitedMemory {
+		return 0, nil
+	}
+	return limit, nil
+}
+
+type cgroupV2Reader struct {
+	dir string
+}
+
+// newCgroupV2Reader resolves the cgroup v2 directory of current process,
+// returns the error if the CPU or memory statistic files are not accessible.
+func newCgroupV2Reader(rootPath string) (*cgroupV2Reader, error) {
+	r := &cgroupV2Reader{dir: loadProcessCgroup().dirOf("", rootPath)}
+	if err := checkFilesExist(filepath.Join(r.dir, "cpu.stat"), filepath.Join(r.dir, "memory.current")); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *cgroupV2Reader) Version() string {
+	return CgroupModeV2
+}
+
+func (r *cgroupV2Reader) CpuUsageNanos() (uint64, error) {
+	usec, err := readStatValue(filepath.Join(r.dir, "cpu.stat"), "usage_usec")
+	if err != nil {
+		return 0, err
+	}
+	return uint64(usec) * uint64(time.Microsecond), nil
+}
+
+// CpuLimitCores parses cpu.max, whose format is "$MAX $PERIOD" and $MAX could be "max".
+// The missing cpu.max means the cpu controller isn't enabled, so there's no limitation.
+func (r *cgroupV2Reader) CpuLimitCores() (float64, error) {
+	content, err := readTrimmed(filepath.Join(r.dir, "cpu.max"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	fields := strings.Fields(content)
+	if len(fields) == 0 || fields[0] == "max" {
+		return 0, nil
+	}
+	quota, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid cpu.max: %s", content)
+	}
+	period := int64(100000)
+	if len(fields) > 1 {
+		if period, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+			return 0, errors.Wrapf(err, "invalid cpu.max: %s", content)
+		}
+	}
+	if quota <= 0 || period <= 0 {
+		return 0, nil
+	}
+	return float64(quota) / float64(period), nil
+}
+
+func (r *cgroupV2Reader) MemoryUsageBytes() (int64, error) {
+	usage, err := readInt(filepath.Join(r.dir, "memory.current"))
+	if err != nil {
+		return 0, err
+	}
+	inactive, err := readStatValue(filepath.Join(r.dir, "memory.stat"), "inactive_file")
+	if err != nil {
+		return usage, nil
+	}
+	return workingSet(usage, inactive), nil
+}
+
+func (r *cgroupV2Reader) MemoryLimitBytes() (int64, error) {
+	content, err := readTrimmed(filepath.Join(r.dir, "memory.max"))
+	if err != nil {
+		return 0, err
+	}
+	if content == "max" {
+		return 0, nil
+	}
+	limit, err := strconv.ParseInt(content, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid memory.max: %s", content)
+	}
+	return limit, nil
+}
+
+func workingSet(usage, inactive int64) int64 {
+	if inactive > usage {
+		return 0
+	}
+	return usage - inactive
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func readTrimmed(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func readInt(path string) (int64, error) {
+	content, err := readTrimmed(path)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseInt(content, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid content of %s", path)
+	}
+	return v, nil
+}
+
+func readUint(path string) (uint64, error) {
+	content, err := readTrimmed(path)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(content, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid content of %s", path)
+	}
+	return v, nil
+}
+
+// readStatValue reads the value of the key from the flat keyed file, e.g. memory.stat and cpu.stat.
+func readStatValue(path string, key string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != key {
+			continue
+		}
+		v, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid value of %s in %s", key, path)
+		}
+		return v, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.Errorf("%s not found in %s", key, path)
+}
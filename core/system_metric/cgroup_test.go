@@ -0,0 +1,256 @@
+package system_metric
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeCgroupFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newFakeCgroupRoot(t *testing.T, files map[string]string) string {
+	root, err := ioutil.TempDir("", "sentinel-cgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeCgroupFiles(t, root, files)
+	return root
+}
+
+// useFakeProcFiles points the /proc/self files to the given contents, the empty content means the file is missing.
+func useFakeProcFiles(t *testing.T, cgroup string, mountinfo string) func() {
+	dir, err := ioutil.TempDir("", "sentinel-proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	if len(cgroup) > 0 {
+		files["cgroup"] = cgroup
+	}
+	if len(mountinfo) > 0 {
+		files["mountinfo"] = mountinfo
+	}
+	writeCgroupFiles(t, dir, files)
+	cgroupPath, mountinfoPath := procSelfCgroupPath, procSelfMountinfoPath
+	procSelfCgroupPath, procSelfMountinfoPath = filepath.Join(dir, "cgroup"), filepath.Join(dir, "mountinfo")
+	return func() {
+		procSelfCgroupPath, procSelfMountinfoPath = cgroupPath, mountinfoPath
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCgroupV1Reader(t *testing.T) {
+	defer useFakeProcFiles(t, "", "")()
+	root := newFakeCgroupRoot(t, map[string]string{
+		"cpu,cpuacct/cpuacct.usage":     "1000000000\n",
+		"cpu,cpuacct/cpu.cfs_quota_us":  "200000\n",
+		"cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+		"memory/memory.usage_in_bytes":  "524288000\n",
+		"memory/memory.limit_in_bytes":  "1073741824\n",
+		"memory/memory.stat":            "cache 1024\ntotal_inactive_file 104857600\n",
+	})
+	defer os.RemoveAll(root)
+
+	r := detectCgroupReader(root)
+	assert.Equal(t, CgroupModeV1, r.Version())
+	usage, err := r.CpuUsageNanos()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1000000000), usage)
+	cores, err := r.CpuLimitCores()
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, cores)
+	mem, err := r.MemoryUsageBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(524288000-104857600), mem)
+	limit, err := r.MemoryLimitBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1073741824), limit)
+
+	// No limitation.
+	writeCgroupFiles(t, root, map[string]string{
+		"cpu,cpuacct/cpu.cfs_quota_us": "-1\n",
+		"memory/memory.limit_in_bytes": "9223372036854771712\n",
+	})
+	cores, err = r.CpuLimitCores()
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, cores)
+	limit, err = r.MemoryLimitBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), limit)
+}
+
+func TestCgroupV2Reader(t *testing.T) {
+	defer useFakeProcFiles(t, "", "")()
+	root := newFakeCgroupRoot(t, map[string]string{
+		"cgroup.controllers": "cpu memory\n",
+		"cpu.stat":           "usage_usec 2000000\nuser_usec 1500000\n",
+		"cpu.max":            "50000 100000\n",
+		"memory.current":     "268435456\n",
+		"memory.max":         "max\n",
+		"memory.stat":        "anon 1024\ninactive_file 67108864\n",
+	})
+	defer os.RemoveAll(root)
+
+	r := detectCgroupReader(root)
+	assert.Equal(t, CgroupModeV2, r.Version())
+	usage, err := r.CpuUsageNanos()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2000000000), usage)
+	cores, err := r.CpuLimitCores()
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, cores)
+	mem, err := r.MemoryUsageBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(268435456-67108864), mem)
+	limit, err := r.MemoryLimitBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), limit)
+
+	// The CPU usage is computed against the quota.
+	sampler := &cgroupCpuSampler{reader: r}
+	now := time.Now()
+	ratio, err := sampler.sample(now)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, ratio)
+	writeCgroupFiles(t, root, map[string]string{"cpu.stat": "usage_usec 2250000\n"})
+	ratio, err = sampler.sample(now.Add(time.Second))
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.5, ratio, 1e-9)
+}
+
+func TestInitCgroupReader(t *testing.T) {
+	defer useFakeProcFiles(t, "", "")()
+	defer setCgroupReader(nil)
+	totalMemorySize := TotalMemorySize
+	defer func() {
+		TotalMemorySize = totalMemorySize
+	}()
+
+	root := newFakeCgroupRoot(t, map[string]string{
+		"cgroup.controllers": "cpu memory\n",
+		"cpu.stat":           "usage_usec 1000\n",
+		"memory.current":     "1024\n",
+		"memory.max":         "4096\n",
+	})
+	defer os.RemoveAll(root)
+
+	assert.Nil(t, InitCgroupReader(CgroupModeAuto, root))
+	assert.Equal(t, CgroupModeV2, CurrentCgroupVersion())
+	assert.Equal(t, uint64(4096), TotalMemorySize)
+	mem, err := getMemoryStat()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1024), mem)
+
+	// Nothing detected from the empty root.
+	emptyRoot := newFakeCgroupRoot(t, nil)
+	defer os.RemoveAll(emptyRoot)
+	assert.Nil(t, InitCgroupReader(CgroupModeAuto, emptyRoot))
+	assert.Equal(t, "", CurrentCgroupVersion())
+
+	// The cgroup v1 files are missing, so the process metrics are used.
+	assert.Nil(t, InitCgroupReader(CgroupModeV1, root))
+	assert.Equal(t, "", CurrentCgroupVersion())
+	assert.Nil(t, InitCgroupReader(CgroupModeV2, root))
+	assert.Equal(t, CgroupModeV2, CurrentCgroupVersion())
+	assert.Nil(t, InitCgroupReader(CgroupModeDisabled, root))
+	assert.Equal(t, "", CurrentCgroupVersion())
+	assert.Nil(t, InitCgroupReader("", root))
+	assert.Equal(t, "", CurrentCgroupVersion())
+	assert.NotNil(t, InitCgroupReader("v3", root))
+}
+
+func TestCgroupReaderOfProcessCgroup(t *testing.T) {
+	t.Run("v1 without cgroup namespace", func(t *testing.T) {
+		root := newFakeCgroupRoot(t, map[string]string{
+			"memory/memory.usage_in_bytes":          "999\n",
+			"memory/app/pod1/memory.usage_in_bytes": "2048\n",
+			"memory/app/pod1/memory.limit_in_bytes": "8192\n",
+			"cpuacct/app/pod1/cpuacct.usage":        "1000\n",
+			"cpu/app/pod1/cpu.cfs_quota_us":         "50000\n",
+			"cpu/app/pod1/cpu.cfs_period_us":        "100000\n",
+		})
+		defer os.RemoveAll(root)
+		defer useFakeProcFiles(t, "4:memory:/app/pod1\n2:cpuacct:/app/pod1\n1:cpu:/app/pod1\n0::/\n",
+			"33 32 0:29 / "+root+"/cpu rw,relatime - cgroup cgroup rw,cpu\n"+
+				"34 32 0:30 / "+root+"/cpuacct rw,relatime - cgroup cgroup rw,cpuacct\n"+
+				"36 32 0:32 / "+root+"/memory rw,relatime - cgroup cgroup rw,memory\n"+
+				"42 32 0:38 / "+root+"/unified rw,relatime - cgroup2 cgroup2 rw\n")()
+
+		// The unified hierarchy has no statistic files, so cgroup v1 is detected.
+		r := detectCgroupReader(DefaultCgroupRootPath)
+		assert.Equal(t, CgroupModeV1, r.Version())
+		mem, err := r.MemoryUsageBytes()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2048), mem)
+		limit, err := r.MemoryLimitBytes()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(8192), limit)
+		cores, err := r.CpuLimitCores()
+		assert.Nil(t, err)
+		assert.Equal(t, 0.5, cores)
+	})
+
+	t.Run("v1 mounted from the cgroup of container", func(t *testing.T) {
+		root := newFakeCgroupRoot(t, map[string]string{
+			"cpu,cpuacct/cpuacct.usage":    "1000\n",
+			"memory/memory.usage_in_bytes": "4096\n",
+		})
+		defer os.RemoveAll(root)
+		defer useFakeProcFiles(t, "4:memory:/docker/abc\n3:cpu,cpuacct:/docker/abc\n",
+			"33 32 0:29 /docker/abc "+root+"/cpu,cpuacct ro - cgroup cgroup rw,cpu,cpuacct\n"+
+				"36 32 0:32 /docker/abc "+root+"/memory ro - cgroup cgroup rw,memory\n")()
+
+		r := detectCgroupReader(DefaultCgroupRootPath)
+		assert.Equal(t, CgroupModeV1, r.Version())
+		mem, err := r.MemoryUsageBytes()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(4096), mem)
+		usage, err := r.CpuUsageNanos()
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(1000), usage)
+	})
+
+	t.Run("v2 without cgroup namespace", func(t *testing.T) {
+		root := newFakeCgroupRoot(t, map[string]string{
+			"cgroup.controllers":                      "cpu memory\n",
+			"system.slice/app.service/cpu.stat":       "usage_usec 100\n",
+			"system.slice/app.service/memory.current": "1024\n",
+			"system.slice/app.service/memory.max":     "max\n",
+		})
+		defer os.RemoveAll(root)
+		defer useFakeProcFiles(t, "0::/system.slice/app.service\n",
+			"30 24 0:26 / "+root+" rw,nosuid - cgroup2 cgroup2 rw\n")()
+
+		r := detectCgroupReader(DefaultCgroupRootPath)
+		assert.Equal(t, CgroupModeV2, r.Version())
+		mem, err := r.MemoryUsageBytes()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1024), mem)
+		// The cpu controller isn't enabled for the cgroup.
+		cores, err := r.CpuLimitCores()
+		assert.Nil(t, err)
+		assert.Equal(t, 0.0, cores)
+	})
+
+	t.Run("statistic files missing", func(t *testing.T) {
+		root := newFakeCgroupRoot(t, map[string]string{"cgroup.controllers": "cpu memory\n"})
+		defer os.RemoveAll(root)
+		defer useFakeProcFiles(t, "0::/user.slice\n", "30 24 0:26 / "+root+" rw - cgroup2 cgroup2 rw\n")()
+
+		assert.Nil(t, detectCgroupReader(root))
+	})
+}
@@ -0,0 +1,187 @@
+package system_metric
+
+import (
+	"runtime"
+	"sync"
+
+	metric_exporter "github.com/alibaba/sentinel-golang/exporter/metric"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/pkg/errors"
+)
+
+// The names of the built-in signals.
+const (
+	SignalLoad          = "load"
+	SignalCpuUsage      = "cpuUsage"
+	SignalMemoryUsage   = "memoryUsage"
+	SignalGoroutines    = "goroutines"
+	SignalHeapInUse     = "heapInUse"
+	SignalGcPause       = "gcPause"
+	SignalGcCpuFraction = "gcCpuFraction"
+	SignalSchedLatency  = "schedLatency"
+)
+
+// MetricProvider provides the value of a system signal, e.g. from a sidecar, a node exporter file or the application itself.
+// The provider is invoked by the collector of the signal periodically rather than on each request, so it could be slow.
+type MetricProvider interface {
+	Value() (float64, error)
+}
+
+// MetricProviderFunc is the adapter to use the ordinary function as MetricProvider.
+type MetricProviderFunc func() (float64, error)
+
+func (f MetricProviderFunc) Value() (float64, error) {
+	return f()
+}
+
+var (
+	// defaultProviders are the built-in collectors of the built-in signals.
+	defaultProviders = map[string]MetricProvider{
+		SignalLoad:     MetricProviderFunc(getLoadStat),
+		SignalCpuUsage: MetricProviderFunc(getCpuStat),
+		SignalMemoryUsage: MetricProviderFunc(func() (float64, error) {
+			v, err := getMemoryStat()
+			return float64(v), err
+		}),
+		SignalGoroutines: MetricProviderFunc(func() (float64, error) {
+			return float64(runtime.NumGoroutine()), nil
+		}),
+		SignalHeapInUse: MetricProviderFunc(func() (float64, error) {
+			return float64(runtimeReader.heapInUse()), nil
+		}),
+		SignalGcPause: MetricProviderFunc(func() (float64, error) {
+			return runtimeReader.gcPauseMs(), nil
+		}),
+		SignalGcCpuFraction: MetricProviderFunc(func() (float64, error) {
+			return runtimeReader.gcCpuFraction(), nil
+		}),
+		SignalSchedLatency: MetricProviderFunc(func() (float64, error) {
+			return runtimeReader.schedLatencyMs(), nil
+		}),
+	}
+
+	providerMux = new(sync.RWMutex)
+	// providers are the providers registered by the users, which take precedence over the default providers.
+	providers = make(map[string]MetricProvider)
+
+	// customSignals caches the values of the custom signals, the key is the signal name and the value is float64.
+	customSignals             = new(sync.Map)
+	customSignalCollectorOnce sync.Once
+
+	customSignalGauge = metric_exporter.NewGauge(
+		"custom_signal",
+		"Value of the custom system signal",
+		[]string{"signal"})
+)
+
+func init() {
+	metric_exporter.Register(customSignalGauge)
+}
+
+func isBuiltInSignal(signal string) bool {
+	_, ok := defaultProviders[signal]
+	return ok
+}
+
+// RegisterMetricProvider registers the provider of the signal. The provider of the built-in signal replaces
+// the default collector, and the provider of the other name defines a custom signal, which is collected by
+// the custom signal collector and could be referenced by the system rules and the adaptive calculators.
+func RegisterMetricProvider(signal string, provider MetricProvider) error {
+	if len(signal) == 0 {
+		return errors.New("empty signal name")
+	}
+	if provider == nil {
+		return errors.New("nil metric provider")
+	}
+	providerMux.Lock()
+	providers[signal] = provider
+	providerMux.Unlock()
+	logging.Info("[SystemMetric] Metric provider is registered", "signal", signal)
+	return nil
+}
+
+// RemoveMetricProvider removes the registered provider of the signal, the built-in signal falls back to the default collector.
+func RemoveMetricProvider(signal string) {
+	providerMux.Lock()
+	delete(providers, signal)
+	providerMux.Unlock()
+	if !isBuiltInSignal(signal) {
+		customSignals.Delete(signal)
+	}
+}
+
+func providerOf(signal string) MetricProvider {
+	providerMux.RLock()
+	defer providerMux.RUnlock()
+	if p, ok := providers[signal]; ok {
+		return p
+	}
+	return defaultProviders[signal]
+}
+
+// InitCustomSignalCollector starts the collector of all the custom signals.
+func InitCustomSignalCollector(intervalMs uint32) {
+	startCollector(&customSignalCollectorOnce, intervalMs, retrieveAndUpdateCustomSignals)
+}
+
+func retrieveAndUpdateCustomSignals() {
+	providerMux.RLock()
+	custom := make(map[string]MetricProvider, len(providers))
+	for signal, p := range providers {
+		if !isBuiltInSignal(signal) {
+			custom[signal] = p
+		}
+	}
+	providerMux.RUnlock()
+
+	for signal, p := range custom {
+		v, err := p.Value()
+		if err != nil {
+			logging.Error(err, "Fail to retrieve and update custom signal", "signal", signal)
+			continue
+		}
+		customSignalGauge.Set(v, signal)
+		customSignals.Store(signal, v)
+	}
+}
+
+// CurrentSignal returns the current value of the signal, both built-in and custom signals are supported.
+// The returned bool is false if the signal is not retrieved yet.
+func CurrentSignal(signal string) (float64, bool) {
+	switch signal {
+	case SignalLoad:
+		v := CurrentLoad()
+		return v, v != NotRetrievedLoadValue
+	case SignalCpuUsage:
+		v := CurrentCpuUsage()
+		return v, v != NotRetrievedCpuUsageValue
+	case SignalMemoryUsage:
+		v := CurrentMemoryUsage()
+		return float64(v), v != NotRetrievedMemoryValue
+	case SignalGoroutines:
+		v := CurrentGoroutines()
+		return float64(v), v != NotRetrievedGoroutinesValue
+	case SignalHeapInUse:
+		v := CurrentHeapInUse()
+		return float64(v), v != NotRetrievedHeapInUseValue
+	case SignalGcPause:
+		v := CurrentGcPause()
+		return v, v != NotRetrievedGcPauseValue
+	case SignalGcCpuFraction:
+		v := CurrentGcCpuFraction()
+		return v, v != NotRetrievedGcCpuFractionValue
+	case SignalSchedLatency:
+		v := CurrentSchedLatency()
+		return v, v != NotRetrievedSchedLatencyValue
+	}
+	v, ok := customSignals.Load(signal)
+	if !ok {
+		return 0, false
+	}
+	return v.(float64), true
+}
+
+// SetCustomSignal is used for unit test, the user shouldn't call this function.
+func SetCustomSignal(signal string, v float64) {
+	customSignals.Store(signal, v)
+}
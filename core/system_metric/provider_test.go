@@ -0,0 +1,76 @@
+package system_metric
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterMetricProvider(t *testing.T) {
+	defer currentLoad.Store(NotRetrievedLoadValue)
+	defer currentMemoryUsage.Store(NotRetrievedMemoryValue)
+
+	assert.NotNil(t, RegisterMetricProvider("", MetricProviderFunc(func() (float64, error) {
+		return 0, nil
+	})))
+	assert.NotNil(t, RegisterMetricProvider(SignalLoad, nil))
+
+	// The registered provider replaces the default collector.
+	assert.Nil(t, RegisterMetricProvider(SignalLoad, MetricProviderFunc(func() (float64, error) {
+		return 3.5, nil
+	})))
+	retrieveAndUpdateLoadStat()
+	assert.Equal(t, 3.5, CurrentLoad())
+	v, ok := CurrentSignal(SignalLoad)
+	assert.True(t, ok)
+	assert.Equal(t, 3.5, v)
+
+	// The value is kept if the provider fails.
+	assert.Nil(t, RegisterMetricProvider(SignalLoad, MetricProviderFunc(func() (float64, error) {
+		return 0, errors.New("sidecar unavailable")
+	})))
+	retrieveAndUpdateLoadStat()
+	assert.Equal(t, 3.5, CurrentLoad())
+
+	// The default collector is used again after the provider is removed.
+	RemoveMetricProvider(SignalLoad)
+	providerMux.RLock()
+	_, ok = providers[SignalLoad]
+	providerMux.RUnlock()
+	assert.False(t, ok)
+	assert.NotNil(t, providerOf(SignalLoad))
+
+	assert.Nil(t, RegisterMetricProvider(SignalMemoryUsage, MetricProviderFunc(func() (float64, error) {
+		return 1024, nil
+	})))
+	defer RemoveMetricProvider(SignalMemoryUsage)
+	retrieveAndUpdateMemoryStat()
+	assert.Equal(t, int64(1024), CurrentMemoryUsage())
+}
+
+func TestCustomSignal(t *testing.T) {
+	signal := "queue_depth"
+	defer RemoveMetricProvider(signal)
+
+	_, ok := CurrentSignal(signal)
+	assert.False(t, ok)
+
+	depth := 10.0
+	assert.Nil(t, RegisterMetricProvider(signal, MetricProviderFunc(func() (float64, error) {
+		return depth, nil
+	})))
+	retrieveAndUpdateCustomSignals()
+	v, ok := CurrentSignal(signal)
+	assert.True(t, ok)
+	assert.Equal(t, 10.0, v)
+
+	depth = 20
+	retrieveAndUpdateCustomSignals()
+	v, _ = CurrentSignal(signal)
+	assert.Equal(t, 20.0, v)
+
+	RemoveMetricProvider(signal)
+	_, ok = CurrentSignal(signal)
+	assert.False(t, ok)
+}
@@ -0,0 +1,227 @@
+package system_metric
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	metric_exporter "github.com/alibaba/sentinel-golang/exporter/metric"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+)
+
+const (
+	NotRetrievedGoroutinesValue    int64   = -1
+	NotRetrievedHeapInUseValue     int64   = -1
+	NotRetrievedGcPauseValue       float64 = -1.0
+	NotRetrievedGcCpuFractionValue float64 = -1.0
+	NotRetrievedSchedLatencyValue  float64 = -1.0
+
+	// runtimeLatencyPercentile is the percentile of the GC pause and scheduler latency within the collect interval.
+	runtimeLatencyPercentile = 0.99
+)
+
+var (
+	currentGoroutines    atomic.Value
+	currentHeapInUse     atomic.Value
+	currentGcPause       atomic.Value
+	currentGcCpuFraction atomic.Value
+	currentSchedLatency  atomic.Value
+
+	goroutinesCollectorOnce   sync.Once
+	heapInUseCollectorOnce    sync.Once
+	gcCollectorOnce           sync.Once
+	schedLatencyCollectorOnce sync.Once
+
+	runtimeReader = newRuntimeStatReader()
+
+	goroutinesGauge = metric_exporter.NewGauge(
+		"runtime_goroutines",
+		"Amount of goroutines",
+		[]string{})
+	heapInUseGauge = metric_exporter.NewGauge(
+		"runtime_heap_inuse_bytes",
+		"Heap memory in use in bytes",
+		[]string{})
+	gcPauseGauge = metric_exporter.NewGauge(
+		"runtime_gc_pause_ms",
+		"P99 of GC stop-the-world pause within the collect interval in milliseconds",
+		[]string{})
+	gcCpuFractionGauge = metric_exporter.NewGauge(
+		"runtime_gc_cpu_fraction",
+		"Fraction of CPU time used by GC within the collect interval",
+		[]string{})
+	schedLatencyGauge = metric_exporter.NewGauge(
+		"runtime_sched_latency_ms",
+		"P99 of the time goroutines spend runnable before running within the collect interval in milliseconds",
+		[]string{})
+)
+
+func init() {
+	currentGoroutines.Store(NotRetrievedGoroutinesValue)
+	currentHeapInUse.Store(NotRetrievedHeapInUseValue)
+	currentGcPause.Store(NotRetrievedGcPauseValue)
+	currentGcCpuFraction.Store(NotRetrievedGcCpuFractionValue)
+	currentSchedLatency.Store(NotRetrievedSchedLatencyValue)
+
+	metric_exporter.Register(goroutinesGauge)
+	metric_exporter.Register(heapInUseGauge)
+	metric_exporter.Register(gcPauseGauge)
+	metric_exporter.Register(gcCpuFractionGauge)
+	metric_exporter.Register(schedLatencyGauge)
+}
+
+// startCollector retrieves the statistic once and then periodically until the system metric collectors are stopped.
+func startCollector(once *sync.Once, intervalMs uint32, retrieve func()) {
+	if intervalMs == 0 {
+		return
+	}
+	once.Do(func() {
+		retrieve()
+
+		ticker := util.NewTicker(time.Duration(intervalMs) * time.Millisecond)
+		go util.RunWithRecover(func() {
+			for {
+				select {
+				case <-ticker.C():
+					retrieve()
+				case <-ssStopChan:
+					ticker.Stop()
+					return
+				}
+			}
+		})
+	})
+}
+
+func InitGoroutinesCollector(intervalMs uint32) {
+	startCollector(&goroutinesCollectorOnce, intervalMs, retrieveAndUpdateGoroutinesStat)
+}
+
+func InitHeapInUseCollector(intervalMs uint32) {
+	startCollector(&heapInUseCollectorOnce, intervalMs, retrieveAndUpdateHeapInUseStat)
+}
+
+// InitGcCollector starts the collector of both GC pause and GC CPU fraction.
+func InitGcCollector(intervalMs uint32) {
+	startCollector(&gcCollectorOnce, intervalMs, retrieveAndUpdateGcStat)
+}
+
+func InitSchedLatencyCollector(intervalMs uint32) {
+	startCollector(&schedLatencyCollectorOnce, intervalMs, retrieveAndUpdateSchedLatencyStat)
+}
+
+// retrieveSignal retrieves the value of the built-in signal from its provider, the error is logged.
+func retrieveSignal(signal string) (float64, bool) {
+	v, err := providerOf(signal).Value()
+	if err != nil {
+		logging.Error(err, "Fail to retrieve and update runtime statistic", "signal", signal)
+		return 0, false
+	}
+	return v, true
+}
+
+func retrieveAndUpdateGoroutinesStat() {
+	v, ok := retrieveSignal(SignalGoroutines)
+	if !ok {
+		return
+	}
+	goroutinesGauge.Set(v)
+	currentGoroutines.Store(int64(v))
+}
+
+func retrieveAndUpdateHeapInUseStat() {
+	v, ok := retrieveSignal(SignalHeapInUse)
+	if !ok {
+		return
+	}
+	heapInUseGauge.Set(v)
+	currentHeapInUse.Store(int64(v))
+}
+
+func retrieveAndUpdateGcStat() {
+	if pause, ok := retrieveSignal(SignalGcPause); ok && pause != NotRetrievedGcPauseValue {
+		gcPauseGauge.Set(pause)
+		currentGcPause.Store(pause)
+	}
+	if fraction, ok := retrieveSignal(SignalGcCpuFraction); ok && fraction != NotRetrievedGcCpuFractionValue {
+		gcCpuFractionGauge.Set(fraction)
+		currentGcCpuFraction.Store(fraction)
+	}
+}
+
+func retrieveAndUpdateSchedLatencyStat() {
+	if latency, ok := retrieveSignal(SignalSchedLatency); ok && latency != NotRetrievedSchedLatencyValue {
+		schedLatencyGauge.Set(latency)
+		currentSchedLatency.Store(latency)
+	}
+}
+
+func CurrentGoroutines() int64 {
+	r, ok := currentGoroutines.Load().(int64)
+	if !ok {
+		return NotRetrievedGoroutinesValue
+	}
+	return r
+}
+
+// SetGoroutines is used for unit test, the user shouldn't call this function.
+func SetGoroutines(n int64) {
+	currentGoroutines.Store(n)
+}
+
+// CurrentHeapInUse returns the bytes of heap spans in use.
+func CurrentHeapInUse() int64 {
+	r, ok := currentHeapInUse.Load().(int64)
+	if !ok {
+		return NotRetrievedHeapInUseValue
+	}
+	return r
+}
+
+// SetHeapInUse is used for unit test, the user shouldn't call this function.
+func SetHeapInUse(bytes int64) {
+	currentHeapInUse.Store(bytes)
+}
+
+// CurrentGcPause returns the P99 of GC pause within the last collect interval in milliseconds.
+func CurrentGcPause() float64 {
+	r, ok := currentGcPause.Load().(float64)
+	if !ok {
+		return NotRetrievedGcPauseValue
+	}
+	return r
+}
+
+// SetGcPause is used for unit test, the user shouldn't call this function.
+func SetGcPause(pauseMs float64) {
+	currentGcPause.Store(pauseMs)
+}
+
+// CurrentGcCpuFraction returns the fraction of CPU time used by GC, in [0.0, 1.0].
+func CurrentGcCpuFraction() float64 {
+	r, ok := currentGcCpuFraction.Load().(float64)
+	if !ok {
+		return NotRetrievedGcCpuFractionValue
+	}
+	return r
+}
+
+// SetGcCpuFraction is used for unit test, the user shouldn't call this function.
+func SetGcCpuFraction(fraction float64) {
+	currentGcCpuFraction.Store(fraction)
+}
+
+// CurrentSchedLatency returns the P99 of scheduler latency within the last collect interval in milliseconds.
+func CurrentSchedLatency() float64 {
+	r, ok := currentSchedLatency.Load().(float64)
+	if !ok {
+		return NotRetrievedSchedLatencyValue
+	}
+	return r
+}
+
+// SetSchedLatency is used for unit test, the user shouldn't call this function.
+func SetSchedLatency(latencyMs float64) {
+	currentSchedLatency.Store(latencyMs)
+}
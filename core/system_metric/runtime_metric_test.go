@@ -0,0 +1,29 @@
+package system_metric
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetrieveAndUpdateRuntimeStat(t *testing.T) {
+	defer func() {
+		currentGoroutines.Store(NotRetrievedGoroutinesValue)
+		currentHeapInUse.Store(NotRetrievedHeapInUseValue)
+		currentGcPause.Store(NotRetrievedGcPauseValue)
+		currentGcCpuFraction.Store(NotRetrievedGcCpuFractionValue)
+	}()
+	assert.Equal(t, NotRetrievedGoroutinesValue, CurrentGoroutines())
+	assert.Equal(t, NotRetrievedHeapInUseValue, CurrentHeapInUse())
+
+	retrieveAndUpdateGoroutinesStat()
+	assert.True(t, CurrentGoroutines() > 0)
+	retrieveAndUpdateHeapInUseStat()
+	assert.True(t, CurrentHeapInUse() > 0)
+	retrieveAndUpdateGcStat()
+	assert.True(t, CurrentGcPause() >= 0)
+	assert.True(t, CurrentGcCpuFraction() >= 0)
+
+	SetGcPause(12.5)
+	assert.Equal(t, 12.5, CurrentGcPause())
+}
@@ -0,0 +1,143 @@
+//go:build go1.17
+// +build go1.17
+
+package system_metric
+
+import (
+	"math"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+)
+
+const (
+	heapObjectsMetric   = "/memory/classes/heap/objects:bytes"
+	heapUnusedMetric    = "/memory/classes/heap/unused:bytes"
+	gcCpuMetric         = "/cpu/classes/gc/total:cpu-seconds"
+	totalCpuMetric      = "/cpu/classes/total:cpu-seconds"
+	schedLatencyMetric  = "/sched/latencies:seconds"
+	gcPauseMetric       = "/sched/pauses/total/gc:seconds"
+	legacyGcPauseMetric = "/gc/pauses:seconds"
+)
+
+// runtimeStatReader reads the runtime signals from runtime/metrics, the histograms and the cumulative
+// values are computed as the delta since the last read.
+type runtimeStatReader struct {
+	mux       sync.Mutex
+	supported map[string]bool
+	gcPause   string
+
+	prevGcPause      *metrics.Float64Histogram
+	prevSchedLatency *metrics.Float64Histogram
+	prevGcCpu        float64
+	prevTotalCpu     float64
+}
+
+func newRuntimeStatReader() *runtimeStatReader {
+	r := &runtimeStatReader{supported: make(map[string]bool)}
+	for _, d := range metrics.All() {
+		r.supported[d.Name] = true
+	}
+	// The GC pause metric is renamed since go1.22.
+	if r.supported[gcPauseMetric] {
+		r.gcPause = gcPauseMetric
+	} else if r.supported[legacyGcPauseMetric] {
+		r.gcPause = legacyGcPauseMetric
+	}
+	return r
+}
+
+func (r *runtimeStatReader) read(names ...string) []metrics.Sample {
+	samples := make([]metrics.Sample, len(names))
+	for i, name := range names {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+	return samples
+}
+
+func (r *runtimeStatReader) heapInUse() int64 {
+	if !r.supported[heapObjectsMetric] || !r.supported[heapUnusedMetric] {
+		ms := &runtime.MemStats{}
+		runtime.ReadMemStats(ms)
+		return int64(ms.HeapInuse)
+	}
+	samples := r.read(heapObjectsMetric, heapUnusedMetric)
+	return int64(samples[0].Value.Uint64() + samples[1].Value.Uint64())
+}
+
+func (r *runtimeStatReader) gcPauseMs() float64 {
+	if len(r.gcPause) == 0 {
+		return NotRetrievedGcPauseValue
+	}
+	h := r.read(r.gcPause)[0].Value.Float64Histogram()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	p := histogramDeltaPercentile(r.prevGcPause, h, runtimeLatencyPercentile)
+	r.prevGcPause = h
+	return p * 1000
+}
+
+func (r *runtimeStatReader) gcCpuFraction() float64 {
+	if !r.supported[gcCpuMetric] || !r.supported[totalCpuMetric] {
+		ms := &runtime.MemStats{}
+		runtime.ReadMemStats(ms)
+		return ms.GCCPUFraction
+	}
+	samples := r.read(gcCpuMetric, totalCpuMetric)
+	gcCpu, totalCpu := samples[0].Value.Float64(), samples[1].Value.Float64()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	deltaGc, deltaTotal := gcCpu-r.prevGcCpu, totalCpu-r.prevTotalCpu
+	r.prevGcCpu, r.prevTotalCpu = gcCpu, totalCpu
+	if deltaTotal <= 0 || deltaGc < 0 {
+		return 0
+	}
+	return math.Min(deltaGc/deltaTotal, 1.0)
+}
+
+func (r *runtimeStatReader) schedLatencyMs() float64 {
+	if !r.supported[schedLatencyMetric] {
+		return NotRetrievedSchedLatencyValue
+	}
+	h := r.read(schedLatencyMetric)[0].Value.Float64Histogram()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	p := histogramDeltaPercentile(r.prevSchedLatency, h, runtimeLatencyPercentile)
+	r.prevSchedLatency = h
+	return p * 1000
+}
+
+// histogramDeltaPercentile returns the percentile of the samples recorded between prev and cur,
+// the upper bound of the bucket is used as the value. It returns 0 if there is no sample.
+func histogramDeltaPercentile(prev, cur *metrics.Float64Histogram, percentile float64) float64 {
+	if cur == nil {
+		return 0
+	}
+	deltas := make([]uint64, len(cur.Counts))
+	total := uint64(0)
+	for i, c := range cur.Counts {
+		if prev != nil && i < len(prev.Counts) && c >= prev.Counts[i] {
+			c -= prev.Counts[i]
+		}
+		deltas[i] = c
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(float64(total) * percentile))
+	seen := uint64(0)
+	for i, c := range deltas {
+		seen += c
+		if seen < rank {
+			continue
+		}
+		upper := cur.Buckets[i+1]
+		if math.IsInf(upper, 1) {
+			return cur.Buckets[i]
+		}
+		return upper
+	}
+	return cur.Buckets[len(cur.Buckets)-1]
+}
@@ -0,0 +1,43 @@
+//go:build !go1.17
+// +build !go1.17
+
+package system_metric
+
+import (
+	"runtime"
+)
+
+// runtimeStatReader reads the runtime signals from runtime.MemStats, since runtime/metrics is unavailable.
+// The scheduler latency is not supported.
+type runtimeStatReader struct {
+}
+
+func newRuntimeStatReader() *runtimeStatReader {
+	return &runtimeStatReader{}
+}
+
+func (r *runtimeStatReader) heapInUse() int64 {
+	ms := &runtime.MemStats{}
+	runtime.ReadMemStats(ms)
+	return int64(ms.HeapInuse)
+}
+
+func (r *runtimeStatReader) gcPauseMs() float64 {
+	ms := &runtime.MemStats{}
+	runtime.ReadMemStats(ms)
+	if ms.NumGC == 0 {
+		return 0
+	}
+	// The pause of the most recent GC.
+	return float64(ms.PauseNs[(ms.NumGC+255)%256]) / 1e6
+}
+
+func (r *runtimeStatReader) gcCpuFraction() float64 {
+	ms := &runtime.MemStats{}
+	runtime.ReadMemStats(ms)
+	return ms.GCCPUFraction
+}
+
+func (r *runtimeStatReader) schedLatencyMs() float64 {
+	return NotRetrievedSchedLatencyValue
+}
@@ -0,0 +1,39 @@
+//go:build go1.17
+// +build go1.17
+
+package system_metric
+
+import (
+	"math"
+	"runtime/metrics"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_histogramDeltaPercentile(t *testing.T) {
+	buckets := []float64{0, 0.001, 0.01, 0.1, math.Inf(1)}
+	prev := &metrics.Float64Histogram{Counts: []uint64{10, 0, 0, 0}, Buckets: buckets}
+	cur := &metrics.Float64Histogram{Counts: []uint64{10, 0, 0, 0}, Buckets: buckets}
+	// No new sample.
+	assert.Equal(t, 0.0, histogramDeltaPercentile(prev, cur, 0.99))
+
+	cur = &metrics.Float64Histogram{Counts: []uint64{108, 1, 1, 0}, Buckets: buckets}
+	assert.Equal(t, 0.001, histogramDeltaPercentile(prev, cur, 0.5))
+	assert.Equal(t, 0.01, histogramDeltaPercentile(prev, cur, 0.99))
+	assert.Equal(t, 0.1, histogramDeltaPercentile(prev, cur, 1))
+
+	// The lower bound is used for the infinite bucket.
+	cur = &metrics.Float64Histogram{Counts: []uint64{10, 0, 0, 5}, Buckets: buckets}
+	assert.Equal(t, 0.1, histogramDeltaPercentile(prev, cur, 0.99))
+	assert.Equal(t, 0.1, histogramDeltaPercentile(nil, cur, 0.99))
+}
+
+func TestRuntimeStatReader(t *testing.T) {
+	r := newRuntimeStatReader()
+	assert.True(t, r.heapInUse() > 0)
+	assert.True(t, r.gcPauseMs() >= 0)
+	fraction := r.gcCpuFraction()
+	assert.True(t, fraction >= 0 && fraction <= 1)
+	assert.True(t, r.schedLatencyMs() >= 0)
+}
@@ -98,15 +98,31 @@ func InitMemoryCollector(intervalMs uint32) {
 }
 
 func retrieveAndUpdateMemoryStat() { // 函数定时更新
-	memoryUsedBytes, err := GetProcessMemoryStat()
+	v, err := providerOf(SignalMemoryUsage).Value()
 	if err != nil {
 		logging.Error(err, "Fail to retrieve and update memory statistic")
 		return
 	}
+	memoryUsedBytes := int64(v)
 	processMemoryGauge.Set(float64(memoryUsedBytes)) // 上报指标
 	currentMemoryUsage.Store(memoryUsedBytes)        // 函数定时更新
 }
 
+// getMemoryStat gets the memory usage of the container if the cgroup reader is used,
+// otherwise (or the cgroup files are not accessible) the memory usage of current process.
+func getMemoryStat() (int64, error) {
+	if reader, _ := currentCgroupReader(); reader != nil {
+		usage, err := reader.MemoryUsageBytes()
+		if err == nil {
+			return usage, nil
+		}
+		if logging.DebugEnabled() {
+			logging.Debug("[SystemMetric] Fail to read the memory usage from cgroup, fall back to the process metric", "err", err.Error())
+		}
+	}
+	return GetProcessMemoryStat()
+}
+
 // GetProcessMemoryStat gets current process's memory usage in Bytes
 func GetProcessMemoryStat() (int64, error) {
 	curProcess := currentProcess.Load()
@@ -154,7 +170,7 @@ func InitCpuCollector(intervalMs uint32) {
 }
 
 func retrieveAndUpdateCpuStat() {
-	cpuPercent, err := getProcessCpuStat()
+	cpuPercent, err := providerOf(SignalCpuUsage).Value()
 	if err != nil {
 		logging.Error(err, "Fail to retrieve and update cpu statistic")
 		return
@@ -165,6 +181,21 @@ func retrieveAndUpdateCpuStat() {
 	currentCpuUsage.Store(cpuPercent)
 }
 
+// getCpuStat gets the CPU usage ratio against the CPU quota of the container if the cgroup reader is used,
+// otherwise (or the cgroup files are not accessible) the CPU usage of current process.
+func getCpuStat() (float64, error) {
+	if _, sampler := currentCgroupReader(); sampler != nil {
+		ratio, err := sampler.sample(time.Now())
+		if err == nil {
+			return ratio, nil
+		}
+		if logging.DebugEnabled() {
+			logging.Debug("[SystemMetric] Fail to read the CPU usage from cgroup, fall back to the process metric", "err", err.Error())
+		}
+	}
+	return getProcessCpuStat()
+}
+
 // getProcessCpuStat gets current process's memory usage in Bytes
 func getProcessCpuStat() (float64, error) {
 	curProcess := currentProcess.Load()
@@ -206,14 +237,26 @@ func InitLoadCollector(intervalMs uint32) {
 }
 
 func retrieveAndUpdateLoadStat() {
-	loadStat, err := load.Avg()
+	load1, err := providerOf(SignalLoad).Value()
 	if err != nil {
 		logging.Error(err, "[retrieveAndUpdateSystemStat] Failed to retrieve current system load")
 		return
 	}
-	if loadStat != nil {
-		currentLoad.Store(loadStat.Load1)
+	if load1 != NotRetrievedLoadValue {
+		currentLoad.Store(load1)
+	}
+}
+
+// getLoadStat gets the system load1.
+func getLoadStat() (float64, error) {
+	loadStat, err := load.Avg()
+	if err != nil {
+		return NotRetrievedLoadValue, err
+	}
+	if loadStat == nil {
+		return NotRetrievedLoadValue, nil
 	}
+	return loadStat.Load1, nil
 }
 
 func CurrentLoad() float64 {
@@ -173,12 +173,17 @@ func HotSpotParamRuleJsonArrayParser(src []byte) (interface{}, error) {
 			MetricType:        hotspotRule.MetricType,
 			ControlBehavior:   hotspotRule.ControlBehavior,
 			ParamIndex:        hotspotRule.ParamIndex,
+			ParamIndexes:      hotspotRule.ParamIndexes,
+			ParamKeys:         hotspotRule.ParamKeys,
+			ParamPath:         hotspotRule.ParamPath,
 			Threshold:         hotspotRule.Threshold,
 			MaxQueueingTimeMs: hotspotRule.MaxQueueingTimeMs,
 			BurstCount:        hotspotRule.BurstCount,
 			DurationInSec:     hotspotRule.DurationInSec,
 			ParamsMaxCapacity: hotspotRule.ParamsMaxCapacity,
+			ParamsCacheType:   hotspotRule.ParamsCacheType,
 			SpecificItems:     parseSpecificItems(hotspotRule.SpecificItems),
+			SpecificMatchers:  parseSpecificMatchers(hotspotRule.SpecificItems),
 		}
 	}
 	return rules, nil
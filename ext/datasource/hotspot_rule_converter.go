@@ -3,6 +3,7 @@ package datasource
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/alibaba/sentinel-golang/core/hotspot"
 	"github.com/alibaba/sentinel-golang/logging"
@@ -27,6 +28,11 @@ type HotspotRule struct {
 	// if ParamIndex is great than or equals to zero, ParamIndex means the <ParamIndex>-th parameter
 	// if ParamIndex is the negative, ParamIndex means the reversed <ParamIndex>-th parameter
 	ParamIndex int `json:"paramIndex"`
+	// ParamIndexes and ParamKeys compose the composite parameter, see hotspot.Rule for details.
+	ParamIndexes []int    `json:"paramIndexes,omitempty"`
+	ParamKeys    []string `json:"paramKeys,omitempty"`
+	// ParamPath is the field path into an argument or an attachment, see hotspot.Rule for details.
+	ParamPath string `json:"paramPath,omitempty"`
 	// Threshold is the threshold to trigger rejection
 	Threshold int64 `json:"threshold"`
 	// MaxQueueingTimeMs only takes effect when ControlBehavior is Throttling and MetricType is QPS
@@ -40,6 +46,8 @@ type HotspotRule struct {
 	// ParamsMaxCapacity is the max capacity of cache statistic
 	ParamsMaxCapacity int64           `json:"paramsMaxCapacity"`
 	SpecificItems     []SpecificValue `json:"specificItems"`
+	// ParamsCacheType is the cache implementation of the statistic, see hotspot.CacheType for details.
+	ParamsCacheType hotspot.CacheType `json:"paramsCacheType,omitempty"`
 }
 
 // ParamKind represents the Param kind.
@@ -50,6 +58,13 @@ const (
 	KindString
 	KindBool
 	KindFloat64
+	KindComposite
+	// KindPrefix, KindRegex, KindRange and KindCIDR are the matchers, which are converted to hotspot.SpecificMatcher.
+	KindPrefix
+	KindRegex
+	// KindRange takes the ValStr in the format of "min,max", e.g. "100,200"
+	KindRange
+	KindCIDR
 	KindSum
 )
 
@@ -63,6 +78,16 @@ func (t ParamKind) String() string {
 		return "KindBool"
 	case KindFloat64:
 		return "KindFloat64"
+	case KindComposite:
+		return "KindComposite"
+	case KindPrefix:
+		return "KindPrefix"
+	case KindRegex:
+		return "KindRegex"
+	case KindRange:
+		return "KindRange"
+	case KindCIDR:
+		return "KindCIDR"
 	default:
 		return "Undefined"
 	}
@@ -118,9 +143,65 @@ func parseSpecificItems(source []SpecificValue) map[interface{}]int64 {
 				continue
 			}
 			ret[realVal] = item.Threshold
+
+		case KindComposite:
+			// the parameter values are joined by hotspot.CompositeKeySeparator, e.g. "tenantA|getUser",
+			// and the non-string value is prefixed by its type, e.g. "int:42|getUser"
+			realVal, err := hotspot.ParseCompositeKey(item.ValStr)
+			if err != nil {
+				logging.Error(errors.Wrap(err, "parseSpecificItems error"), "Failed to parse value for composite specific item", "itemValStr", item.ValStr)
+				continue
+			}
+			ret[realVal] = item.Threshold
+		case KindPrefix, KindRegex, KindRange, KindCIDR:
+			// the matchers are parsed by parseSpecificMatchers
+			continue
 		default:
 			logging.Error(errors.New("Unsupported kind for specific item"), "", item.ValKind)
 		}
 	}
 	return ret
 }
+
+// parseSpecificMatchers parses the matcher kinds of SpecificValue as hotspot.SpecificMatcher in order.
+func parseSpecificMatchers(source []SpecificValue) []hotspot.SpecificMatcher {
+	var ret []hotspot.SpecificMatcher
+	for _, item := range source {
+		m := hotspot.SpecificMatcher{
+			Pattern:   item.ValStr,
+			Threshold: item.Threshold,
+		}
+		switch item.ValKind {
+		case KindPrefix:
+			m.Kind = hotspot.MatchPrefix
+		case KindRegex:
+			m.Kind = hotspot.MatchRegex
+		case KindCIDR:
+			m.Kind = hotspot.MatchCIDR
+		case KindRange:
+			bounds := strings.Split(item.ValStr, ",")
+			if len(bounds) != 2 {
+				logging.Error(errors.New("parseSpecificMatchers error"), "Failed to parse value for range specific item, the format should be min,max", "itemValStr", item.ValStr)
+				continue
+			}
+			min, err := strconv.ParseFloat(strings.TrimSpace(bounds[0]), 64)
+			if err != nil {
+				logging.Error(errors.Wrap(err, "parseSpecificMatchers error"), "Failed to parse min for range specific item", "itemValStr", item.ValStr)
+				continue
+			}
+			max, err := strconv.ParseFloat(strings.TrimSpace(bounds[1]), 64)
+			if err != nil {
+				logging.Error(errors.Wrap(err, "parseSpecificMatchers error"), "Failed to parse max for range specific item", "itemValStr", item.ValStr)
+				continue
+			}
+			m.Kind = hotspot.MatchRange
+			m.Pattern = ""
+			m.Min = min
+			m.Max = max
+		default:
+			continue
+		}
+		ret = append(ret, m)
+	}
+	return ret
+}
@@ -3,12 +3,13 @@ package datasource
 import (
 	"testing"
 
+	"github.com/alibaba/sentinel-golang/core/hotspot"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_parseSpecificItems(t *testing.T) {
 	t.Run("Test_parseSpecificItems", func(t *testing.T) {
-		source := make([]SpecificValue, 6)
+		source := make([]SpecificValue, 7)
 		s1 := SpecificValue{
 			ValKind:   KindInt,
 			ValStr:    "10010",
@@ -45,14 +46,67 @@ func Test_parseSpecificItems(t *testing.T) {
 		source[3] = s4
 		source[4] = s5
 		source[5] = s6
+		source[6] = SpecificValue{
+			ValKind:   KindComposite,
+			ValStr:    "tenantA|getUser",
+			Threshold: 100,
+		}
 
 		got := parseSpecificItems(source)
-		assert.True(t, len(got) == 5)
+		assert.True(t, len(got) == 6)
 		assert.True(t, got[10010] == 100)
 		assert.True(t, got[true] == 100)
 		assert.True(t, got[1.234] == 100)
 		assert.True(t, got[1.23400] == 100)
 		assert.True(t, got["test-string"] == 100)
 		assert.True(t, got[1.23457] == 100)
+		assert.True(t, got[hotspot.NewCompositeKey("tenantA", "getUser")] == 100)
+	})
+
+	t.Run("Test_parseSpecificItems_Composite", func(t *testing.T) {
+		got := parseSpecificItems([]SpecificValue{
+			{ValKind: KindComposite, ValStr: `a\|b|c`, Threshold: 10},
+			{ValKind: KindComposite, ValStr: "a|b|c", Threshold: 20},
+		})
+		assert.Len(t, got, 2)
+		assert.Equal(t, int64(10), got[hotspot.NewCompositeKey("a|b", "c")])
+		assert.Equal(t, int64(20), got[hotspot.NewCompositeKey("a", "b", "c")])
+	})
+
+	t.Run("Test_parseSpecificItems_CompositeTyped", func(t *testing.T) {
+		got := parseSpecificItems([]SpecificValue{
+			{ValKind: KindComposite, ValStr: "int:42|string:getUser", Threshold: 10},
+			{ValKind: KindComposite, ValStr: "int64:7|bool:true|getUser", Threshold: 20},
+			{ValKind: KindComposite, ValStr: `int\:42|tenant:a`, Threshold: 30},
+			{ValKind: KindComposite, ValStr: "int:abc|getUser", Threshold: 40},
+		})
+		assert.Len(t, got, 3)
+		assert.Equal(t, int64(10), got[hotspot.NewCompositeKey(42, "getUser")])
+		assert.Equal(t, int64(20), got[hotspot.NewCompositeKey(int64(7), true, "getUser")])
+		assert.Equal(t, int64(30), got[hotspot.NewCompositeKey("int:42", "tenant:a")])
+		_, ok := got[hotspot.NewCompositeKey("42", "getUser")]
+		assert.False(t, ok)
+	})
+}
+
+func Test_parseSpecificMatchers(t *testing.T) {
+	t.Run("Test_parseSpecificMatchers", func(t *testing.T) {
+		source := []SpecificValue{
+			{ValKind: KindString, ValStr: "test-string", Threshold: 100},
+			{ValKind: KindPrefix, ValStr: "vip-", Threshold: 10},
+			{ValKind: KindRange, ValStr: "100, 200", Threshold: 20},
+			{ValKind: KindRange, ValStr: "100", Threshold: 20},
+			{ValKind: KindCIDR, ValStr: "10.0.0.0/8", Threshold: 30},
+			{ValKind: KindRegex, ValStr: "^u[0-9]+$", Threshold: 40},
+		}
+		got := parseSpecificMatchers(source)
+		assert.Equal(t, []hotspot.SpecificMatcher{
+			{Kind: hotspot.MatchPrefix, Pattern: "vip-", Threshold: 10},
+			{Kind: hotspot.MatchRange, Min: 100, Max: 200, Threshold: 20},
+			{Kind: hotspot.MatchCIDR, Pattern: "10.0.0.0/8", Threshold: 30},
+			{Kind: hotspot.MatchRegex, Pattern: "^u[0-9]+$", Threshold: 40},
+		}, got)
+		assert.Equal(t, 1, len(parseSpecificItems(source)))
+		assert.Nil(t, parseSpecificMatchers(source[:1]))
 	})
 }
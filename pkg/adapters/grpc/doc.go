@@ -19,5 +19,11 @@ Sentinel interceptors (via options).
 Fallback logic: the plugin will return the BlockError by default
 if current request is blocked by Sentinel rules. Users may also
 provide customized fallback logic via WithXxxBlockFallback(handler) options.
+
+Outlier detection: users may wrap a balancer builder with NewOutlierBalancerBuilder,
+then the picker skips the endpoints ejected by the outlier detection rule:
+
+	balancer.Register(sentinelPlugin.NewOutlierBalancerBuilder("sentinel_round_robin", "my-service",
+		balancer.Get(roundrobin.Name)))
 */
 package grpc
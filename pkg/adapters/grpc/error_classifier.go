@@ -0,0 +1,41 @@
+package grpc
+
+import (
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultFailureCodes are the gRPC status codes that indicate the downstream service is unhealthy.
+var DefaultFailureCodes = []codes.Code{
+	codes.Unknown,
+	codes.DeadlineExceeded,
+	codes.ResourceExhausted,
+	codes.Internal,
+	codes.Unavailable,
+	codes.DataLoss,
+}
+
+// NewStatusCodeErrorClassifier creates the circuit breaker error classifier based on gRPC status codes.
+// The errors carrying one of the given failure codes (DefaultFailureCodes if absent) count as failures,
+// codes.OK counts as success, and all the other errors (e.g. codes.InvalidArgument, codes.Canceled) are ignored.
+// The non-status errors are regarded as codes.Unknown.
+func NewStatusCodeErrorClassifier(failureCodes ...codes.Code) circuitbreaker.ErrorClassifier {
+	if len(failureCodes) == 0 {
+		failureCodes = DefaultFailureCodes
+	}
+	failures := make(map[codes.Code]struct{}, len(failureCodes))
+	for _, c := range failureCodes {
+		failures[c] = struct{}{}
+	}
+	return func(err error) circuitbreaker.ErrorClass {
+		code := status.Code(err)
+		if code == codes.OK {
+			return circuitbreaker.ErrorClassSuccess
+		}
+		if _, ok := failures[code]; ok {
+			return circuitbreaker.ErrorClassFailure
+		}
+		return circuitbreaker.ErrorClassIgnored
+	}
+}
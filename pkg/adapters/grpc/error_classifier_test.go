@@ -0,0 +1,27 @@
+package grpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewStatusCodeErrorClassifier(t *testing.T) {
+	t.Run("default failure codes", func(t *testing.T) {
+		classifier := NewStatusCodeErrorClassifier()
+		assert.Equal(t, circuitbreaker.ErrorClassFailure, classifier(status.Error(codes.Unavailable, "unavailable")))
+		assert.Equal(t, circuitbreaker.ErrorClassFailure, classifier(errors.New("biz error")))
+		assert.Equal(t, circuitbreaker.ErrorClassIgnored, classifier(status.Error(codes.InvalidArgument, "bad request")))
+		assert.Equal(t, circuitbreaker.ErrorClassIgnored, classifier(status.Error(codes.Canceled, "canceled")))
+	})
+
+	t.Run("custom failure codes", func(t *testing.T) {
+		classifier := NewStatusCodeErrorClassifier(codes.NotFound)
+		assert.Equal(t, circuitbreaker.ErrorClassFailure, classifier(status.Error(codes.NotFound, "not found")))
+		assert.Equal(t, circuitbreaker.ErrorClassIgnored, classifier(status.Error(codes.Unavailable, "unavailable")))
+	})
+}
@@ -0,0 +1,152 @@
+package grpc
+
+import (
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/core/outlier"
+	"github.com/alibaba/sentinel-golang/util"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/resolver"
+)
+
+// NewOutlierBalancerBuilder wraps the child balancer builder with outlier detection.
+// The picker of the built balancer skips the endpoints ejected by the outlier detection rule of the given resource,
+// and records the result of each RPC to the outlier detector. If all the picked endpoints are ejected,
+// the picker falls back to the endpoint picked by the child picker.
+// The returned builder should be registered through balancer.Register and then referenced by the name in service config.
+func NewOutlierBalancerBuilder(name, resource string, child balancer.Builder) balancer.Builder {
+	return &outlierBalancerBuilder{
+		name:     name,
+		resource: resource,
+		child:    child,
+	}
+}
+
+type outlierBalancerBuilder struct {
+	name     string
+	resource string
+	child    balancer.Builder
+}
+
+func (b *outlierBalancerBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	ccw := &outlierClientConn{
+		ClientConn: cc,
+		resource:   b.resource,
+		addrs:      make(map[balancer.SubConn]string),
+	}
+	return b.child.Build(ccw, opts)
+}
+
+func (b *outlierBalancerBuilder) Name() string {
+	return b.name
+}
+
+// outlierClientConn records the address of each SubConn and wraps the picker updated by the child balancer.
+type outlierClientConn struct {
+	balancer.ClientConn
+	resource string
+
+	mux   sync.RWMutex
+	addrs map[balancer.SubConn]string
+}
+
+func (cc *outlierClientConn) NewSubConn(addrs []resolver.Address, opts balancer.NewSubConnOptions) (balancer.SubConn, error) {
+	sc, err := cc.ClientConn.NewSubConn(addrs, opts)
+	if err != nil || len(addrs) == 0 {
+		return sc, err
+	}
+	cc.mux.Lock()
+	cc.addrs[sc] = addrs[0].Addr
+	cc.mux.Unlock()
+	return sc, nil
+}
+
+func (cc *outlierClientConn) RemoveSubConn(sc balancer.SubConn) {
+	cc.mux.Lock()
+	addr, ok := cc.addrs[sc]
+	delete(cc.addrs, sc)
+	cc.mux.Unlock()
+	if ok {
+		outlier.RemoveInstance(cc.resource, addr)
+	}
+	cc.ClientConn.RemoveSubConn(sc)
+}
+
+func (cc *outlierClientConn) UpdateState(s balancer.State) {
+	if s.Picker != nil {
+		s.Picker = newOutlierPicker(cc.resource, s.Picker, cc.addressOf, cc.subConnCount)
+	}
+	cc.ClientConn.UpdateState(s)
+}
+
+func (cc *outlierClientConn) addressOf(sc balancer.SubConn) (string, bool) {
+	cc.mux.RLock()
+	defer cc.mux.RUnlock()
+
+	addr, ok := cc.addrs[sc]
+	return addr, ok
+}
+
+func (cc *outlierClientConn) subConnCount() int {
+	cc.mux.RLock()
+	defer cc.mux.RUnlock()
+
+	return len(cc.addrs)
+}
+
+// outlierPicker picks again if the endpoint picked by the child picker is ejected.
+type outlierPicker struct {
+	resource     string
+	picker       balancer.Picker
+	addressOf    func(balancer.SubConn) (string, bool)
+	subConnCount func() int
+}
+
+func newOutlierPicker(resource string, picker balancer.Picker, addressOf func(balancer.SubConn) (string, bool), subConnCount func() int) *outlierPicker {
+	return &outlierPicker{
+		resource:     resource,
+		picker:       picker,
+		addressOf:    addressOf,
+		subConnCount: subConnCount,
+	}
+}
+
+func (p *outlierPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	attempts := p.subConnCount()
+	if attempts < 1 {
+		attempts = 1
+	}
+	var (
+		result balancer.PickResult
+		addr   string
+		err    error
+	)
+	for i := 0; i < attempts; i++ {
+		if i > 0 && result.Done != nil {
+			// release the skipped pick of the child picker
+			result.Done(balancer.DoneInfo{})
+		}
+		result, err = p.picker.Pick(info)
+		if err != nil {
+			return result, err
+		}
+		var ok bool
+		addr, ok = p.addressOf(result.SubConn)
+		if !ok || !outlier.IsEjected(p.resource, addr) {
+			break
+		}
+	}
+	if len(addr) == 0 {
+		return result, nil
+	}
+
+	start := util.CurrentTimeMillis()
+	childDone := result.Done
+	result.Done = func(di balancer.DoneInfo) {
+		outlier.OnRequestComplete(p.resource, addr, util.CurrentTimeMillis()-start, di.Err)
+		if childDone != nil {
+			childDone(di)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/outlier"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/resolver"
+)
+
+type fakeSubConn struct {
+	addr string
+}
+
+func (sc *fakeSubConn) UpdateAddresses([]resolver.Address) {}
+
+func (sc *fakeSubConn) Connect() {}
+
+// fakeRoundRobinPicker picks the SubConns in turn.
+type fakeRoundRobinPicker struct {
+	subConns []balancer.SubConn
+	next     int
+}
+
+func (p *fakeRoundRobinPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	sc := p.subConns[p.next%len(p.subConns)]
+	p.next++
+	return balancer.PickResult{SubConn: sc}, nil
+}
+
+func TestOutlierPicker(t *testing.T) {
+	const resource = "grpc.testing.TestService"
+	_, err := outlier.LoadRules([]*outlier.Rule{
+		{
+			Resource:           resource,
+			MaxErrorRatio:      0.5,
+			MinRequestAmount:   2,
+			StatIntervalMs:     10000,
+			BaseEjectionTimeMs: 10000,
+			MaxEjectionPercent: 0.5,
+		},
+	})
+	assert.Nil(t, err)
+	defer func() {
+		_ = outlier.ClearRules()
+	}()
+
+	scA, scB := &fakeSubConn{addr: "10.0.0.1:80"}, &fakeSubConn{addr: "10.0.0.2:80"}
+	addrs := map[balancer.SubConn]string{scA: scA.addr, scB: scB.addr}
+	picker := newOutlierPicker(resource, &fakeRoundRobinPicker{subConns: []balancer.SubConn{scA, scB}},
+		func(sc balancer.SubConn) (string, bool) {
+			addr, ok := addrs[sc]
+			return addr, ok
+		},
+		func() int {
+			return len(addrs)
+		})
+
+	// scA fails and scB succeeds
+	for i := 0; i < 4; i++ {
+		result, err := picker.Pick(balancer.PickInfo{})
+		assert.Nil(t, err)
+		var doneErr error
+		if result.SubConn == scA {
+			doneErr = errors.New("unavailable")
+		}
+		result.Done(balancer.DoneInfo{Err: doneErr})
+	}
+	assert.True(t, outlier.IsEjected(resource, scA.addr))
+	assert.False(t, outlier.IsEjected(resource, scB.addr))
+
+	// the ejected scA is skipped
+	for i := 0; i < 4; i++ {
+		result, err := picker.Pick(balancer.PickInfo{})
+		assert.Nil(t, err)
+		assert.Equal(t, scB, result.SubConn)
+		result.Done(balancer.DoneInfo{})
+	}
+}
@@ -127,3 +127,47 @@ func TestAdaptiveFlowControl2(t *testing.T) {
 	_, blockError := api.Entry(rs, api.WithTrafficType(base.Inbound))
 	assert.Nil(t, blockError)
 }
+
+func TestAdaptiveFlowControlWithSignal(t *testing.T) {
+	initSentinel()
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+
+	rs := "adaptive-signal"
+	signal := "test_queue_ratio"
+	rule := flow.Rule{
+		Resource:               rs,
+		TokenCalculateStrategy: flow.MemoryAdaptive,
+		ControlBehavior:        flow.Reject,
+		StatIntervalInMs:       1000,
+		LowMemUsageThreshold:   5,
+		HighMemUsageThreshold:  1,
+		AdaptiveSignal:         signal,
+		SignalLowWaterMark:     0.2,
+		SignalHighWaterMark:    0.6,
+	}
+	ok, err := flow.LoadRules([]*flow.Rule{&rule})
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	defer flow.ClearRules()
+
+	// The threshold is interpolated by the fractional watermarks of the signal: 5 - (5-1)*(0.4-0.2)/(0.6-0.2) = 3
+	system_metric.SetCustomSignal(signal, 0.4)
+	for i := 0; i < 3; i++ {
+		entry, blockError := api.Entry(rs, api.WithTrafficType(base.Inbound))
+		if assert.Nil(t, blockError) {
+			entry.Exit()
+		}
+	}
+	_, blockError := api.Entry(rs, api.WithTrafficType(base.Inbound))
+	assert.NotNil(t, blockError)
+
+	// The water marks of the signal are validated separately from the memory water marks.
+	invalid := rule
+	invalid.SignalLowWaterMark = 0.6
+	invalid.SignalHighWaterMark = 0.2
+	assert.NotNil(t, flow.IsValidRule(&invalid))
+	invalid = rule
+	invalid.AdaptiveSignal = ""
+	assert.NotNil(t, flow.IsValidRule(&invalid))
+}
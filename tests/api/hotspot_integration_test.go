@@ -0,0 +1,432 @@
+package api
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/api"
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHotspotTopKParams(t *testing.T) {
+	// The parameter statistic is enabled by the top-K reporter in config.
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Log.Logger = logging.NewConsoleLogger()
+	conf.Sentinel.Log.Metric.FlushIntervalSec = 0
+	conf.Sentinel.Stat.System.CollectIntervalMs = 0
+	conf.Sentinel.Stat.HotSpot.TopKParamsReportIntervalMs = 1000
+	conf.Sentinel.Stat.HotSpot.TopKParamsCount = 2
+	assert.Nil(t, api.InitWithConfig(conf))
+	defer hotspot.StopTopKParamsReporter()
+	assert.True(t, hotspot.ParamStatEnabled())
+	util.SetClock(util.NewMockClock())
+	defer hotspot.ClearRules()
+
+	rs := "hotspot-top-k"
+	_, err := hotspot.LoadRules([]*hotspot.Rule{
+		{
+			ID:                "qps",
+			Resource:          rs,
+			MetricType:        hotspot.QPS,
+			ControlBehavior:   hotspot.Reject,
+			ParamIndex:        0,
+			Threshold:         3,
+			DurationInSec:     1,
+			ParamsMaxCapacity: 100,
+		},
+	})
+	assert.Nil(t, err)
+
+	tenants := map[string]int{"tenantA": 5, "tenantB": 2, "tenantC": 1}
+	for tenant, n := range tenants {
+		for i := 0; i < n; i++ {
+			e, b := api.Entry(rs, api.WithTrafficType(base.Inbound), api.WithArgs(tenant))
+			if b == nil {
+				e.Exit()
+			}
+		}
+	}
+
+	topK := hotspot.GetTopKParams(rs, 2)
+	assert.Equal(t, 1, len(topK))
+	assert.Equal(t, "qps", topK[0].RuleId)
+	params := topK[0].Params
+	assert.Equal(t, 2, len(params))
+	assert.Equal(t, "tenantA", params[0].Value)
+	assert.Equal(t, int64(3), params[0].PassCount)
+	assert.Equal(t, int64(2), params[0].BlockCount)
+	assert.Equal(t, "tenantB", params[1].Value)
+	assert.Equal(t, int64(2), params[1].PassCount)
+	assert.Equal(t, int64(0), params[1].BlockCount)
+
+	assert.Equal(t, 0, len(hotspot.GetTopKParams("nonexistent", 2)))
+
+	// Nothing is recorded once the reporter is stopped.
+	hotspot.StopTopKParamsReporter()
+	assert.False(t, hotspot.ParamStatEnabled())
+	_, err = hotspot.LoadRules([]*hotspot.Rule{
+		{
+			ID:                "qps-disabled",
+			Resource:          rs + "-disabled",
+			MetricType:        hotspot.QPS,
+			ControlBehavior:   hotspot.Reject,
+			ParamIndex:        0,
+			Threshold:         3,
+			DurationInSec:     1,
+			ParamsMaxCapacity: 100,
+		},
+	})
+	assert.Nil(t, err)
+	if e, b := api.Entry(rs+"-disabled", api.WithTrafficType(base.Inbound), api.WithArgs("tenantA")); b == nil {
+		e.Exit()
+	}
+	topK = hotspot.GetTopKParams(rs+"-disabled", 2)
+	assert.Equal(t, 1, len(topK))
+	assert.Equal(t, 0, len(topK[0].Params))
+
+	// The counters of the existing rule are created once the statistic is enabled.
+	hotspot.SetParamStatEnabled(true)
+	defer hotspot.SetParamStatEnabled(false)
+	if e, b := api.Entry(rs+"-disabled", api.WithTrafficType(base.Inbound), api.WithArgs("tenantA")); b == nil {
+		e.Exit()
+	}
+	topK = hotspot.GetTopKParams(rs+"-disabled", 2)
+	assert.Equal(t, 1, len(topK))
+	if assert.Equal(t, 1, len(topK[0].Params)) {
+		assert.Equal(t, "tenantA", topK[0].Params[0].Value)
+		assert.Equal(t, int64(1), topK[0].Params[0].PassCount)
+	}
+}
+
+type hotspotUser struct {
+	ID string
+}
+
+type hotspotRequest struct {
+	User   *hotspotUser
+	Labels map[string]interface{}
+}
+
+func TestHotspotParamPath(t *testing.T) {
+	initSentinel()
+	util.SetClock(util.NewMockClock())
+	defer hotspot.ClearRules()
+
+	newRule := func(id, res, path string) *hotspot.Rule {
+		return &hotspot.Rule{
+			ID:              id,
+			Resource:        res,
+			MetricType:      hotspot.QPS,
+			ControlBehavior: hotspot.Reject,
+			ParamPath:       path,
+			Threshold:       1,
+			DurationInSec:   1,
+		}
+	}
+	_, err := hotspot.LoadRules([]*hotspot.Rule{
+		newRule("field", "hotspot-path-field", "args[0].User.ID"),
+		newRule("pointer", "hotspot-path-pointer", "attachments[req]/Labels/tenant"),
+	})
+	assert.Nil(t, err)
+
+	req := &hotspotRequest{User: &hotspotUser{ID: "u1"}, Labels: map[string]interface{}{"tenant": "t1"}}
+	passed := func(res string, opts ...api.EntryOption) bool {
+		e, b := api.Entry(res, append(opts, api.WithTrafficType(base.Inbound))...)
+		if b != nil {
+			return false
+		}
+		e.Exit()
+		return true
+	}
+
+	assert.True(t, passed("hotspot-path-field", api.WithArgs(req)))
+	assert.False(t, passed("hotspot-path-field", api.WithArgs(req)))
+	// Another user isn't limited.
+	assert.True(t, passed("hotspot-path-field", api.WithArgs(&hotspotRequest{User: &hotspotUser{ID: "u2"}})))
+	// The nil field is ignored.
+	assert.True(t, passed("hotspot-path-field", api.WithArgs(&hotspotRequest{})))
+	assert.True(t, passed("hotspot-path-field", api.WithArgs(&hotspotRequest{})))
+
+	attachment := api.WithAttachments(map[interface{}]interface{}{"req": req})
+	assert.True(t, passed("hotspot-path-pointer", attachment))
+	assert.False(t, passed("hotspot-path-pointer", attachment))
+
+	// The typed extractor takes precedence over reflection.
+	assert.Nil(t, hotspot.RegisterFieldExtractor(&hotspotRequest{}, "User.ID", func(value interface{}) interface{} {
+		return "fixed"
+	}))
+	defer hotspot.RemoveFieldExtractor(&hotspotRequest{}, "User.ID")
+	assert.True(t, passed("hotspot-path-field", api.WithArgs(&hotspotRequest{User: &hotspotUser{ID: "u3"}})))
+	assert.False(t, passed("hotspot-path-field", api.WithArgs(&hotspotRequest{User: &hotspotUser{ID: "u4"}})))
+
+	// The unhashable result of extractor is ignored rather than panicking.
+	assert.Nil(t, hotspot.RegisterFieldExtractor(&hotspotRequest{}, "User.ID", func(value interface{}) interface{} {
+		return []string{"u5"}
+	}))
+	assert.True(t, passed("hotspot-path-field", api.WithArgs(&hotspotRequest{User: &hotspotUser{ID: "u5"}})))
+	assert.True(t, passed("hotspot-path-field", api.WithArgs(&hotspotRequest{User: &hotspotUser{ID: "u5"}})))
+
+	_, err = hotspot.LoadRules([]*hotspot.Rule{newRule("invalid", "hotspot-path-invalid", "args[x].User")})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(hotspot.GetRulesOfResource("hotspot-path-invalid")))
+}
+
+func TestHotspotSpecificMatchers(t *testing.T) {
+	initSentinel()
+	util.SetClock(util.NewMockClock())
+	defer hotspot.ClearRules()
+
+	rs := "hotspot-specific-matchers"
+	_, err := hotspot.LoadRules([]*hotspot.Rule{
+		{
+			Resource:        rs,
+			MetricType:      hotspot.QPS,
+			ControlBehavior: hotspot.Reject,
+			ParamIndex:      0,
+			Threshold:       1,
+			DurationInSec:   1,
+			SpecificItems:   map[interface{}]int64{"vip-exact": 1},
+			SpecificMatchers: []hotspot.SpecificMatcher{
+				{Kind: hotspot.MatchPrefix, Pattern: "vip-", Threshold: 3},
+				{Kind: hotspot.MatchCIDR, Pattern: "10.0.0.0/8", Threshold: 0},
+				{Kind: hotspot.MatchRange, Min: 100, Max: 200, Threshold: 2},
+				{Kind: hotspot.MatchRegex, Pattern: "^vip-.*$", Threshold: 100},
+			},
+		},
+	})
+	assert.Nil(t, err)
+
+	passedCount := func(arg interface{}, n int) int {
+		passed := 0
+		for i := 0; i < n; i++ {
+			e, b := api.Entry(rs, api.WithTrafficType(base.Inbound), api.WithArgs(arg))
+			if b == nil {
+				passed++
+				e.Exit()
+			}
+		}
+		return passed
+	}
+	assert.Equal(t, 1, passedCount("vip-exact", 5))
+	// The first matched matcher takes effect.
+	assert.Equal(t, 3, passedCount("vip-user", 5))
+	assert.Equal(t, 0, passedCount("10.1.2.3", 5))
+	assert.Equal(t, 0, passedCount(net.ParseIP("10.2.3.4"), 5))
+	assert.Equal(t, 1, passedCount("192.168.1.1", 5))
+	assert.Equal(t, 2, passedCount(150, 5))
+	assert.Equal(t, 1, passedCount(250, 5))
+
+	regexRs := rs + "-regex"
+	_, err = hotspot.LoadRules([]*hotspot.Rule{
+		{
+			Resource:         regexRs,
+			MetricType:       hotspot.QPS,
+			ControlBehavior:  hotspot.Reject,
+			ParamIndex:       0,
+			Threshold:        1,
+			DurationInSec:    1,
+			SpecificMatchers: []hotspot.SpecificMatcher{{Kind: hotspot.MatchRegex, Pattern: "^u[0-9]+$", Threshold: 2}},
+		},
+	})
+	assert.Nil(t, err)
+	// The matching results are cached by the parameter value.
+	for _, arg := range []string{"u123", "user", "u123", "user"} {
+		e, b := api.Entry(regexRs, api.WithTrafficType(base.Inbound), api.WithArgs(arg))
+		if b == nil {
+			e.Exit()
+		}
+	}
+	_, b := api.Entry(regexRs, api.WithTrafficType(base.Inbound), api.WithArgs("u123"))
+	assert.NotNil(t, b)
+	_, b = api.Entry(regexRs, api.WithTrafficType(base.Inbound), api.WithArgs("user"))
+	assert.NotNil(t, b)
+
+	_, err = hotspot.LoadRules([]*hotspot.Rule{
+		{
+			Resource:         rs,
+			MetricType:       hotspot.QPS,
+			ControlBehavior:  hotspot.Reject,
+			Threshold:        1,
+			DurationInSec:    1,
+			SpecificMatchers: []hotspot.SpecificMatcher{{Kind: hotspot.MatchCIDR, Pattern: "10.0.0.0", Threshold: 1}},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(hotspot.GetRulesOfResource(rs)))
+}
+
+func TestHotspotParamsCacheType(t *testing.T) {
+	initSentinel()
+	util.SetClock(util.NewMockClock())
+	defer hotspot.ClearRules()
+
+	assert.NotNil(t, hotspot.SetDefaultCacheType(hotspot.DefaultCacheType))
+	assert.Nil(t, hotspot.SetDefaultCacheType(hotspot.TinyLFUCache))
+	defer hotspot.SetDefaultCacheType(hotspot.LRUCache)
+	assert.Equal(t, hotspot.TinyLFUCache, hotspot.GetDefaultCacheType())
+
+	for _, cacheType := range []hotspot.CacheType{hotspot.DefaultCacheType, hotspot.LRUCache, hotspot.ShardedLRUCache, hotspot.TinyLFUCache} {
+		rs := "hotspot-cache-" + cacheType.String()
+		_, err := hotspot.LoadRules([]*hotspot.Rule{
+			{
+				Resource:        rs,
+				MetricType:      hotspot.QPS,
+				ControlBehavior: hotspot.Reject,
+				ParamIndex:      0,
+				Threshold:       2,
+				DurationInSec:   1,
+				ParamsCacheType: cacheType,
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(hotspot.GetRulesOfResource(rs)))
+
+		passed := 0
+		for i := 0; i < 5; i++ {
+			e, b := api.Entry(rs, api.WithTrafficType(base.Inbound), api.WithArgs("tenantA"))
+			if b == nil {
+				passed++
+				e.Exit()
+			}
+		}
+		assert.Equal(t, 2, passed, cacheType.String())
+	}
+}
+
+func TestHotspotTinyLFUNewParamLimited(t *testing.T) {
+	initSentinel()
+	util.SetClock(util.NewMockClock())
+	defer hotspot.ClearRules()
+
+	qpsRes, concurrencyRes := "hotspot-tinylfu-qps", "hotspot-tinylfu-concurrency"
+	_, err := hotspot.LoadRules([]*hotspot.Rule{
+		{
+			Resource:          qpsRes,
+			MetricType:        hotspot.QPS,
+			ControlBehavior:   hotspot.Reject,
+			ParamIndex:        0,
+			Threshold:         1,
+			DurationInSec:     1,
+			ParamsMaxCapacity: 64,
+			ParamsCacheType:   hotspot.TinyLFUCache,
+		},
+		{
+			Resource:          concurrencyRes,
+			MetricType:        hotspot.Concurrency,
+			ParamIndex:        0,
+			Threshold:         1,
+			ParamsMaxCapacity: 64,
+			ParamsCacheType:   hotspot.TinyLFUCache,
+		},
+	})
+	assert.Nil(t, err)
+
+	// Warm the caches up with the frequent parameters, so that the new one is not admitted into the main space.
+	for round := 0; round < 20; round++ {
+		for i := 0; i < 256; i++ {
+			arg := "warm-" + strconv.Itoa(i)
+			if e, b := api.Entry(qpsRes, api.WithTrafficType(base.Inbound), api.WithArgs(arg)); b == nil {
+				e.Exit()
+			}
+			if e, b := api.Entry(concurrencyRes, api.WithTrafficType(base.Inbound), api.WithArgs(arg)); b == nil {
+				e.Exit()
+			}
+		}
+	}
+
+	passed := 0
+	for i := 0; i < 10; i++ {
+		if e, b := api.Entry(qpsRes, api.WithTrafficType(base.Inbound), api.WithArgs("new")); b == nil {
+			passed++
+			e.Exit()
+		}
+	}
+	assert.Equal(t, 1, passed)
+
+	e1, b1 := api.Entry(concurrencyRes, api.WithTrafficType(base.Inbound), api.WithArgs("new"))
+	assert.Nil(t, b1)
+	_, b2 := api.Entry(concurrencyRes, api.WithTrafficType(base.Inbound), api.WithArgs("new"))
+	assert.NotNil(t, b2)
+	e1.Exit()
+}
+
+func TestHotspotCompositeKeyNoCollision(t *testing.T) {
+	initSentinel()
+	util.SetClock(util.NewMockClock())
+	defer hotspot.ClearRules()
+
+	rs := "hotspot-composite"
+	_, err := hotspot.LoadRules([]*hotspot.Rule{
+		{
+			Resource:        rs,
+			MetricType:      hotspot.QPS,
+			ControlBehavior: hotspot.Reject,
+			ParamIndexes:    []int{0, 1},
+			Threshold:       1,
+			DurationInSec:   1,
+			SpecificItems: map[interface{}]int64{
+				hotspot.NewCompositeKey("vip", "getUser"): 2,
+			},
+		},
+	})
+	assert.Nil(t, err)
+
+	// The values containing the separator or formatted the same are different parameters.
+	for _, args := range [][]interface{}{{"a|b", "c"}, {"a", "b|c"}, {1, "x"}, {"1", "x"}} {
+		e, b := api.Entry(rs, api.WithTrafficType(base.Inbound), api.WithArgs(args...))
+		if assert.Nil(t, b, "%v", args) {
+			e.Exit()
+		}
+	}
+	_, b := api.Entry(rs, api.WithTrafficType(base.Inbound), api.WithArgs("a", "b|c"))
+	assert.NotNil(t, b)
+
+	// The specific item of the composite parameter.
+	for i := 0; i < 2; i++ {
+		e, b := api.Entry(rs, api.WithTrafficType(base.Inbound), api.WithArgs("vip", "getUser"))
+		if assert.Nil(t, b) {
+			e.Exit()
+		}
+	}
+	_, b = api.Entry(rs, api.WithTrafficType(base.Inbound), api.WithArgs("vip", "getUser"))
+	assert.NotNil(t, b)
+
+	// The matchers match the parameter values of the composite parameter without the types.
+	matcherRs := rs + "-matchers"
+	_, err = hotspot.LoadRules([]*hotspot.Rule{
+		{
+			Resource:        matcherRs,
+			MetricType:      hotspot.QPS,
+			ControlBehavior: hotspot.Reject,
+			ParamIndexes:    []int{0, 1},
+			Threshold:       1,
+			DurationInSec:   1,
+			SpecificMatchers: []hotspot.SpecificMatcher{
+				{Kind: hotspot.MatchRegex, Pattern: `^tenantA\|get`, Threshold: 3},
+				{Kind: hotspot.MatchPrefix, Pattern: "tenantB|", Threshold: 2},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	passedCount := func(n int, args ...interface{}) int {
+		passed := 0
+		for i := 0; i < n; i++ {
+			e, b := api.Entry(matcherRs, api.WithTrafficType(base.Inbound), api.WithArgs(args...))
+			if b == nil {
+				passed++
+				e.Exit()
+			}
+		}
+		return passed
+	}
+	assert.Equal(t, 3, passedCount(5, "tenantA", "getUser"))
+	assert.Equal(t, 1, passedCount(5, "tenantA", "listUser"))
+	assert.Equal(t, 2, passedCount(5, "tenantB", 42))
+	assert.Equal(t, 1, passedCount(5, "tenantC", "getUser"))
+}
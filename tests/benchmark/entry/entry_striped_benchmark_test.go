@@ -0,0 +1,113 @@
+package entry
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	sentinel "github.com/alibaba/sentinel-golang/api"
+	"github.com/alibaba/sentinel-golang/core/base"
+	statbase "github.com/alibaba/sentinel-golang/core/stat/base"
+)
+
+// benchmarkEntryWithCounter runs sentinel.Entry with the given counter type of the MetricBucket.
+// Each counter type uses its own resource, so that the buckets of the resource are all created with the counter type.
+func benchmarkEntryWithCounter(b *testing.B, counterType string, parallelism int) {
+	if err := statbase.SetCounterType(counterType, 0); err != nil {
+		b.Fatal(err)
+	}
+	defer statbase.SetCounterType(base.CounterTypeAtomic, 0)
+
+	resource := "entry_counter_benchmark_test_" + counterType
+	sc := newSlotChain()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.SetParallelism(parallelism)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			e, blockErr := sentinel.Entry(resource, sentinel.WithSlotChain(sc))
+			if blockErr != nil {
+				fmt.Println("blocked")
+			} else {
+				e.Exit()
+			}
+		}
+	})
+}
+
+func Benchmark_Entry_AtomicCounter_Concurrency_1(b *testing.B) {
+	benchmarkEntryWithCounter(b, base.CounterTypeAtomic, 1)
+}
+
+func Benchmark_Entry_StripedCounter_Concurrency_1(b *testing.B) {
+	benchmarkEntryWithCounter(b, base.CounterTypeStriped, 1)
+}
+
+func Benchmark_Entry_AtomicCounter_Concurrency_4(b *testing.B) {
+	benchmarkEntryWithCounter(b, base.CounterTypeAtomic, 4)
+}
+
+func Benchmark_Entry_StripedCounter_Concurrency_4(b *testing.B) {
+	benchmarkEntryWithCounter(b, base.CounterTypeStriped, 4)
+}
+
+func Benchmark_Entry_AtomicCounter_Concurrency_16(b *testing.B) {
+	benchmarkEntryWithCounter(b, base.CounterTypeAtomic, 16)
+}
+
+func Benchmark_Entry_StripedCounter_Concurrency_16(b *testing.B) {
+	benchmarkEntryWithCounter(b, base.CounterTypeStriped, 16)
+}
+
+func Benchmark_Entry_AtomicCounter_Concurrency_64(b *testing.B) {
+	benchmarkEntryWithCounter(b, base.CounterTypeAtomic, 64)
+}
+
+func Benchmark_Entry_StripedCounter_Concurrency_64(b *testing.B) {
+	benchmarkEntryWithCounter(b, base.CounterTypeStriped, 64)
+}
+
+// Benchmark_MetricBucket_* compare the counters of a single bucket without the cost of the slot chain.
+func benchmarkBucketAdd(b *testing.B, counterType string, parallelism int) {
+	if err := statbase.SetCounterType(counterType, 0); err != nil {
+		b.Fatal(err)
+	}
+	defer statbase.SetCounterType(base.CounterTypeAtomic, 0)
+
+	mb := statbase.NewMetricBucket()
+	b.ResetTimer()
+	b.SetParallelism(parallelism)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mb.Add(base.MetricEventPass, 1)
+		}
+	})
+}
+
+func Benchmark_MetricBucket_AtomicCounter_Concurrency_16(b *testing.B) {
+	benchmarkBucketAdd(b, base.CounterTypeAtomic, 16)
+}
+
+func Benchmark_MetricBucket_StripedCounter_Concurrency_16(b *testing.B) {
+	benchmarkBucketAdd(b, base.CounterTypeStriped, 16)
+}
+
+// Benchmark_Counter_* compare the StripedCounter with a single atomic int64 under the contention of all Ps.
+func Benchmark_Counter_Atomic(b *testing.B) {
+	var v int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			atomic.AddInt64(&v, 1)
+		}
+	})
+}
+
+func Benchmark_Counter_Striped(b *testing.B) {
+	c := statbase.NewStripedCounter(uint32(runtime.GOMAXPROCS(0)))
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Add(1)
+		}
+	})
+}
@@ -1,8 +1,12 @@
 package circuitbreaker
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"net/http/httptest"
 	"reflect"
 	"sync"
 	"sync/atomic"
@@ -316,3 +320,573 @@ func TestCircuitBreakerSlotIntegration_Concurrency(t *testing.T) {
 		t.Fatal(clearErr)
 	}
 }
+
+type backoffStateChangeListenerMock struct {
+	StateChangeListenerMock
+	retryTimeouts []uint32
+}
+
+func (s *backoffStateChangeListenerMock) OnTransformToOpenWithBackoff(prev circuitbreaker.State, rule circuitbreaker.Rule, snapshot interface{}, retryTimeoutMs uint32) {
+	s.retryTimeouts = append(s.retryTimeouts, retryTimeoutMs)
+	logging.Debug("transform to open", "strategy", rule.Strategy, "prevState", prev.String(), "snapshot", snapshot, "retryTimeoutMs", retryTimeoutMs)
+}
+
+func TestCircuitBreakerSlotIntegration_RetryTimeoutBackoff(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+
+	circuitbreaker.ClearStateChangeListeners()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Log.Logger = logging.NewConsoleLogger()
+	err := sentinel.InitWithConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cbRule := &circuitbreaker.Rule{
+		Resource:                      "abc",
+		Strategy:                      circuitbreaker.ErrorCount,
+		RetryTimeoutMs:                100,
+		MinRequestAmount:              0,
+		StatIntervalMs:                10000,
+		Threshold:                     1,
+		RetryTimeoutBackoffMultiplier: 2,
+		MaxRetryTimeoutMs:             300,
+	}
+	_, err = circuitbreaker.LoadRules([]*circuitbreaker.Rule{cbRule})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateListener := &backoffStateChangeListenerMock{}
+	stateListener.On("OnTransformToClosed", mock.Anything, mock.Anything).Return()
+	stateListener.On("OnTransformToHalfOpen", mock.Anything, mock.Anything).Return()
+	circuitbreaker.RegisterStateChangeListeners(stateListener)
+
+	sc := base.NewSlotChain()
+	sc.AddRuleCheckSlot(&circuitbreaker.Slot{})
+	sc.AddStatSlot(&circuitbreaker.MetricStatSlot{})
+
+	entryWithErr := func(bizErr error) *base.BlockError {
+		e, b := sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+		if b != nil {
+			return b
+		}
+		if bizErr != nil {
+			sentinel.TraceError(e, bizErr)
+		}
+		e.Exit()
+		return nil
+	}
+
+	// Closed -> Open
+	assert.Nil(t, entryWithErr(errors.New("biz error")))
+	// failed probe: HalfOpen -> Open, retry timeout grows to 200ms
+	util.Sleep(150 * time.Millisecond)
+	assert.Nil(t, entryWithErr(errors.New("biz error")))
+	util.Sleep(150 * time.Millisecond)
+	assert.NotNil(t, entryWithErr(nil))
+	// failed probe: retry timeout is capped by MaxRetryTimeoutMs
+	util.Sleep(100 * time.Millisecond)
+	assert.Nil(t, entryWithErr(errors.New("biz error")))
+	// succeed probe: HalfOpen -> Closed, retry timeout resets
+	util.Sleep(350 * time.Millisecond)
+	assert.Nil(t, entryWithErr(nil))
+	assert.Nil(t, entryWithErr(errors.New("biz error")))
+
+	assert.Equal(t, []uint32{100, 200, 300, 100}, stateListener.retryTimeouts)
+	stateListener.AssertNotCalled(t, "OnTransformToOpen", mock.Anything, mock.Anything, mock.Anything)
+	stateListener.AssertNumberOfCalls(t, "OnTransformToClosed", 1)
+
+	circuitbreaker.ClearStateChangeListeners()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+}
+
+func TestCircuitBreakerSlotIntegration_ErrorClassifier(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+
+	circuitbreaker.ClearStateChangeListeners()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Log.Logger = logging.NewConsoleLogger()
+	err := sentinel.InitWithConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cbRule := &circuitbreaker.Rule{
+		Resource:            "abc",
+		Strategy:            circuitbreaker.ErrorCount,
+		RetryTimeoutMs:      100,
+		MinRequestAmount:    0,
+		StatIntervalMs:      10000,
+		Threshold:           1,
+		ProbeNum:            2,
+		ErrorClassifierName: circuitbreaker.ContextCanceledClassifierName,
+	}
+	_, err = circuitbreaker.LoadRules([]*circuitbreaker.Rule{cbRule})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateListener := &StateChangeListenerMock{}
+	stateListener.On("OnTransformToOpen", circuitbreaker.Closed, mock.Anything, mock.Anything).Return()
+	stateListener.On("OnTransformToOpen", circuitbreaker.HalfOpen, mock.Anything, mock.Anything).Return()
+	stateListener.On("OnTransformToHalfOpen", circuitbreaker.Open, mock.Anything).Return()
+	circuitbreaker.RegisterStateChangeListeners(stateListener)
+
+	sc := base.NewSlotChain()
+	sc.AddRuleCheckSlot(&circuitbreaker.Slot{})
+	sc.AddStatSlot(&circuitbreaker.MetricStatSlot{})
+
+	e, b := sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+	assert.True(t, b == nil)
+	sentinel.TraceError(e, context.Canceled)
+	e.Exit()
+	stateListener.AssertNotCalled(t, "OnTransformToOpen", mock.Anything, mock.Anything, mock.Anything)
+
+	e, b = sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+	assert.True(t, b == nil)
+	sentinel.TraceError(e, errors.New("biz error"))
+	e.Exit()
+	stateListener.AssertNumberOfCalls(t, "OnTransformToOpen", 1)
+
+	// The canceled request completed during Half-Open doesn't rollback the probe, only the canceled probe does.
+	util.Sleep(100 * time.Millisecond)
+	probe, b := sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+	assert.True(t, b == nil)
+	stateListener.AssertNumberOfCalls(t, "OnTransformToHalfOpen", 1)
+	e, b = sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+	assert.True(t, b == nil)
+	sentinel.TraceError(e, context.Canceled)
+	e.Exit()
+	stateListener.AssertNumberOfCalls(t, "OnTransformToOpen", 1)
+	sentinel.TraceError(probe, context.Canceled)
+	probe.Exit()
+	stateListener.AssertNumberOfCalls(t, "OnTransformToOpen", 2)
+	stateListener.AssertCalled(t, "OnTransformToOpen", circuitbreaker.HalfOpen, mock.Anything, mock.Anything)
+
+	// The rule referencing the unregistered classifier is invalid.
+	invalidRule := *cbRule
+	invalidRule.ErrorClassifierName = "unregistered"
+	assert.NotNil(t, circuitbreaker.IsValidRule(&invalidRule))
+
+	circuitbreaker.ClearStateChangeListeners()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+}
+
+func TestCircuitBreakerSlotIntegration_ConsecutiveErrors(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+
+	circuitbreaker.ClearStateChangeListeners()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Log.Logger = logging.NewConsoleLogger()
+	err := sentinel.InitWithConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cbRule := &circuitbreaker.Rule{
+		Resource:       "abc",
+		Strategy:       circuitbreaker.ConsecutiveErrors,
+		RetryTimeoutMs: 100,
+		Threshold:      3,
+	}
+	_, err = circuitbreaker.LoadRules([]*circuitbreaker.Rule{cbRule})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateListener := &StateChangeListenerMock{}
+	stateListener.On("OnTransformToOpen", circuitbreaker.Closed, mock.Anything, mock.Anything).Return()
+	stateListener.On("OnTransformToClosed", mock.Anything, mock.Anything).Return()
+	stateListener.On("OnTransformToHalfOpen", mock.Anything, mock.Anything).Return()
+	circuitbreaker.RegisterStateChangeListeners(stateListener)
+
+	sc := base.NewSlotChain()
+	sc.AddRuleCheckSlot(&circuitbreaker.Slot{})
+	sc.AddStatSlot(&circuitbreaker.MetricStatSlot{})
+
+	entryWithErr := func(bizErr error) *base.BlockError {
+		e, b := sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+		if b != nil {
+			return b
+		}
+		if bizErr != nil {
+			sentinel.TraceError(e, bizErr)
+		}
+		e.Exit()
+		return nil
+	}
+
+	// the succeed request resets the consecutive error count
+	assert.Nil(t, entryWithErr(errors.New("biz error")))
+	assert.Nil(t, entryWithErr(errors.New("biz error")))
+	assert.Nil(t, entryWithErr(nil))
+	assert.Nil(t, entryWithErr(errors.New("biz error")))
+	assert.Nil(t, entryWithErr(errors.New("biz error")))
+	stateListener.AssertNotCalled(t, "OnTransformToOpen", mock.Anything, mock.Anything, mock.Anything)
+
+	assert.Nil(t, entryWithErr(errors.New("biz error")))
+	stateListener.AssertCalled(t, "OnTransformToOpen", circuitbreaker.Closed, mock.Anything, uint64(3))
+	b := entryWithErr(nil)
+	assert.True(t, b != nil && b.BlockType() == base.BlockTypeCircuitBreaking)
+
+	// probe succeed
+	util.Sleep(150 * time.Millisecond)
+	assert.Nil(t, entryWithErr(nil))
+	stateListener.AssertNumberOfCalls(t, "OnTransformToClosed", 1)
+
+	circuitbreaker.ClearStateChangeListeners()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+}
+
+func TestCircuitBreakerSlotIntegration_Override(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+
+	circuitbreaker.ClearStateChangeListeners()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Log.Logger = logging.NewConsoleLogger()
+	err := sentinel.InitWithConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cbRule := &circuitbreaker.Rule{
+		Resource:         "abc",
+		Strategy:         circuitbreaker.ErrorCount,
+		RetryTimeoutMs:   100000,
+		MinRequestAmount: 0,
+		StatIntervalMs:   10000,
+		Threshold:        1,
+	}
+	_, err = circuitbreaker.LoadRules([]*circuitbreaker.Rule{cbRule})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedFromOpen := make(chan struct{}, 1)
+	stateListener := &StateChangeListenerMock{}
+	stateListener.On("OnTransformToOpen", mock.Anything, mock.Anything, mock.Anything).Return()
+	stateListener.On("OnTransformToClosed", circuitbreaker.Open, mock.Anything).Run(func(mock.Arguments) {
+		select {
+		case closedFromOpen <- struct{}{}:
+		default:
+		}
+	}).Return()
+	stateListener.On("OnTransformToClosed", mock.Anything, mock.Anything).Return()
+	stateListener.On("OnTransformToHalfOpen", mock.Anything, mock.Anything).Return()
+	circuitbreaker.RegisterStateChangeListeners(stateListener)
+
+	sc := base.NewSlotChain()
+	sc.AddRuleCheckSlot(&circuitbreaker.Slot{})
+	sc.AddStatSlot(&circuitbreaker.MetricStatSlot{})
+
+	t.Run("ForceOpen", func(t *testing.T) {
+		assert.Nil(t, circuitbreaker.ForceOpen("abc", 100*time.Millisecond))
+		stateListener.AssertCalled(t, "OnTransformToOpen", circuitbreaker.Closed, mock.Anything, mock.Anything)
+		o, ok := circuitbreaker.GetOverride("abc")
+		assert.True(t, ok)
+		assert.Equal(t, circuitbreaker.Open, o.State)
+
+		_, b := sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+		assert.True(t, b != nil && b.BlockType() == base.BlockTypeCircuitBreaking)
+
+		// the override expires, the listeners are notified without any traffic
+		util.Sleep(150 * time.Millisecond)
+		select {
+		case <-closedFromOpen:
+		case <-time.After(time.Second):
+			t.Fatal("the expiry of the override was not notified")
+		}
+		_, ok = circuitbreaker.GetOverride("abc")
+		assert.False(t, ok)
+		e, b := sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+		assert.True(t, b == nil)
+		e.Exit()
+	})
+
+	t.Run("ForceClose", func(t *testing.T) {
+		// trigger the circuit breaker
+		e, b := sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+		assert.True(t, b == nil)
+		sentinel.TraceError(e, errors.New("biz error"))
+		e.Exit()
+		_, b = sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+		assert.True(t, b != nil)
+
+		assert.Nil(t, circuitbreaker.ForceClose("abc", time.Minute))
+		stateListener.AssertCalled(t, "OnTransformToClosed", circuitbreaker.Open, mock.Anything)
+		e, b = sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+		assert.True(t, b == nil)
+		e.Exit()
+
+		assert.True(t, circuitbreaker.ClearOverride("abc"))
+		assert.False(t, circuitbreaker.ClearOverride("abc"))
+		_, b = sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+		assert.True(t, b != nil)
+	})
+
+	circuitbreaker.ClearStateChangeListeners()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+}
+
+func TestCircuitBreakerSlotIntegration_OverrideRetryTimeout(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+
+	circuitbreaker.ClearStateChangeListeners()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Log.Logger = logging.NewConsoleLogger()
+	err := sentinel.InitWithConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cbRule := &circuitbreaker.Rule{
+		Resource:                      "abc",
+		Strategy:                      circuitbreaker.ErrorCount,
+		RetryTimeoutMs:                100,
+		MinRequestAmount:              0,
+		StatIntervalMs:                10000,
+		Threshold:                     1,
+		RetryTimeoutBackoffMultiplier: 2,
+		MaxRetryTimeoutMs:             1000,
+	}
+	_, err = circuitbreaker.LoadRules([]*circuitbreaker.Rule{cbRule})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateListener := &backoffStateChangeListenerMock{}
+	stateListener.On("OnTransformToClosed", mock.Anything, mock.Anything).Return()
+	stateListener.On("OnTransformToHalfOpen", mock.Anything, mock.Anything).Return()
+	circuitbreaker.RegisterStateChangeListeners(stateListener)
+
+	sc := base.NewSlotChain()
+	sc.AddRuleCheckSlot(&circuitbreaker.Slot{})
+	sc.AddStatSlot(&circuitbreaker.MetricStatSlot{})
+
+	entryWithErr := func(bizErr error) *base.BlockError {
+		e, b := sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+		if b != nil {
+			return b
+		}
+		if bizErr != nil {
+			sentinel.TraceError(e, bizErr)
+		}
+		e.Exit()
+		return nil
+	}
+
+	// the ttl beyond the range of uint32 milliseconds is clamped
+	assert.Nil(t, circuitbreaker.ForceOpen("abc", 100*24*time.Hour))
+	assert.True(t, circuitbreaker.ClearOverride("abc"))
+
+	// the state machine doesn't transform while the override is active
+	assert.Nil(t, circuitbreaker.ForceClose("abc", time.Minute))
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, entryWithErr(errors.New("biz error")))
+	}
+	assert.True(t, circuitbreaker.ClearOverride("abc"))
+	assert.Equal(t, []uint32{math.MaxUint32}, stateListener.retryTimeouts)
+
+	// Closed -> Open, then the failed probe backs off the retry timeout to 200ms
+	assert.Nil(t, entryWithErr(errors.New("biz error")))
+	util.Sleep(150 * time.Millisecond)
+	assert.Nil(t, entryWithErr(errors.New("biz error")))
+
+	// the current retry timeout after backoff is notified when the override is removed
+	assert.Nil(t, circuitbreaker.ForceClose("abc", time.Minute))
+	assert.True(t, circuitbreaker.ClearOverride("abc"))
+	assert.Equal(t, []uint32{math.MaxUint32, 100, 200, 200}, stateListener.retryTimeouts)
+	assert.NotNil(t, entryWithErr(nil))
+
+	circuitbreaker.ClearStateChangeListeners()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+}
+
+func TestCircuitBreakerSlotIntegration_SlowPercentile(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+
+	circuitbreaker.ClearStateChangeListeners()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Log.Logger = logging.NewConsoleLogger()
+	err := sentinel.InitWithConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cbRule := &circuitbreaker.Rule{
+		Resource:         "abc",
+		Strategy:         circuitbreaker.SlowPercentile,
+		RetryTimeoutMs:   100000,
+		MinRequestAmount: 10,
+		StatIntervalMs:   100000,
+		MaxAllowedRtMs:   10,
+		Percentile:       90,
+	}
+	_, err = circuitbreaker.LoadRules([]*circuitbreaker.Rule{cbRule})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateListener := &StateChangeListenerMock{}
+	stateListener.On("OnTransformToOpen", circuitbreaker.Closed, mock.Anything, mock.Anything).Return()
+	circuitbreaker.RegisterStateChangeListeners(stateListener)
+
+	sc := base.NewSlotChain()
+	sc.AddRuleCheckSlot(&circuitbreaker.Slot{})
+	sc.AddStatSlot(&circuitbreaker.MetricStatSlot{})
+
+	entryWithRt := func(rt time.Duration) {
+		e, b := sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+		assert.True(t, b == nil)
+		util.Sleep(rt)
+		e.Exit()
+	}
+
+	for i := 0; i < 9; i++ {
+		entryWithRt(0)
+	}
+	entryWithRt(50 * time.Millisecond)
+	// P90 is still under the bound
+	stateListener.AssertNotCalled(t, "OnTransformToOpen", mock.Anything, mock.Anything, mock.Anything)
+
+	entryWithRt(50 * time.Millisecond)
+	stateListener.AssertCalled(t, "OnTransformToOpen", circuitbreaker.Closed, mock.Anything, mock.MatchedBy(func(observed uint64) bool {
+		return observed >= 50
+	}))
+	_, b := sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+	assert.True(t, b != nil && b.BlockType() == base.BlockTypeCircuitBreaking)
+
+	// The slow requests completed concurrently are all evaluated, so the circuit breaker opens
+	// once the last of them completes.
+	cbRule.Resource = "def"
+	_, err = circuitbreaker.LoadRules([]*circuitbreaker.Rule{cbRule})
+	assert.Nil(t, err)
+	entries := make([]*base.SentinelEntry, 0, 10)
+	for i := 0; i < 10; i++ {
+		e, b := sentinel.Entry("def", sentinel.WithSlotChain(sc))
+		assert.True(t, b == nil)
+		entries = append(entries, e)
+	}
+	util.Sleep(50 * time.Millisecond)
+	wg := &sync.WaitGroup{}
+	for _, e := range entries {
+		wg.Add(1)
+		go func(e *base.SentinelEntry) {
+			defer wg.Done()
+			e.Exit()
+		}(e)
+	}
+	wg.Wait()
+	_, b = sentinel.Entry("def", sentinel.WithSlotChain(sc))
+	assert.True(t, b != nil && b.BlockType() == base.BlockTypeCircuitBreaking)
+
+	circuitbreaker.ClearStateChangeListeners()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+}
+
+func TestCircuitBreakerSlotIntegration_StateTransitionHistory(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+
+	circuitbreaker.ClearStateChangeListeners()
+	circuitbreaker.ClearStateTransitionHistory()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Log.Logger = logging.NewConsoleLogger()
+	err := sentinel.InitWithConfig(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cbRule := &circuitbreaker.Rule{
+		Id:               "rule-1",
+		Resource:         "abc",
+		Strategy:         circuitbreaker.ErrorRatio,
+		RetryTimeoutMs:   100,
+		MinRequestAmount: 0,
+		StatIntervalMs:   10000,
+		Threshold:        0.5,
+	}
+	_, err = circuitbreaker.LoadRules([]*circuitbreaker.Rule{cbRule})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sc := base.NewSlotChain()
+	sc.AddRuleCheckSlot(&circuitbreaker.Slot{})
+	sc.AddStatSlot(&circuitbreaker.MetricStatSlot{})
+
+	e, b := sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+	assert.True(t, b == nil)
+	sentinel.TraceError(e, errors.New("biz error"))
+	e.Exit()
+	util.Sleep(150 * time.Millisecond)
+	e, b = sentinel.Entry("abc", sentinel.WithSlotChain(sc))
+	assert.True(t, b == nil)
+	e.Exit()
+
+	history := circuitbreaker.GetStateTransitionHistory("abc")
+	assert.Equal(t, 3, len(history))
+	assert.Equal(t, circuitbreaker.Closed, history[0].FromState)
+	assert.Equal(t, circuitbreaker.Open, history[0].ToState)
+	assert.Equal(t, "rule-1", history[0].RuleId)
+	assert.Equal(t, 1.0, history[0].Snapshot)
+	assert.Equal(t, circuitbreaker.HalfOpen, history[1].ToState)
+	assert.Equal(t, circuitbreaker.Closed, history[2].ToState)
+
+	rec := httptest.NewRecorder()
+	circuitbreaker.StateTransitionHistoryHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/circuitbreaker/history?resource=abc", nil))
+	resp := make(map[string][]map[string]interface{})
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 3, len(resp["abc"]))
+	assert.Equal(t, "Open", resp["abc"][0]["toState"])
+
+	// The history is removed with the circuit breakers of the resource.
+	_, err = circuitbreaker.LoadRules([]*circuitbreaker.Rule{cbRule})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(circuitbreaker.GetStateTransitionHistory("abc")))
+	otherRule := *cbRule
+	otherRule.Resource = "def"
+	_, err = circuitbreaker.LoadRules([]*circuitbreaker.Rule{&otherRule})
+	assert.Nil(t, err)
+	assert.Nil(t, circuitbreaker.GetStateTransitionHistory("abc"))
+	assert.Equal(t, 0, len(circuitbreaker.GetAllStateTransitionHistory()))
+
+	circuitbreaker.ClearStateTransitionHistory()
+	if clearErr := circuitbreaker.ClearRules(); clearErr != nil {
+		t.Fatal(clearErr)
+	}
+}
@@ -0,0 +1,139 @@
+package isolation
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	sentinel "github.com/alibaba/sentinel-golang/api"
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBulkheadSlotChain() *base.SlotChain {
+	sc := base.NewSlotChain()
+	sc.AddStatPrepareSlot(stat.DefaultResourceNodePrepareSlot)
+	sc.AddRuleCheckSlot(isolation.DefaultSlot)
+	sc.AddStatSlot(stat.DefaultSlot)
+	sc.AddStatSlot(isolation.DefaultStatSlot)
+	return sc
+}
+
+func TestBulkheadIsolation(t *testing.T) {
+	res := "bulkhead-test"
+	_, err := isolation.LoadRules([]*isolation.Rule{
+		{
+			Resource:          res,
+			MetricType:        isolation.Concurrency,
+			Threshold:         1,
+			MaxQueueingCount:  2,
+			MaxQueueingTimeMs: 500,
+		},
+	})
+	assert.Nil(t, err)
+	defer isolation.ClearRules()
+	sc := newBulkheadSlotChain()
+
+	e, b := sentinel.Entry(res, sentinel.WithSlotChain(sc))
+	assert.Nil(t, b)
+
+	// Two callers wait in FIFO order and the third one is rejected immediately.
+	order := make(chan int, 2)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			e, b := sentinel.Entry(res, sentinel.WithSlotChain(sc))
+			if !assert.Nil(t, b) {
+				return
+			}
+			order <- i
+			time.Sleep(20 * time.Millisecond)
+			e.Exit()
+		}(i)
+		// Make sure the callers are queued in order.
+		time.Sleep(20 * time.Millisecond)
+	}
+	_, b = sentinel.Entry(res, sentinel.WithSlotChain(sc))
+	assert.NotNil(t, b)
+	assert.Equal(t, base.BlockTypeIsolation, b.BlockType())
+
+	e.Exit()
+	wg.Wait()
+	close(order)
+	got := make([]int, 0, 2)
+	for i := range order {
+		got = append(got, i)
+	}
+	assert.Equal(t, []int{0, 1}, got)
+
+	// The caller waiting for too long is rejected.
+	e, b = sentinel.Entry(res, sentinel.WithSlotChain(sc))
+	assert.Nil(t, b)
+	start := time.Now()
+	_, b = sentinel.Entry(res, sentinel.WithSlotChain(sc))
+	assert.NotNil(t, b)
+	assert.True(t, time.Since(start) >= 500*time.Millisecond)
+
+	// The waiting stops when the context is canceled.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	_, b = sentinel.Entry(res, sentinel.WithSlotChain(sc), sentinel.WithContext(ctx))
+	assert.NotNil(t, b)
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+	e.Exit()
+
+	// The slot is free again.
+	e, b = sentinel.Entry(res, sentinel.WithSlotChain(sc))
+	assert.Nil(t, b)
+	e.Exit()
+}
+
+func TestBulkheadReservationReleasedWithoutStatSlot(t *testing.T) {
+	res := "bulkhead-without-stat-slot-test"
+	_, err := isolation.LoadRules([]*isolation.Rule{
+		{
+			Resource:          res,
+			MetricType:        isolation.Concurrency,
+			Threshold:         1,
+			MaxQueueingCount:  1,
+			MaxQueueingTimeMs: 200,
+		},
+	})
+	assert.Nil(t, err)
+	defer isolation.ClearRules()
+	sc := newBulkheadSlotChain()
+	// The custom slot chain doesn't release the reservation when the concurrency is counted.
+	customSc := base.NewSlotChain()
+	customSc.AddStatPrepareSlot(stat.DefaultResourceNodePrepareSlot)
+	customSc.AddRuleCheckSlot(isolation.DefaultSlot)
+	customSc.AddStatSlot(stat.DefaultSlot)
+
+	e, b := sentinel.Entry(res, sentinel.WithSlotChain(sc))
+	assert.Nil(t, b)
+	passed := make(chan *base.SentinelEntry, 1)
+	go func() {
+		e, b := sentinel.Entry(res, sentinel.WithSlotChain(customSc))
+		if assert.Nil(t, b) {
+			passed <- e
+		}
+		close(passed)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	e.Exit()
+	e = <-passed
+	if !assert.NotNil(t, e) {
+		return
+	}
+	e.Exit()
+
+	// The reservation is released when the entry exits.
+	e, b = sentinel.Entry(res, sentinel.WithSlotChain(sc))
+	assert.Nil(t, b)
+	e.Exit()
+}
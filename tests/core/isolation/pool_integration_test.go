@@ -0,0 +1,108 @@
+package isolation
+
+import (
+	"testing"
+
+	sentinel "github.com/alibaba/sentinel-golang/api"
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsolationPool(t *testing.T) {
+	updated, err := isolation.LoadPools([]*isolation.Pool{
+		{
+			Name:      "db-pool",
+			Threshold: 4,
+			Members: []isolation.PoolMember{
+				{Resource: "pool-order", Reservation: 1, Cap: 3},
+				{ResourcePattern: "^pool-user-.*", Reservation: 1},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.True(t, updated)
+	defer isolation.ClearPools()
+	sc := newBulkheadSlotChain()
+
+	// The order member takes its reservation and the shared slots up to its cap.
+	entries := make([]*base.SentinelEntry, 0)
+	for i := 0; i < 3; i++ {
+		e, b := sentinel.Entry("pool-order", sentinel.WithSlotChain(sc))
+		assert.Nil(t, b)
+		entries = append(entries, e)
+	}
+	_, b := sentinel.Entry("pool-order", sentinel.WithSlotChain(sc))
+	assert.NotNil(t, b)
+	assert.Equal(t, base.BlockTypeIsolation, b.BlockType())
+	assert.Equal(t, "db-pool", b.TriggeredRule().ResourceName())
+
+	// The reservation of the user member is still available.
+	e, b := sentinel.Entry("pool-user-query", sentinel.WithSlotChain(sc))
+	assert.Nil(t, b)
+	entries = append(entries, e)
+	_, b = sentinel.Entry("pool-user-update", sentinel.WithSlotChain(sc))
+	assert.NotNil(t, b)
+
+	usage, ok := isolation.GetPoolUsage("db-pool")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(4), usage.Concurrency)
+	assert.Equal(t, uint32(3), usage.Members["pool-order"])
+	assert.Equal(t, uint32(1), usage.Members["^pool-user-.*"])
+
+	// The resource out of pool is not limited.
+	e, b = sentinel.Entry("pool-other", sentinel.WithSlotChain(sc))
+	assert.Nil(t, b)
+	e.Exit()
+
+	for _, e := range entries {
+		e.Exit()
+	}
+	usage, _ = isolation.GetPoolUsage("db-pool")
+	assert.Equal(t, uint32(0), usage.Concurrency)
+
+	// The shared slots released by the order member could be taken by the user member.
+	entries = entries[:0]
+	for i := 0; i < 3; i++ {
+		e, b := sentinel.Entry("pool-user-query", sentinel.WithSlotChain(sc))
+		assert.Nil(t, b)
+		entries = append(entries, e)
+	}
+	_, b = sentinel.Entry("pool-user-query", sentinel.WithSlotChain(sc))
+	assert.NotNil(t, b)
+	for _, e := range entries {
+		e.Exit()
+	}
+}
+
+func TestIsolationPoolReleasedWithoutStatSlot(t *testing.T) {
+	_, err := isolation.LoadPools([]*isolation.Pool{
+		{
+			Name:      "cache-pool",
+			Threshold: 1,
+			Members: []isolation.PoolMember{
+				{Resource: "pool-cache"},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	defer isolation.ClearPools()
+	// The custom slot chain without isolation.DefaultStatSlot.
+	sc := base.NewSlotChain()
+	sc.AddStatPrepareSlot(stat.DefaultResourceNodePrepareSlot)
+	sc.AddRuleCheckSlot(isolation.DefaultSlot)
+	sc.AddStatSlot(stat.DefaultSlot)
+
+	for i := 0; i < 3; i++ {
+		e, b := sentinel.Entry("pool-cache", sentinel.WithSlotChain(sc))
+		if !assert.Nil(t, b) {
+			return
+		}
+		_, b = sentinel.Entry("pool-cache", sentinel.WithSlotChain(sc))
+		assert.NotNil(t, b)
+		e.Exit()
+	}
+	usage, _ := isolation.GetPoolUsage("cache-pool")
+	assert.Equal(t, uint32(0), usage.Concurrency)
+}
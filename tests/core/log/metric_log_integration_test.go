@@ -0,0 +1,112 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/log/metric"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricItemJsonString(t *testing.T) {
+	item := &base.MetricItem{
+		Resource:       "a|b",
+		Classification: int32(base.ResTypeWeb),
+		Timestamp:      1600000000000,
+		PassQps:        10,
+		BlockQps:       2,
+		CompleteQps:    9,
+		ErrorQps:       1,
+		AvgRt:          5,
+		Concurrency:    3,
+		P99Rt:          20,
+		MaxRt:          30,
+	}
+	s, err := item.ToJsonString()
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(s, "\n"))
+	assert.True(t, strings.Contains(s, `"resource":"a|b"`))
+
+	parsed, err := base.MetricItemFromJsonString(s)
+	assert.NoError(t, err)
+	assert.Equal(t, item, parsed)
+
+	// Unknown fields are ignored and absent fields are zero, so that the format is forward compatible.
+	parsed, err = base.MetricItemFromJsonString(`{"timestamp":1600000000000,"resource":"abc","passQps":3,"futureField":{"x":1}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, &base.MetricItem{Resource: "abc", Timestamp: 1600000000000, PassQps: 3}, parsed)
+
+	_, err = base.MetricItemFromJsonString(`{"resource":"abc"}`)
+	assert.Error(t, err)
+	_, err = base.MetricItemFromJsonString(`{"timestamp":`)
+	assert.Error(t, err)
+}
+
+func TestJsonMetricLogWriterAndSearcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sentinel-metric-log")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Log.Dir = dir
+	config.ResetGlobalConfig(conf)
+	defer config.ResetGlobalConfig(config.NewDefaultConfig())
+
+	appName := "metric-log-format-test"
+	now := util.CurrentTimeMillis() / 1000 * 1000
+	// The fat writer and the JSON writer write to different files of the same day.
+	fatWriter, err := metric.NewDefaultMetricLogWriterWithFormat(1024*1024, 8, appName, config.MetricLogFormatFat)
+	assert.NoError(t, err)
+	assert.NoError(t, fatWriter.Write(now+1000, []*base.MetricItem{{Resource: "res-a", PassQps: 1, P99Rt: 7}}))
+	assert.NoError(t, fatWriter.(*metric.DefaultMetricLogWriter).Close())
+
+	jsonWriter, err := metric.NewDefaultMetricLogWriterWithFormat(1024*1024, 8, appName, config.MetricLogFormatJson)
+	assert.NoError(t, err)
+	assert.NoError(t, jsonWriter.Write(now+2000, []*base.MetricItem{
+		{Resource: "res-a", PassQps: 2, MaxRt: 9},
+		{Resource: "res-b", BlockQps: 3},
+	}))
+	assert.NoError(t, jsonWriter.(*metric.DefaultMetricLogWriter).Close())
+
+	_, err = metric.NewDefaultMetricLogWriterWithFormat(1024*1024, 8, appName, "xml")
+	assert.Error(t, err)
+
+	baseFilename := metric.FormMetricFileName(appName, false)
+	files, err := filepath.Glob(filepath.Join(dir, baseFilename+".*"))
+	assert.NoError(t, err)
+	jsonLines := 0
+	for _, f := range files {
+		if strings.HasSuffix(f, metric.MetricIdxSuffix) {
+			continue
+		}
+		content, err := ioutil.ReadFile(f)
+		assert.NoError(t, err)
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			if strings.HasPrefix(line, "{") {
+				jsonLines++
+			}
+		}
+	}
+	assert.Equal(t, 2, jsonLines)
+
+	searcher, err := metric.NewDefaultMetricSearcher(dir, baseFilename)
+	assert.NoError(t, err)
+	items, err := searcher.FindByTimeAndResource(now+1000, now+2000, "res-a")
+	assert.NoError(t, err)
+	if assert.Len(t, items, 2) {
+		assert.Equal(t, uint64(1), items[0].PassQps)
+		assert.Equal(t, uint64(7), items[0].P99Rt)
+		assert.Equal(t, now+2000, items[1].Timestamp)
+		assert.Equal(t, uint64(2), items[1].PassQps)
+		assert.Equal(t, uint64(9), items[1].MaxRt)
+	}
+	items, err = searcher.FindFromTimeWithMaxLines(now+2000, 10)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+}
@@ -0,0 +1,274 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/log/metric"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const retentionTestApp = "metric-log-retention-test"
+
+func withMetricLogDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "sentinel-metric-retention")
+	assert.NoError(t, err)
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Log.Dir = dir
+	config.ResetGlobalConfig(conf)
+	return dir, func() {
+		config.ResetGlobalConfig(config.NewDefaultConfig())
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func listFiles(t *testing.T, dir string) []string {
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	return names
+}
+
+func countSuffix(names []string, suffix string) int {
+	n := 0
+	for _, name := range names {
+		if strings.HasSuffix(name, suffix) {
+			n++
+		}
+	}
+	return n
+}
+
+// createOldMetricFile creates a metric file and its index file of the given date with the given modification time.
+func createOldMetricFile(t *testing.T, dir, date string, size int, modTime time.Time) string {
+	filename := filepath.Join(dir, metric.FormMetricFileName(retentionTestApp, false)+"."+date)
+	assert.NoError(t, ioutil.WriteFile(filename, make([]byte, size), 0644))
+	assert.NoError(t, ioutil.WriteFile(filename+metric.MetricIdxSuffix, nil, 0644))
+	assert.NoError(t, os.Chtimes(filename, modTime, modTime))
+	return filename
+}
+
+func TestGzipCompressedMetricLog(t *testing.T) {
+	dir, cleanup := withMetricLogDir(t)
+	defer cleanup()
+
+	// Each write exceeds the max size of a single file, so the file is rolled and compressed after each write.
+	w, err := metric.NewDefaultMetricLogWriterWithOptions(10, 8, retentionTestApp, metric.MetricLogWriterOptions{
+		Compression: config.MetricLogCompressionGzip,
+	})
+	assert.NoError(t, err)
+	defer w.(*metric.DefaultMetricLogWriter).Close()
+
+	now := util.CurrentTimeMillis() / 1000 * 1000
+	for i := uint64(1); i <= 3; i++ {
+		assert.NoError(t, w.Write(now+i*1000, []*base.MetricItem{
+			{Resource: "res-a", PassQps: i},
+			{Resource: "res-b", BlockQps: i},
+		}))
+	}
+	// The rolled files are compressed in the background.
+	var names []string
+	assert.Eventually(t, func() bool {
+		names = listFiles(t, dir)
+		return countSuffix(names, metric.GzipSuffix) == 3
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 4, countSuffix(names, metric.MetricIdxSuffix))
+	assert.Equal(t, 0, countSuffix(names, ".tmp"))
+
+	// The compressed file is a valid gzip file.
+	for _, name := range names {
+		if !strings.HasSuffix(name, metric.GzipSuffix) {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		r, err := gzip.NewReader(f)
+		assert.NoError(t, err)
+		content, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, strings.Count(string(content), "\n"))
+		_ = f.Close()
+	}
+
+	searcher, err := metric.NewDefaultMetricSearcher(dir, metric.FormMetricFileName(retentionTestApp, false))
+	assert.NoError(t, err)
+	items, err := searcher.FindByTimeAndResource(now+2000, now+3000, "res-a")
+	assert.NoError(t, err)
+	if assert.Len(t, items, 2) {
+		assert.Equal(t, uint64(2), items[0].PassQps)
+		assert.Equal(t, uint64(3), items[1].PassQps)
+	}
+	items, err = searcher.FindFromTimeWithMaxLines(now+1000, 100)
+	assert.NoError(t, err)
+	assert.Len(t, items, 6)
+}
+
+func TestZstdCompressedMetricLog(t *testing.T) {
+	dir, cleanup := withMetricLogDir(t)
+	defer cleanup()
+
+	w, err := metric.NewDefaultMetricLogWriterWithOptions(10, 8, retentionTestApp, metric.MetricLogWriterOptions{
+		Compression: config.MetricLogCompressionZstd,
+	})
+	assert.NoError(t, err)
+	defer w.(*metric.DefaultMetricLogWriter).Close()
+
+	now := util.CurrentTimeMillis() / 1000 * 1000
+	for i := uint64(1); i <= 3; i++ {
+		assert.NoError(t, w.Write(now+i*1000, []*base.MetricItem{{Resource: "res-a", PassQps: i}}))
+	}
+	assert.Eventually(t, func() bool {
+		return countSuffix(listFiles(t, dir), metric.ZstdSuffix) == 3
+	}, 3*time.Second, 10*time.Millisecond)
+
+	searcher, err := metric.NewDefaultMetricSearcher(dir, metric.FormMetricFileName(retentionTestApp, false))
+	assert.NoError(t, err)
+	items, err := searcher.FindByTimeAndResource(now+1000, now+3000, "res-a")
+	assert.NoError(t, err)
+	if assert.Len(t, items, 3) {
+		assert.Equal(t, uint64(1), items[0].PassQps)
+		assert.Equal(t, uint64(3), items[2].PassQps)
+	}
+}
+
+func TestMetricLogCompressionConfig(t *testing.T) {
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Log.Metric.Compression = config.MetricLogCompressionGzip
+	assert.NoError(t, config.CheckValid(conf))
+	conf.Sentinel.Log.Metric.Compression = config.MetricLogCompressionZstd
+	assert.NoError(t, config.CheckValid(conf))
+	conf.Sentinel.Log.Metric.Compression = "lz4"
+	assert.Error(t, config.CheckValid(conf))
+}
+
+type identityCompressor struct{}
+
+func (c *identityCompressor) Suffix() string {
+	return ".identity"
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (w nopWriteCloser) Close() error {
+	return nil
+}
+
+func (c *identityCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+func (c *identityCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(r), nil
+}
+
+func TestRegisteredMetricLogCompressor(t *testing.T) {
+	dir, cleanup := withMetricLogDir(t)
+	defer cleanup()
+
+	_, err := metric.NewDefaultMetricLogWriterWithOptions(10, 8, retentionTestApp, metric.MetricLogWriterOptions{
+		Compression: "identity",
+	})
+	assert.Error(t, err)
+
+	metric.RegisterMetricLogCompressor("identity", &identityCompressor{})
+	w, err := metric.NewDefaultMetricLogWriterWithOptions(10, 8, retentionTestApp, metric.MetricLogWriterOptions{
+		Compression: "identity",
+		Format:      config.MetricLogFormatJson,
+	})
+	assert.NoError(t, err)
+	defer w.(*metric.DefaultMetricLogWriter).Close()
+
+	now := util.CurrentTimeMillis() / 1000 * 1000
+	assert.NoError(t, w.Write(now+1000, []*base.MetricItem{{Resource: "res-a", PassQps: 1}}))
+	assert.NoError(t, w.Write(now+2000, []*base.MetricItem{{Resource: "res-a", PassQps: 2}}))
+	assert.Eventually(t, func() bool {
+		return countSuffix(listFiles(t, dir), ".identity") == 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	searcher, err := metric.NewDefaultMetricSearcher(dir, metric.FormMetricFileName(retentionTestApp, false))
+	assert.NoError(t, err)
+	items, err := searcher.FindByTimeAndResource(now+2000, now+2000, "res-a")
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, uint64(2), items[0].PassQps)
+	}
+}
+
+func TestMetricLogRetention(t *testing.T) {
+	t.Run("MaxFileAmount", func(t *testing.T) {
+		dir, cleanup := withMetricLogDir(t)
+		defer cleanup()
+
+		w, err := metric.NewDefaultMetricLogWriterWithOptions(10, 2, retentionTestApp, metric.MetricLogWriterOptions{
+			Compression: config.MetricLogCompressionGzip,
+		})
+		assert.NoError(t, err)
+		defer w.(*metric.DefaultMetricLogWriter).Close()
+
+		now := util.CurrentTimeMillis() / 1000 * 1000
+		for i := uint64(1); i <= 5; i++ {
+			assert.NoError(t, w.Write(now+i*1000, []*base.MetricItem{{Resource: "res-a", PassQps: i}}))
+		}
+		assert.Eventually(t, func() bool {
+			names := listFiles(t, dir)
+			return countSuffix(names, metric.MetricIdxSuffix) == 2 && countSuffix(names, metric.GzipSuffix) == 1 &&
+				countSuffix(names, ".tmp") == 0
+		}, 3*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("MaxAgeDays", func(t *testing.T) {
+		dir, cleanup := withMetricLogDir(t)
+		defer cleanup()
+
+		expired := createOldMetricFile(t, dir, "2000-01-01", 10, time.Now().Add(-8*24*time.Hour))
+		kept := createOldMetricFile(t, dir, "2000-01-02", 10, time.Now().Add(-6*24*time.Hour))
+		w, err := metric.NewDefaultMetricLogWriterWithOptions(1024, 8, retentionTestApp, metric.MetricLogWriterOptions{
+			MaxAgeDays: 7,
+		})
+		assert.NoError(t, err)
+		defer w.(*metric.DefaultMetricLogWriter).Close()
+
+		_, err = os.Stat(expired)
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(expired + metric.MetricIdxSuffix)
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(kept)
+		assert.NoError(t, err)
+	})
+
+	t.Run("TotalSizeLimit", func(t *testing.T) {
+		dir, cleanup := withMetricLogDir(t)
+		defer cleanup()
+
+		oldest := createOldMetricFile(t, dir, "2000-01-01", 300, time.Now())
+		older := createOldMetricFile(t, dir, "2000-01-02", 300, time.Now())
+		newer := createOldMetricFile(t, dir, "2000-01-03", 300, time.Now())
+		// 300 * 2 bytes of the rolled files and 100 bytes of the room for the current file.
+		w, err := metric.NewDefaultMetricLogWriterWithOptions(100, 8, retentionTestApp, metric.MetricLogWriterOptions{
+			TotalSizeLimit: 700,
+		})
+		assert.NoError(t, err)
+		defer w.(*metric.DefaultMetricLogWriter).Close()
+
+		_, err = os.Stat(oldest)
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(older)
+		assert.NoError(t, err)
+		_, err = os.Stat(newer)
+		assert.NoError(t, err)
+	})
+}
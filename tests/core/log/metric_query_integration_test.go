@@ -0,0 +1,144 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/log/metric"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricSearcherQuery(t *testing.T) {
+	dir, cleanup := withMetricLogDir(t)
+	defer cleanup()
+
+	appName := "metric-query-test"
+	w, err := metric.NewDefaultMetricLogWriterWithOptions(1024*1024, 8, appName, metric.MetricLogWriterOptions{})
+	assert.NoError(t, err)
+	defer w.(*metric.DefaultMetricLogWriter).Close()
+
+	// 20 seconds aligned to the 20s buckets (so aligned to the 10s buckets too).
+	begin := (util.CurrentTimeMillis()/20000 + 1) * 20000
+	for i := uint64(0); i < 20; i++ {
+		items := []*base.MetricItem{
+			{Resource: "web:/a", Classification: int32(base.ResTypeWeb), PassQps: 10, BlockQps: 1, CompleteQps: 10, AvgRt: 10, MaxRt: 20 + i},
+			{Resource: "web:/b", Classification: int32(base.ResTypeWeb), PassQps: 20, BlockQps: 5, CompleteQps: 10, AvgRt: 30, MaxRt: 40},
+		}
+		// rpc:c only has traffic in the even seconds.
+		if i%2 == 0 {
+			items = append(items, &base.MetricItem{Resource: "rpc:c", Classification: int32(base.ResTypeRPC), PassQps: 4, BlockQps: 2, CompleteQps: 4, AvgRt: 5})
+		}
+		assert.NoError(t, w.Write(begin+i*1000, items))
+	}
+	end := begin + 19*1000
+
+	searcher, err := metric.NewDefaultMetricSearcher(dir, metric.FormMetricFileName(appName, false))
+	assert.NoError(t, err)
+	querier, ok := searcher.(metric.MetricQuerier)
+	assert.True(t, ok)
+
+	t.Run("PrefixSumDownsampling", func(t *testing.T) {
+		series, err := querier.Query(&metric.MetricQuery{
+			BeginTimeMs:    begin,
+			EndTimeMs:      end,
+			ResourcePrefix: "web:",
+			IntervalSec:    10,
+			Aggregation:    metric.AggregationSum,
+		})
+		assert.NoError(t, err)
+		if assert.Len(t, series, 2) {
+			assert.Equal(t, "web:/a", series[0].Group)
+			if assert.Len(t, series[0].Points, 2) {
+				p := series[0].Points[0]
+				assert.Equal(t, begin, p.Timestamp)
+				assert.Equal(t, uint64(100), p.PassQps)
+				assert.Equal(t, uint64(10), p.BlockQps)
+				assert.Equal(t, uint64(10), p.AvgRt)
+				assert.Equal(t, uint64(29), p.MaxRt)
+				assert.Equal(t, begin+10000, series[0].Points[1].Timestamp)
+				assert.Equal(t, uint64(39), series[0].Points[1].MaxRt)
+			}
+			assert.Equal(t, "web:/b", series[1].Group)
+		}
+	})
+
+	t.Run("ResourcesAvgAndMax", func(t *testing.T) {
+		series, err := querier.Query(&metric.MetricQuery{
+			BeginTimeMs: begin,
+			EndTimeMs:   end,
+			Resources:   []string{"rpc:c"},
+			IntervalSec: 10,
+			Aggregation: metric.AggregationAvg,
+		})
+		assert.NoError(t, err)
+		if assert.Len(t, series, 1) && assert.Len(t, series[0].Points, 2) {
+			// The seconds without traffic count as 0.
+			assert.Equal(t, uint64(2), series[0].Points[0].PassQps)
+			assert.Equal(t, uint64(1), series[0].Points[0].BlockQps)
+		}
+
+		series, err = querier.Query(&metric.MetricQuery{
+			BeginTimeMs: begin,
+			EndTimeMs:   end,
+			Resources:   []string{"rpc:c", "web:/b"},
+			IntervalSec: 20,
+			Aggregation: metric.AggregationMax,
+		})
+		assert.NoError(t, err)
+		if assert.Len(t, series, 2) {
+			assert.Equal(t, "rpc:c", series[0].Group)
+			assert.Equal(t, "web:/b", series[1].Group)
+			if assert.Len(t, series[1].Points, 1) {
+				assert.Equal(t, uint64(20), series[1].Points[0].PassQps)
+			}
+		}
+	})
+
+	t.Run("GroupByClassification", func(t *testing.T) {
+		series, err := querier.Query(&metric.MetricQuery{
+			BeginTimeMs: begin,
+			EndTimeMs:   begin + 9000,
+			GroupBy:     metric.GroupByClassification,
+			IntervalSec: 10,
+		})
+		assert.NoError(t, err)
+		if assert.Len(t, series, 2) {
+			assert.Equal(t, int32(base.ResTypeWeb), series[0].Classification)
+			if assert.Len(t, series[0].Points, 1) {
+				p := series[0].Points[0]
+				assert.Equal(t, uint64(300), p.PassQps)
+				assert.Equal(t, uint64(60), p.BlockQps)
+				// The RT is weighted by the complete QPS of the resources.
+				assert.Equal(t, uint64(20), p.AvgRt)
+				assert.Equal(t, uint64(40), p.MaxRt)
+			}
+			assert.Equal(t, int32(base.ResTypeRPC), series[1].Classification)
+		}
+	})
+
+	t.Run("TopNByBlockQps", func(t *testing.T) {
+		series, err := querier.Query(&metric.MetricQuery{
+			BeginTimeMs: begin,
+			EndTimeMs:   end,
+			IntervalSec: 1,
+			TopN:        2,
+		})
+		assert.NoError(t, err)
+		if assert.Len(t, series, 2) {
+			assert.Equal(t, "web:/b", series[0].Group)
+			assert.Equal(t, "rpc:c", series[1].Group)
+			assert.Len(t, series[0].Points, 20)
+			assert.Len(t, series[1].Points, 10)
+		}
+	})
+
+	t.Run("InvalidQuery", func(t *testing.T) {
+		_, err := querier.Query(nil)
+		assert.Error(t, err)
+		_, err = querier.Query(&metric.MetricQuery{BeginTimeMs: end, EndTimeMs: begin})
+		assert.Error(t, err)
+		_, err = querier.Query(&metric.MetricQuery{BeginTimeMs: begin, EndTimeMs: end, Aggregation: 10})
+		assert.Error(t, err)
+	})
+}
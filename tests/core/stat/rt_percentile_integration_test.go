@@ -0,0 +1,130 @@
+package stat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	sbase "github.com/alibaba/sentinel-golang/core/stat/base"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricBucketRtHistogram(t *testing.T) {
+	mb := sbase.NewMetricBucket()
+	// The histogram is allocated when the first RT is recorded.
+	assert.Nil(t, mb.RtHistogram())
+	mb.Add(base.MetricEventPass, 1)
+	assert.Nil(t, mb.RtHistogram())
+	for rt := int64(1); rt <= 100; rt++ {
+		mb.Add(base.MetricEventRt, rt)
+	}
+	assert.Equal(t, int64(1), mb.MinRt())
+	assert.Equal(t, int64(100), mb.MaxRt())
+	assert.Equal(t, uint64(100), mb.RtHistogram().Count())
+	// The error of the percentile is within 1/8 of the value.
+	assert.InEpsilon(t, 50, float64(mb.RtHistogram().Percentile(50)), 0.125)
+	assert.InEpsilon(t, 99, float64(mb.RtHistogram().Percentile(99)), 0.125)
+}
+
+func TestRtPercentileClampedToMaxRt(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+
+	h := sbase.NewHistogram()
+	for i := 0; i < 10; i++ {
+		h.Record(100)
+	}
+	// The upper bound of the bucket of 100 is 103, but no request reached it.
+	assert.Equal(t, uint64(100), h.Percentile(99))
+	assert.Equal(t, uint64(100), h.Max())
+	merged := sbase.NewHistogram()
+	merged.Merge(h)
+	assert.Equal(t, uint64(100), merged.Percentile(99))
+	h.Reset()
+	assert.Equal(t, uint64(0), h.Max())
+
+	node := stat.NewResourceNode("rt-percentile-clamp", base.ResTypeCommon)
+	start := util.CurrentTimeMillis()
+	for i := 0; i < 10; i++ {
+		node.AddCount(base.MetricEventRt, 100)
+		node.AddCount(base.MetricEventComplete, 1)
+	}
+	assert.Equal(t, 100.0, node.RtPercentile(99))
+
+	util.Sleep(time.Second)
+	items := node.MetricsOnCondition(func(ts uint64) bool {
+		return ts <= start
+	})
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, uint64(100), items[0].MaxRt)
+		assert.Equal(t, uint64(100), items[0].P50Rt)
+		assert.Equal(t, uint64(100), items[0].P99Rt)
+	}
+}
+
+func TestNodeRtPercentile(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+
+	node := stat.NewResourceNode("rt-percentile", base.ResTypeCommon)
+	_, ok := interface{}(node.DefaultMetric()).(base.RtDistributionStat)
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, node.RtPercentile(99))
+	assert.Equal(t, 0.0, node.MaxRT())
+
+	start := util.CurrentTimeMillis()
+	for i := 0; i < 98; i++ {
+		node.AddCount(base.MetricEventRt, 10)
+		node.AddCount(base.MetricEventComplete, 1)
+	}
+	node.AddCount(base.MetricEventRt, 500)
+	node.AddCount(base.MetricEventRt, 1000)
+	node.AddCount(base.MetricEventComplete, 2)
+
+	assert.Equal(t, 10.0, node.RtPercentile(50))
+	assert.Equal(t, 10.0, node.RtPercentile(90))
+	assert.InEpsilon(t, 500, node.RtPercentile(99), 0.125)
+	assert.Equal(t, 1000.0, node.MaxRT())
+	assert.Equal(t, 10.0, node.MinRT())
+
+	util.Sleep(time.Second)
+	items := node.MetricsOnCondition(func(ts uint64) bool {
+		return ts <= start
+	})
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, uint64(10), items[0].P50Rt)
+		assert.Equal(t, uint64(10), items[0].P90Rt)
+		assert.InEpsilon(t, 500, float64(items[0].P99Rt), 0.125)
+		assert.Equal(t, uint64(1000), items[0].MaxRt)
+	}
+}
+
+func TestMetricItemRtPercentiles(t *testing.T) {
+	item := &base.MetricItem{
+		Resource:    "abc",
+		Timestamp:   1600000000000,
+		PassQps:     10,
+		CompleteQps: 10,
+		AvgRt:       20,
+		P50Rt:       15,
+		P90Rt:       40,
+		P99Rt:       90,
+		MaxRt:       120,
+	}
+	s, err := item.ToFatString()
+	assert.Nil(t, err)
+	parsed, err := base.MetricItemFromFatString(s)
+	assert.Nil(t, err)
+	assert.Equal(t, item.P50Rt, parsed.P50Rt)
+	assert.Equal(t, item.P90Rt, parsed.P90Rt)
+	assert.Equal(t, item.P99Rt, parsed.P99Rt)
+	assert.Equal(t, item.MaxRt, parsed.MaxRt)
+
+	// The legacy metric line without the percentiles.
+	parsed, err = base.MetricItemFromFatString("1600000000000|2020-09-13 20:26:40|abc|10|0|10|0|20|0|0|0")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(20), parsed.AvgRt)
+	assert.Equal(t, uint64(0), parsed.P99Rt)
+}
@@ -0,0 +1,92 @@
+package stat
+
+import (
+	"testing"
+	"time"
+
+	sentinel "github.com/alibaba/sentinel-golang/api"
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStatSlotChain() *base.SlotChain {
+	sc := base.NewSlotChain()
+	sc.AddStatPrepareSlot(stat.DefaultResourceNodePrepareSlot)
+	sc.AddStatSlot(stat.DefaultSlot)
+	return sc
+}
+
+func TestSnapshot(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+	stat.ResetResourceNodeMap()
+	defer stat.ResetResourceNodeMap()
+
+	sc := newStatSlotChain()
+	for i := 0; i < 10; i++ {
+		e, b := sentinel.Entry("snapshot-web", sentinel.WithSlotChain(sc),
+			sentinel.WithResourceType(base.ResTypeWeb), sentinel.WithTrafficType(base.Inbound))
+		assert.Nil(t, b)
+		util.Sleep(5 * time.Millisecond)
+		if i%5 == 0 {
+			sentinel.TraceError(e, assert.AnError)
+		}
+		e.Exit()
+	}
+	for i := 0; i < 4; i++ {
+		e, b := sentinel.Entry("snapshot-rpc", sentinel.WithSlotChain(sc),
+			sentinel.WithResourceType(base.ResTypeRPC), sentinel.WithTrafficType(base.Outbound))
+		assert.Nil(t, b)
+		e.Exit()
+	}
+	held, b := sentinel.Entry("snapshot-rpc", sentinel.WithSlotChain(sc),
+		sentinel.WithResourceType(base.ResTypeRPC), sentinel.WithTrafficType(base.Outbound))
+	assert.Nil(t, b)
+	defer held.Exit()
+
+	all := stat.Snapshot()
+	if assert.Len(t, all, 2) {
+		assert.Equal(t, "snapshot-rpc", all[0].Resource)
+		assert.Equal(t, "snapshot-web", all[1].Resource)
+	}
+
+	web := stat.ResourceSnapshotOf("snapshot-web")
+	if assert.NotNil(t, web) {
+		assert.Equal(t, base.ResTypeWeb, web.ResourceType)
+		assert.Equal(t, []base.TrafficType{base.Inbound}, web.TrafficTypes)
+		assert.Equal(t, int32(0), web.CurrentConcurrency)
+		assert.Equal(t, 10.0, web.Second.PassQps)
+		assert.Equal(t, 10.0, web.Second.CompleteQps)
+		assert.Equal(t, 2.0, web.Second.ErrorQps)
+		assert.Equal(t, 0.0, web.Second.BlockQps)
+		assert.Equal(t, 5.0, web.Second.AvgRt)
+		assert.Equal(t, 5.0, web.Second.MinRt)
+		assert.Equal(t, int32(1), web.Second.MaxConcurrency)
+		assert.Equal(t, uint32(10000), web.Total.IntervalMs)
+		assert.Equal(t, 1.0, web.Total.PassQps)
+		assert.Equal(t, 5.0, web.Total.AvgRt)
+	}
+
+	rpc := stat.ResourceSnapshotOf("snapshot-rpc")
+	if assert.NotNil(t, rpc) {
+		assert.Equal(t, []base.TrafficType{base.Outbound}, rpc.TrafficTypes)
+		assert.Equal(t, int32(1), rpc.CurrentConcurrency)
+		assert.Equal(t, 5.0, rpc.Second.PassQps)
+		assert.Equal(t, 4.0, rpc.Second.CompleteQps)
+	}
+	assert.Nil(t, stat.ResourceSnapshotOf("snapshot-absent"))
+
+	webOnly := stat.Snapshot(stat.WithResourceTypes(base.ResTypeWeb))
+	if assert.Len(t, webOnly, 1) {
+		assert.Equal(t, "snapshot-web", webOnly[0].Resource)
+	}
+	outbound := stat.Snapshot(stat.WithTrafficTypes(base.Outbound))
+	if assert.Len(t, outbound, 1) {
+		assert.Equal(t, "snapshot-rpc", outbound[0].Resource)
+	}
+	assert.Empty(t, stat.Snapshot(stat.WithResourceTypes(base.ResTypeWeb), stat.WithTrafficTypes(base.Outbound)))
+
+	assert.Equal(t, base.TotalInBoundResourceName, stat.InboundSnapshot().Resource)
+}
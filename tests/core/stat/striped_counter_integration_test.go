@@ -0,0 +1,59 @@
+package stat
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	statbase "github.com/alibaba/sentinel-golang/core/stat/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripedCounter(t *testing.T) {
+	c := statbase.NewStripedCounter(5)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(32000), c.Sum())
+	c.Reset()
+	assert.Equal(t, int64(0), c.Sum())
+}
+
+func TestMetricBucketWithStripedCounter(t *testing.T) {
+	assert.Error(t, statbase.SetCounterType("unknown", 0))
+	assert.NoError(t, statbase.SetCounterType(base.CounterTypeStriped, 6))
+	defer statbase.SetCounterType(base.CounterTypeAtomic, 0)
+
+	counterType, stripes := statbase.CurrentCounterType()
+	assert.Equal(t, base.CounterTypeStriped, counterType)
+	assert.Equal(t, uint32(8), stripes)
+
+	mb := statbase.NewMetricBucket()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				mb.Add(base.MetricEventPass, 2)
+				mb.Add(base.MetricEventBlock, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(3200), mb.Get(base.MetricEventPass))
+	assert.Equal(t, int64(1600), mb.Get(base.MetricEventBlock))
+	assert.Equal(t, int64(0), mb.Get(base.MetricEventError))
+
+	bla := statbase.NewBucketLeapArray(2, 1000)
+	bla.AddCount(base.MetricEventComplete, 5)
+	assert.Equal(t, int64(5), bla.Count(base.MetricEventComplete))
+}
@@ -0,0 +1,383 @@
+package system
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sentinel "github.com/alibaba/sentinel-golang/api"
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/alibaba/sentinel-golang/core/system_metric"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSystemSlotChain() *base.SlotChain {
+	sc := base.NewSlotChain()
+	sc.AddStatPrepareSlot(stat.DefaultResourceNodePrepareSlot)
+	sc.AddRuleCheckSlot(system.DefaultAdaptiveSlot)
+	sc.AddStatSlot(stat.DefaultSlot)
+	sc.AddStatSlot(system.DefaultAdaptiveStatSlot)
+	return sc
+}
+
+func entryOf(sc *base.SlotChain, res string, resType base.ResourceType) (*base.SentinelEntry, *base.BlockError) {
+	return sentinel.Entry(res, sentinel.WithSlotChain(sc), sentinel.WithResourceType(resType),
+		sentinel.WithTrafficType(base.Inbound))
+}
+
+func TestScopedSystemRules(t *testing.T) {
+	webRule := &system.Rule{
+		MetricType:    system.Concurrency,
+		TriggerCount:  2,
+		ResourceTypes: []base.ResourceType{base.ResTypeWeb},
+	}
+	bizRule := &system.Rule{
+		MetricType:      system.Concurrency,
+		TriggerCount:    3,
+		Resources:       []string{"sys-order"},
+		ResourcePattern: "^sys-user-.*",
+	}
+	_, err := system.LoadRules([]*system.Rule{webRule, bizRule})
+	assert.Nil(t, err)
+	defer system.ClearRules()
+	sc := newSystemSlotChain()
+
+	e1, b := entryOf(sc, "sys-health", base.ResTypeRPC)
+	assert.Nil(t, b)
+	e2, b := entryOf(sc, "sys-page", base.ResTypeWeb)
+	assert.Nil(t, b)
+	// The RPC traffic isn't counted by the rule of web traffic.
+	e3, b := entryOf(sc, "sys-page", base.ResTypeWeb)
+	assert.Nil(t, b)
+
+	// The web traffic is shed while the RPC traffic keeps passing.
+	_, b = entryOf(sc, "sys-page", base.ResTypeWeb)
+	assert.NotNil(t, b)
+	assert.Equal(t, base.BlockTypeSystemFlow, b.BlockType())
+	e4, b := entryOf(sc, "sys-health", base.ResTypeRPC)
+	assert.Nil(t, b)
+
+	// The resources in the list or matching the pattern are counted together by the second rule.
+	e5, b := entryOf(sc, "sys-order", base.ResTypeRPC)
+	assert.Nil(t, b)
+	e6, b := entryOf(sc, "sys-user-query", base.ResTypeRPC)
+	assert.Nil(t, b)
+	e7, b := entryOf(sc, "sys-user-update", base.ResTypeRPC)
+	assert.Nil(t, b)
+	_, b = entryOf(sc, "sys-order", base.ResTypeRPC)
+	assert.NotNil(t, b)
+	_, b = entryOf(sc, "sys-user-query", base.ResTypeRPC)
+	assert.NotNil(t, b)
+
+	// The blocked requests are counted by the statistic of their own scope.
+	webNode := system.GetScopeStatNode(webRule)
+	assert.NotNil(t, webNode)
+	assert.Equal(t, int64(1), webNode.GetSum(base.MetricEventBlock))
+	assert.True(t, webNode.GetQPS(base.MetricEventBlock) > 0)
+	bizNode := system.GetScopeStatNode(bizRule)
+	assert.NotNil(t, bizNode)
+	assert.Equal(t, int64(2), bizNode.GetSum(base.MetricEventBlock))
+	assert.True(t, bizNode.GetQPS(base.MetricEventBlock) > 0)
+
+	for _, e := range []*base.SentinelEntry{e1, e2, e3, e4, e5, e6, e7} {
+		e.Exit()
+	}
+	e, b := entryOf(sc, "sys-user-query", base.ResTypeRPC)
+	assert.Nil(t, b)
+	e.Exit()
+}
+
+func TestScopedSystemRuleOutbound(t *testing.T) {
+	_, err := system.LoadRules([]*system.Rule{
+		{
+			MetricType:   system.Concurrency,
+			TriggerCount: 1,
+			Resources:    []string{"sys-downstream"},
+		},
+	})
+	assert.Nil(t, err)
+	defer system.ClearRules()
+	sc := newSystemSlotChain()
+
+	outbound := func(res string) (*base.SentinelEntry, *base.BlockError) {
+		return sentinel.Entry(res, sentinel.WithSlotChain(sc), sentinel.WithTrafficType(base.Outbound))
+	}
+	e, b := outbound("sys-downstream")
+	assert.Nil(t, b)
+	_, b = outbound("sys-downstream")
+	assert.NotNil(t, b)
+	e.Exit()
+	e, b = outbound("sys-downstream")
+	assert.Nil(t, b)
+	e.Exit()
+}
+
+func TestSystemRulePriority(t *testing.T) {
+	_, err := system.LoadRules([]*system.Rule{
+		{
+			MetricType:    system.Concurrency,
+			TriggerCount:  100,
+			ResourceTypes: []base.ResourceType{base.ResTypeWeb},
+			Priority:      0,
+		},
+		{
+			MetricType:    system.Concurrency,
+			TriggerCount:  2,
+			ResourceTypes: []base.ResourceType{base.ResTypeRPC},
+			Priority:      10,
+		},
+	})
+	assert.Nil(t, err)
+	defer system.ClearRules()
+	sc := newSystemSlotChain()
+
+	e1, b := entryOf(sc, "sys-rpc", base.ResTypeRPC)
+	assert.Nil(t, b)
+	e2, b := entryOf(sc, "sys-web", base.ResTypeWeb)
+	assert.Nil(t, b)
+	e3, b := entryOf(sc, "sys-rpc", base.ResTypeRPC)
+	assert.Nil(t, b)
+
+	// The rule of RPC traffic with higher priority is triggered, so the web traffic is shed too.
+	_, b = entryOf(sc, "sys-rpc", base.ResTypeRPC)
+	assert.NotNil(t, b)
+	_, b = entryOf(sc, "sys-web", base.ResTypeWeb)
+	assert.NotNil(t, b)
+	// The traffic out of all the scopes is not affected.
+	e, b := entryOf(sc, "sys-db", base.ResTypeDBSQL)
+	assert.Nil(t, b)
+	e.Exit()
+
+	e1.Exit()
+	e2.Exit()
+	e3.Exit()
+	e, b = entryOf(sc, "sys-web", base.ResTypeWeb)
+	assert.Nil(t, b)
+	e.Exit()
+}
+
+func TestRuntimeSignalSystemRules(t *testing.T) {
+	defer system_metric.SetGoroutines(system_metric.NotRetrievedGoroutinesValue)
+	defer system_metric.SetGcPause(system_metric.NotRetrievedGcPauseValue)
+	_, err := system.LoadRules([]*system.Rule{
+		{
+			MetricType:   system.Goroutines,
+			TriggerCount: 1000,
+		},
+		{
+			MetricType:   system.GcPause,
+			TriggerCount: 50,
+		},
+	})
+	assert.Nil(t, err)
+	defer system.ClearRules()
+	sc := newSystemSlotChain()
+
+	// The signals not retrieved yet pass by default.
+	e, b := entryOf(sc, "sys-runtime", base.ResTypeCommon)
+	assert.Nil(t, b)
+	e.Exit()
+
+	system_metric.SetGoroutines(500)
+	system_metric.SetGcPause(10)
+	e, b = entryOf(sc, "sys-runtime", base.ResTypeCommon)
+	assert.Nil(t, b)
+	e.Exit()
+
+	system_metric.SetGoroutines(2000)
+	_, b = entryOf(sc, "sys-runtime", base.ResTypeCommon)
+	assert.NotNil(t, b)
+	assert.Equal(t, system.Goroutines, b.TriggeredRule().(*system.Rule).MetricType)
+
+	system_metric.SetGoroutines(500)
+	system_metric.SetGcPause(80)
+	_, b = entryOf(sc, "sys-runtime", base.ResTypeCommon)
+	assert.NotNil(t, b)
+	assert.Equal(t, "gcPause", b.TriggeredRule().ResourceName())
+}
+
+func TestBBRLimiterSystemRule(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+	defer system_metric.SetSystemCpuUsage(system_metric.NotRetrievedCpuUsageValue)
+	_, err := system.LoadRules([]*system.Rule{
+		{
+			MetricType:     system.CpuUsage,
+			TriggerCount:   0.8,
+			Strategy:       system.BBRLimiter,
+			BbrWindowMs:    1000,
+			BbrBucketCount: 10,
+			BbrDecay:       0.1,
+			BbrCooldownMs:  500,
+		},
+	})
+	assert.Nil(t, err)
+	defer system.ClearRules()
+	sc := newSystemSlotChain()
+
+	// Fill the window with the requests whose RT is 10ms in a single bucket.
+	system_metric.SetSystemCpuUsage(0.1)
+	util.Sleep(100 * time.Millisecond)
+	for i := 0; i < 9; i++ {
+		e, b := entryOf(sc, "sys-bbr", base.ResTypeCommon)
+		assert.Nil(t, b)
+		util.Sleep(10 * time.Millisecond)
+		e.Exit()
+	}
+
+	// The smoothed CPU usage exceeds the threshold, the requests beyond the estimated capacity are shed.
+	util.Sleep(100 * time.Millisecond)
+	system_metric.SetSystemCpuUsage(0.9)
+	e1, b := entryOf(sc, "sys-bbr", base.ResTypeCommon)
+	assert.Nil(t, b)
+	e2, b := entryOf(sc, "sys-bbr", base.ResTypeCommon)
+	assert.Nil(t, b)
+	_, b = entryOf(sc, "sys-bbr", base.ResTypeCommon)
+	assert.NotNil(t, b)
+	assert.Equal(t, base.BlockTypeSystemFlow, b.BlockType())
+
+	// The shedding keeps going during the cooldown period though the CPU usage drops.
+	system_metric.SetSystemCpuUsage(0.1)
+	util.Sleep(100 * time.Millisecond)
+	_, b = entryOf(sc, "sys-bbr", base.ResTypeCommon)
+	assert.NotNil(t, b)
+
+	util.Sleep(500 * time.Millisecond)
+	e3, b := entryOf(sc, "sys-bbr", base.ResTypeCommon)
+	assert.Nil(t, b)
+	e1.Exit()
+	e2.Exit()
+	e3.Exit()
+}
+
+func TestScopedBBRLimiterSystemRule(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+	defer system_metric.SetSystemCpuUsage(system_metric.NotRetrievedCpuUsageValue)
+	_, err := system.LoadRules([]*system.Rule{
+		{
+			MetricType:     system.CpuUsage,
+			TriggerCount:   0.8,
+			Resources:      []string{"sys-bbr-out"},
+			Strategy:       system.BBRLimiter,
+			BbrWindowMs:    1000,
+			BbrBucketCount: 10,
+			BbrDecay:       0.1,
+			BbrCooldownMs:  500,
+		},
+	})
+	assert.Nil(t, err)
+	defer system.ClearRules()
+	sc := newSystemSlotChain()
+	outbound := func() (*base.SentinelEntry, *base.BlockError) {
+		return sentinel.Entry("sys-bbr-out", sentinel.WithSlotChain(sc), sentinel.WithTrafficType(base.Outbound))
+	}
+
+	// The inbound traffic out of the scope isn't recorded by the limiter.
+	system_metric.SetSystemCpuUsage(0.1)
+	util.Sleep(100 * time.Millisecond)
+	for i := 0; i < 200; i++ {
+		e, b := entryOf(sc, "sys-bbr-other", base.ResTypeCommon)
+		assert.Nil(t, b)
+		e.Exit()
+	}
+
+	// The outbound traffic in the scope is recorded: 30 requests whose RT is 50ms in a bucket.
+	util.Sleep(100 * time.Millisecond)
+	entries := make([]*base.SentinelEntry, 0, 30)
+	for i := 0; i < 30; i++ {
+		e, b := outbound()
+		assert.Nil(t, b)
+		entries = append(entries, e)
+	}
+	util.Sleep(50 * time.Millisecond)
+	for _, e := range entries {
+		e.Exit()
+	}
+
+	// The estimated capacity of the scope is 30 * 10 * 50 / 1000 = 15.
+	util.Sleep(100 * time.Millisecond)
+	system_metric.SetSystemCpuUsage(0.9)
+	entries = entries[:0]
+	for i := 0; i < 16; i++ {
+		e, b := outbound()
+		assert.Nil(t, b)
+		entries = append(entries, e)
+	}
+	_, b := outbound()
+	assert.NotNil(t, b)
+	for _, e := range entries {
+		e.Exit()
+	}
+}
+
+func TestBBRLimiterConcurrentCompletion(t *testing.T) {
+	defer system_metric.SetSystemCpuUsage(system_metric.NotRetrievedCpuUsageValue)
+	_, err := system.LoadRules([]*system.Rule{
+		{
+			MetricType:     system.CpuUsage,
+			TriggerCount:   0.8,
+			Strategy:       system.BBRLimiter,
+			BbrWindowMs:    1000,
+			BbrBucketCount: 10,
+		},
+	})
+	assert.Nil(t, err)
+	defer system.ClearRules()
+	sc := newSystemSlotChain()
+	system_metric.SetSystemCpuUsage(0.1)
+
+	// The completed requests are recorded concurrently without blocking each other.
+	var blocked int32
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				e, b := entryOf(sc, "sys-bbr-concurrent", base.ResTypeCommon)
+				if b != nil {
+					atomic.AddInt32(&blocked, 1)
+					continue
+				}
+				e.Exit()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(0), atomic.LoadInt32(&blocked))
+}
+
+func TestCustomSignalSystemRule(t *testing.T) {
+	signal := "sys-queue-depth"
+	defer system_metric.RemoveMetricProvider(signal)
+	_, err := system.LoadRules([]*system.Rule{
+		{
+			MetricType:   system.CustomSignal,
+			Signal:       signal,
+			TriggerCount: 100,
+		},
+	})
+	assert.Nil(t, err)
+	defer system.ClearRules()
+	sc := newSystemSlotChain()
+
+	// The signal is not retrieved yet.
+	e, b := entryOf(sc, "sys-custom", base.ResTypeCommon)
+	assert.Nil(t, b)
+	e.Exit()
+
+	system_metric.SetCustomSignal(signal, 50)
+	e, b = entryOf(sc, "sys-custom", base.ResTypeCommon)
+	assert.Nil(t, b)
+	e.Exit()
+
+	system_metric.SetCustomSignal(signal, 150)
+	_, b = entryOf(sc, "sys-custom", base.ResTypeCommon)
+	assert.NotNil(t, b)
+	assert.Equal(t, signal, b.TriggeredRule().ResourceName())
+}